@@ -0,0 +1,47 @@
+/***************************************************************************
+        Distributed Architecture for Judiciary Processes Distribution
+        ===== judiciary: court, district and trial agents ====
+
+   Usage: judiciary <court|district|trial> [agent's flags]
+
+***************************************************************************/
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"judiciary/internal/court"
+	"judiciary/internal/district"
+	"judiciary/internal/trial"
+)
+
+// Agents available in the binary (first argument of the command line)
+var agents = map[string]func(args []string){
+	"court":    court.Main,
+	"district": district.Main,
+	"trial":    trial.Main,
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: judiciary <court|district|trial> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "  judiciary court    [-h] ...    runs the court")
+	fmt.Fprintln(os.Stderr, "  judiciary district [-h] ...    runs a district")
+	fmt.Fprintln(os.Stderr, "  judiciary trial    [-h] ...    runs a trial")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	run, ok := agents[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown agent: %s\n\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+	run(os.Args[2:])
+}
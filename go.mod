@@ -0,0 +1,3 @@
+module judiciary
+
+go 1.24
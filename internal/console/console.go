@@ -0,0 +1,44 @@
+// Package console has the terminal helpers shared by the agents' menus.
+package console
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"runtime"
+)
+
+func ClearScreen() {
+	//fmt.Print("\033[2J\033[H")
+
+	switch runtime.GOOS {
+	case "windows":
+		// For cmd / PowerShell
+		cmd := exec.Command("cmd", "/c", "cls")
+		cmd.Stdout = os.Stdout
+		_ = cmd.Run()
+	default:
+		// Linux, macOS, MSYS2, etc.
+		cmd := exec.Command("clear")
+		cmd.Stdout = os.Stdout
+		if err := cmd.Run(); err != nil {
+			// If error, goes to ANSI scape
+			fmt.Print("\033[2J\033[H")
+		}
+	}
+}
+
+// Name of the operator of the menu (user of the system), for the records of
+// who made a change
+func UserName() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	for _, env := range []string{"USER", "USERNAME"} {
+		if name := os.Getenv(env); name != "" {
+			return name
+		}
+	}
+	return "unknown"
+}
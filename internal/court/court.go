@@ -0,0 +1,732 @@
+/***************************************************************************
+	Distributed Architecture for Judiciary Processes Distribution
+	===== Court Agent ====
+
+	Authors: 
+	        Antonio Gilberto de Moura (A - AGM)
+		Fernado Maurício Gomes (F - FMG)
+
+        Rel 1.1.0
+
+Revision History for court.go:
+
+   Release   Author   Date           Description
+    1.0.0    A/F      19/Nov/2025    Initial stable release
+    1.1.0    A        28/Jan/2026    Translation to English
+
+***************************************************************************/
+
+package court
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"judiciary/internal/console"
+	"judiciary/internal/protocol"
+	"judiciary/internal/storage"
+	"judiciary/internal/transport"
+)
+
+// Release identification
+const Release = "1.1.0" // Translation to English
+
+// Identity of the court in the envelopes
+const localSender = "court"
+
+
+// ---------- Data Structures ----------
+
+type DistrictList struct {
+	mu    sync.RWMutex
+	Items []protocol.District
+	store storage.Backend
+
+	// IDs given and removed districts (see DistrictIDs)
+	ids DistrictIDs
+}
+
+// Keys of the districts' list and of the IDs' registry in the storage (json:
+// districts.json and district_ids.json)
+const (
+	keyDistricts   = "districts"
+	keyDistrictIDs = "district_ids"
+)
+
+// Lawsuit IDs are "district.trial.seq": a new district with the ID of a removed
+// one would issue the IDs of lawsuits still cited by other lawsuits. The next
+// ID only goes up and each removed district leaves a tombstone; its ID is never
+// given again.
+type DistrictIDs struct {
+	NextID  int               `json:"next_id"`
+	Retired []RetiredDistrict `json:"retired,omitempty"`
+}
+
+type RetiredDistrict struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Address   string    `json:"address"`
+	RemovedAt time.Time `json:"removed_at"`
+}
+
+
+// ---------- Functions ----------
+
+func NewDistrictList(store storage.Backend) *DistrictList {
+	return &DistrictList{
+		Items: make([]protocol.District, 0),
+		store: store,
+	}
+}
+
+func (dl *DistrictList) Load() error {
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+
+	var items []protocol.District
+	found, err := dl.store.Get(keyDistricts, &items)
+	if err != nil {
+		return err
+	}
+	if found {
+		dl.Items = items
+	}
+	var ids DistrictIDs
+	if _, err := dl.store.Get(keyDistrictIDs, &ids); err != nil {
+		return err
+	}
+	dl.ids = ids
+	// previous versions kept no registry: the next ID comes after the greater one
+	for _, d := range dl.Items {
+		if d.ID >= dl.ids.NextID {
+			dl.ids.NextID = d.ID + 1
+		}
+	}
+	for _, r := range dl.ids.Retired {
+		if r.ID >= dl.ids.NextID {
+			dl.ids.NextID = r.ID + 1
+		}
+	}
+	return nil
+}
+
+// The list and the IDs' registry, in one transaction
+func (dl *DistrictList) Save() error {
+	dl.mu.RLock()
+	defer dl.mu.RUnlock()
+
+	return dl.store.Update(func(tx storage.Tx) error {
+		if err := tx.Put(keyDistricts, dl.Items); err != nil {
+			return err
+		}
+		return tx.Put(keyDistrictIDs, dl.ids)
+	})
+}
+
+// Generate the next district ID: never one given before, even if its district
+// was removed (dl.mu must be locked)
+func (dl *DistrictList) nextID() int {
+	if dl.ids.NextID < 1 {
+		dl.ids.NextID = 1
+	}
+	id := dl.ids.NextID
+	dl.ids.NextID++
+	return id
+}
+
+// Removed district with the ID (dl.mu must be locked)
+func (dl *DistrictList) retiredLocked(id int) (RetiredDistrict, bool) {
+	for _, r := range dl.ids.Retired {
+		if r.ID == id {
+			return r, true
+		}
+	}
+	return RetiredDistrict{}, false
+}
+
+// Add generates and returns a district with ID defined; an ID already given
+// (in use or of a removed district) is refused
+func (dl *DistrictList) Add(d protocol.District) (protocol.District, error) {
+	dl.mu.Lock()
+	if d.ID == 0 {
+		d.ID = dl.nextID()
+	} else {
+		if r, ok := dl.retiredLocked(d.ID); ok {
+			dl.mu.Unlock()
+			return protocol.District{}, fmt.Errorf("district ID %d was retired (district %s removed): IDs are not reused", d.ID, r.Name)
+		}
+		for _, x := range dl.Items {
+			if x.ID == d.ID {
+				dl.mu.Unlock()
+				return protocol.District{}, fmt.Errorf("district ID %d is in use by %s", d.ID, x.Name)
+			}
+		}
+		if d.ID >= dl.ids.NextID {
+			dl.ids.NextID = d.ID + 1
+		}
+	}
+	dl.Items = append(dl.Items, d)
+	dl.mu.Unlock()
+
+	if err := dl.Save(); err != nil {
+		return protocol.District{}, err
+	}
+	return d, nil
+}
+
+func (dl *DistrictList) RemoveByName(name string) (*protocol.District, error) {
+	dl.mu.Lock()
+	idx := -1
+	var removed protocol.District
+	for i, d := range dl.Items {
+		if d.Name == name {
+			idx = i
+			removed = d
+			break
+		}
+	}
+	if idx == -1 {
+		dl.mu.Unlock()
+		return nil, errors.New("district not found")
+	}
+	dl.Items = append(dl.Items[:idx], dl.Items[idx+1:]...)
+	dl.ids.Retired = append(dl.ids.Retired, RetiredDistrict{ID: removed.ID, Name: removed.Name, Address: removed.Address, RemovedAt: time.Now()})
+	dl.mu.Unlock()
+
+	if err := dl.Save(); err != nil {
+		return nil, err
+	}
+	return &removed, nil
+}
+
+func (dl *DistrictList) UpdateTrials(name string, trials int) (*protocol.District, error) {
+	dl.mu.Lock()
+	idx := -1
+	for i, d := range dl.Items {
+		if d.Name == name {
+			dl.Items[i].Trials = trials 
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		dl.mu.Unlock()
+		return nil, errors.New("district not found")
+	}
+	updated := dl.Items[idx]
+	dl.mu.Unlock()
+
+	if err := dl.Save(); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (dl *DistrictList) GetByName(name string) *protocol.District {
+	dl.mu.RLock()
+	defer dl.mu.RUnlock()
+
+	for _, d := range dl.Items {
+		if d.Name == name {
+			dp := d
+			return &dp
+		}
+	}
+	return nil
+}
+
+func (dl *DistrictList) ListExcept(addr string) []protocol.District {
+	dl.mu.RLock()
+	defer dl.mu.RUnlock()
+
+	res := make([]protocol.District, 0, len(dl.Items))
+	for _, d := range dl.Items {
+		if d.Address != addr {
+			res = append(res, d)
+		}
+	}
+	return res
+}
+
+
+// ---------- Lawsuit fingerprint leases ----------
+
+// While a district verifies and files a lawsuit, it holds a lease on the
+// lawsuit's fingerprint (hash of plaintiff, defendant, cause and claims),
+// so that the same lawsuit filed at the same time in another district is
+// refused instead of passing every stage too.
+// Leases are kept only in memory: after expiration they are free again.
+
+const (
+	defaultLeaseTTL = 2 * time.Minute
+	maxLeaseTTL     = 10 * time.Minute
+)
+
+type LeaseTable struct {
+	mu     sync.Mutex
+	leases map[string]protocol.Lease
+}
+
+func NewLeaseTable() *LeaseTable {
+	return &LeaseTable{leases: make(map[string]protocol.Lease)}
+}
+
+func newLeaseToken() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
+
+// Acquire grants the lease if it is free or expired, with the token of the
+// request (the district creates it; without one, a new token); with the token
+// of the current lease it is renewed. Otherwise returns the current lease and false.
+func (lt *LeaseTable) Acquire(fingerprint, holder, token string, ttl time.Duration) (protocol.Lease, bool) {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+
+	now := time.Now()
+	if cur, ok := lt.leases[fingerprint]; ok && now.Before(cur.ExpiresAt) && cur.Token != token {
+		return cur, false
+	}
+	if token == "" {
+		token = newLeaseToken()
+	}
+	l := protocol.Lease{Fingerprint: fingerprint, Holder: holder, Token: token, ExpiresAt: now.Add(ttl)}
+	lt.leases[fingerprint] = l
+	return l, true
+}
+
+// Release frees the lease only for the holder of the token
+func (lt *LeaseTable) Release(fingerprint, token string) bool {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+
+	cur, ok := lt.leases[fingerprint]
+	if !ok || cur.Token != token {
+		return false
+	}
+	delete(lt.leases, fingerprint)
+	return true
+}
+
+// Purge removes the expired leases and returns how many were removed
+func (lt *LeaseTable) Purge() int {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+
+	now := time.Now()
+	n := 0
+	for fp, l := range lt.leases {
+		if !now.Before(l.ExpiresAt) {
+			delete(lt.leases, fp)
+			n++
+		}
+	}
+	return n
+}
+
+func (lt *LeaseTable) List() []protocol.Lease {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+
+	res := make([]protocol.Lease, 0, len(lt.leases))
+	for _, l := range lt.leases {
+		res = append(res, l)
+	}
+	return res
+}
+
+
+// ---------- Protocol (messages from the districts) ----------
+
+func handlePacket(w transport.Replier, data []byte, dl *DistrictList, lt *LeaseTable) {
+	log.Printf("[REQ] %s - package received from %s (%d bytes)",
+		time.Now().Format(time.RFC3339), w.Remote(), len(data))
+
+	var req protocol.Request
+	if err := json.Unmarshal(data, &req); err != nil {
+		log.Printf("[ERR] %s - error for requisition decodification from %s: %v",
+			time.Now().Format(time.RFC3339), w.Remote(), err)
+		sendResponse(w, req.Envelope, protocol.Response{Success: false, Message: "error for requisition decodification"})
+		return
+	}
+
+	log.Printf("[REQ] %s - trace=%s from %s: type=%q name=%q trials=%d",
+		time.Now().Format(time.RFC3339), req.TraceID, w.Remote(), req.Type, req.Name, req.Trials)
+
+	switch req.Type {
+
+	case "list":
+		districts := dl.ListExcept(w.Remote())
+		sendResponse(w, req.Envelope, protocol.Response{Success: true, Message: "ok", Districts: districts})
+
+	case "create":
+		if req.Name == "" || req.Trials <= 0 {
+			sendResponse(w, req.Envelope, protocol.Response{Success: false, Message: "fields 'name' and 'trials' are required"})
+			return
+		}
+		existing := dl.GetByName(req.Name)
+		if existing != nil {
+			sendResponse(w, req.Envelope, protocol.Response{Success: true, Message: "district already existent", District: existing})
+			return
+		}
+		new_d := protocol.District{Name: req.Name, Address: w.Remote(), Trials: req.Trials}
+		new_d, err := dl.Add(new_d)
+		if err != nil {
+			sendResponse(w, req.Envelope, protocol.Response{Success: false, Message: err.Error()})
+			return
+		}
+		sendResponse(w, req.Envelope, protocol.Response{Success: true, Message: "district created", District: &new_d})
+
+	case "remove":
+		if req.Name == "" {
+			sendResponse(w, req.Envelope, protocol.Response{Success: false, Message: "field 'name' is required"})
+			return
+		}
+		removed, err := dl.RemoveByName(req.Name)
+		if err != nil {
+			sendResponse(w, req.Envelope, protocol.Response{Success: false, Message: err.Error()})
+			return
+		}
+		sendResponse(w, req.Envelope, protocol.Response{Success: true, Message: "district removed", District: removed})
+
+	case "update_trials":
+		if req.Name == "" {
+			sendResponse(w, req.Envelope, protocol.Response{Success: false, Message: "field 'name' is required"})
+			return
+		}
+		updated, err := dl.UpdateTrials(req.Name, req.Trials)
+		if err != nil {
+			sendResponse(w, req.Envelope, protocol.Response{Success: false, Message: err.Error()})
+			return
+		}
+		sendResponse(w, req.Envelope, protocol.Response{Success: true, Message: "trials number updated", District: updated})
+
+	case "lease_acquire", "lease_release":
+		handleLease(w, data, lt)
+
+	default:
+		sendResponse(w, req.Envelope, protocol.Response{Success: false, Message: "unknown type of request"})
+	}
+}
+
+// The response goes with the envelope answering the request reqEnv
+func sendResponse(w transport.Replier, reqEnv protocol.Envelope, resp protocol.Response) {
+	resp.Envelope = reqEnv.Reply(localSender)
+	b, err := json.Marshal(resp)
+	if err != nil {
+		return
+	}
+	w.Reply(b)
+
+	log.Printf("[RESP] %s - trace=%s to %s: success=%v msg=%q districts=%d",
+		time.Now().Format(time.RFC3339), reqEnv.TraceID, w.Remote(),
+		resp.Success, resp.Message, len(resp.Districts))
+}
+
+func handleLease(w transport.Replier, data []byte, lt *LeaseTable) {
+	var req protocol.LeaseRequest
+	if err := json.Unmarshal(data, &req); err != nil || req.Fingerprint == "" {
+		sendLeaseResponse(w, protocol.LeaseResponse{Envelope: req.Reply(localSender), Success: false, Message: "field 'fingerprint' is required"})
+		return
+	}
+
+	if req.Type == "lease_release" {
+		if lt.Release(req.Fingerprint, req.Token) {
+			sendLeaseResponse(w, protocol.LeaseResponse{Envelope: req.Reply(localSender), Success: true, Message: "lease released"})
+		} else {
+			sendLeaseResponse(w, protocol.LeaseResponse{Envelope: req.Reply(localSender), Success: false, Message: "lease not held with this token"})
+		}
+		return
+	}
+
+	ttl := time.Duration(req.TTLMs) * time.Millisecond
+	if ttl <= 0 {
+		ttl = defaultLeaseTTL
+	}
+	if ttl > maxLeaseTTL {
+		ttl = maxLeaseTTL
+	}
+	holder := req.Holder
+	if holder == "" {
+		holder = w.Remote()
+	}
+
+	l, ok := lt.Acquire(req.Fingerprint, holder, req.Token, ttl)
+	if !ok {
+		sendLeaseResponse(w, protocol.LeaseResponse{Envelope: req.Reply(localSender), Success: false, Message: "lease held by " + l.Holder, Lease: &l})
+		return
+	}
+	sendLeaseResponse(w, protocol.LeaseResponse{Envelope: req.Reply(localSender), Success: true, Message: "lease granted", Lease: &l})
+}
+
+func sendLeaseResponse(w transport.Replier, resp protocol.LeaseResponse) {
+	b, err := json.Marshal(resp)
+	if err != nil {
+		return
+	}
+	w.Reply(b)
+
+	holder := ""
+	if resp.Lease != nil {
+		holder = resp.Lease.Holder
+	}
+	log.Printf("[RESP] %s - trace=%s to %s: success=%v msg=%q lease_holder=%q",
+		time.Now().Format(time.RFC3339), resp.TraceID, w.Remote(),
+		resp.Success, resp.Message, holder)
+}
+
+
+// ---------- Menu throught keyboard ----------
+func startMenu(dl *DistrictList, lt *LeaseTable, quit chan bool) {
+	reader := bufio.NewReader(os.Stdin)
+
+	for {
+		fmt.Println() 
+		fmt.Println("========== COURT OF JUSTICE ==========")
+		fmt.Println("1 (L) - Show districts list")
+		fmt.Println("2 (A) - Add a new district")
+		fmt.Println("3 (D) - Delete a district")
+		fmt.Println("4 (Q) - Quit")
+		fmt.Println("5 (R) - Refresh (clear the screen)")
+		fmt.Println("6 (F) - Show lawsuits being filed (fingerprint leases)")
+		fmt.Print("Your option> ")
+
+		line, _ := reader.ReadString('\n')
+		opt := strings.TrimSpace(line)
+
+		switch opt {
+
+		case "5","r", "R":
+			console.ClearScreen()
+			continue
+
+		case "1","l","L":
+			dl.mu.RLock()
+			fmt.Println("\n--- DISTRICTS ---")
+			if len(dl.Items) == 0 {
+				fmt.Println("(empty list)")
+			} else {
+				for _, d := range dl.Items {
+					fmt.Printf("ID %d | %s | %s | %d trials\n",
+						d.ID, d.Name, d.Address, d.Trials)
+				}
+			}
+			if len(dl.ids.Retired) > 0 {
+				fmt.Println("\n--- REMOVED DISTRICTS (IDs not reused) ---")
+				for _, r := range dl.ids.Retired {
+					fmt.Printf("ID %d | %s | %s | Removed at %s\n",
+						r.ID, r.Name, r.Address, r.RemovedAt.Local().Format("2006-01-02 15:04:05"))
+				}
+			}
+			dl.mu.RUnlock()
+
+			fmt.Print("\nPress ENTER to return to menu...")
+			reader.ReadString('\n')
+			console.ClearScreen()
+
+		case "2", "a", "A":
+			fmt.Print("District's name: ")
+			name, _ := reader.ReadString('\n')
+			name = strings.TrimSpace(name)
+
+			fmt.Print("District's UDP address: ")
+			add, _ := reader.ReadString('\n')
+			add = strings.TrimSpace(add)
+
+			fmt.Print("Number of trials: ")
+			ts, _ := reader.ReadString('\n')
+			ts = strings.TrimSpace(ts)
+			trials, err := strconv.Atoi(ts)
+			if err != nil || trials < 0 {
+				fmt.Println("Invalid number of trials.")
+
+				fmt.Print("\nPress ENTER to return to menu...")
+				reader.ReadString('\n')
+				console.ClearScreen()
+				continue
+			}
+
+			d := protocol.District{Name: name, Address: add, Trials: trials}
+			d, err = dl.Add(d)
+			if err != nil {
+				fmt.Println("Error:", err)
+			} else {
+				fmt.Printf("District added: ID %d\n", d.ID)
+			}
+
+			fmt.Print("\nPress ENTER to return to menu...")
+			reader.ReadString('\n')
+			console.ClearScreen()
+
+		case "3", "d", "D":
+			fmt.Print("Name of the district to be removed: ")
+			name, _ := reader.ReadString('\n')
+			name = strings.TrimSpace(name)
+			removed, err := dl.RemoveByName(name)
+			if err != nil {
+				fmt.Println("Error:", err)
+			} else {
+				fmt.Printf("District removed: ID %d | %s\n", removed.ID, removed.Name)
+			}
+
+			fmt.Print("\nPress ENTER to return to menu...")
+			reader.ReadString('\n')
+			console.ClearScreen()
+
+		case "6", "f", "F":
+			fmt.Println("\n--- LAWSUITS BEING FILED ---")
+			leases := lt.List()
+			if len(leases) == 0 {
+				fmt.Println("(empty list)")
+			}
+			for _, l := range leases {
+				state := "expires " + l.ExpiresAt.Format(time.RFC3339)
+				if !time.Now().Before(l.ExpiresAt) {
+					state = "expired"
+				}
+				fmt.Printf("%s... | district %s | %s\n", l.Fingerprint[:min(12, len(l.Fingerprint))], l.Holder, state)
+			}
+
+			fmt.Print("\nPress ENTER to return to menu...")
+			reader.ReadString('\n')
+			console.ClearScreen()
+
+		case "4", "q", "Q":
+			if err := dl.Save(); err != nil {
+				fmt.Println("Saving error:", err)
+			}
+			quit <- true
+			return
+
+		default:
+			fmt.Println("Invalid Option.")
+			fmt.Print("\nPress ENTER to return to menu...")
+			reader.ReadString('\n')
+			console.ClearScreen()
+		}
+	}
+}
+
+
+// ---------- MAIN ----------
+
+// Main runs the court agent with the command line arguments (after "court")
+func Main(args []string) {
+	fs := flag.NewFlagSet("court", flag.ExitOnError)
+	helpFlag := fs.Bool("h", false, "Show help")
+	infoFlag := fs.Bool("info", false, "Show information about option flags")
+	addrFlag := fs.String("addr", "", "Court's address (default :9000)")
+	transportFlag := fs.String("transport", "udp", "Transport of the messages: udp, tcp, unix or mem")
+	logFlag := fs.String("log", "", "Log file (or 'term' to log to terminal; default: court.log)")
+	storageFlag := fs.String("storage", "json", "Storage of the districts' list: json (districts.json) or kv (court.db)")
+	fs.Parse(args)
+
+	if *helpFlag {
+		fmt.Println("Program used to simulate the decentralization of the procedure for filing")
+		fmt.Println("a new civil lawsuit in one of the existing trials in the various districts")
+	        fmt.Println("of the Court of Justice of the State of São Paulo.")
+		fmt.Println("\n Release:", Release)
+		fmt.Println()
+		fmt.Println("Usage: judiciary court [-h] [-info] [-addr <address>] [-transport <udp|tcp|unix|mem>]")
+		fmt.Println("            [-log <file|term>] [-storage <json|kv>]")
+		return
+	}
+
+	// Uses -info as the default behavior for -h
+	if *infoFlag {
+		fs.Usage()
+		os.Exit(0)
+	}
+
+	// LOG configuration
+	if *logFlag == "" {
+		logFile, err := os.OpenFile("court.log",
+			os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			fmt.Println("Error after trying to open the default log file (court.log):", err)
+		} else {
+			log.SetOutput(logFile)
+		}
+	} else if *logFlag == "term" {
+		// default out (stderr)
+	} else {
+		logFile, err := os.OpenFile(*logFlag,
+			os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			fmt.Println("Error after trying to open the log file:", err)
+		} else {
+			log.SetOutput(logFile)
+		}
+	}
+
+	tport, err := transport.ByName(*transportFlag)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+
+	udpAddr := ":9000"
+	if strings.TrimSpace(*addrFlag) != "" {
+		udpAddr = strings.TrimSpace(*addrFlag)
+	}
+
+	files := map[string]string{keyDistricts: "districts.json", keyDistrictIDs: "district_ids.json"}
+	store, err := storage.Open(*storageFlag, "court.db", files)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	defer store.Close()
+	if err := storage.Import(store, files); err != nil {
+		fmt.Println("Error after trying to import districts.json and district_ids.json:", err)
+	}
+
+	dl := NewDistrictList(store)
+	if err := dl.Load(); err != nil {
+		fmt.Println("Error after trying to load districts list from the disc:", err)
+	}
+
+	console.ClearScreen()
+	time.Sleep(100 * time.Millisecond)
+	console.ClearScreen()
+	fmt.Println("Court Server running in", udpAddr)
+	time.Sleep(2000 * time.Millisecond)
+	console.ClearScreen()
+		
+	lt := NewLeaseTable()
+	go func() {
+		for range time.Tick(30 * time.Second) {
+			if n := lt.Purge(); n > 0 {
+				log.Printf("[LEASE] %s - %d expired lease(s) removed", time.Now().Format(time.RFC3339), n)
+			}
+		}
+	}()
+
+	quit := make(chan bool)
+	go startMenu(dl, lt, quit)
+
+	ln, err := transport.Listen(tport, udpAddr, func(w transport.Replier, data []byte) {
+		go handlePacket(w, data, dl, lt)
+	})
+	if err != nil {
+		fmt.Println("Error after trying to open "+tport.Name()+":", err)
+		return
+	}
+	defer ln.Close()
+
+	<-quit
+}
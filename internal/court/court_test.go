@@ -0,0 +1,60 @@
+package court
+
+import (
+	"path/filepath"
+	"testing"
+
+	"judiciary/internal/protocol"
+	"judiciary/internal/storage"
+)
+
+func newTestDistrictList(t *testing.T, dir string) *DistrictList {
+	t.Helper()
+	dl := NewDistrictList(storage.NewJSONFiles(map[string]string{
+		keyDistricts:   filepath.Join(dir, "districts.json"),
+		keyDistrictIDs: filepath.Join(dir, "district_ids.json"),
+	}))
+	if err := dl.Load(); err != nil {
+		t.Fatal(err)
+	}
+	return dl
+}
+
+// The ID of a removed district is not given again, also after a restart, and
+// is refused when asked for
+func TestDistrictIDsNotReused(t *testing.T) {
+	dir := t.TempDir()
+	dl := newTestDistrictList(t, dir)
+	for i, name := range []string{"A", "B", "C"} {
+		if d, err := dl.Add(protocol.District{Name: name}); err != nil || d.ID != i+1 {
+			t.Fatalf("Add %s: %+v, %v", name, d, err)
+		}
+	}
+	if _, err := dl.RemoveByName("C"); err != nil {
+		t.Fatal(err)
+	}
+	if d, _ := dl.Add(protocol.District{Name: "D"}); d.ID != 4 {
+		t.Fatalf("ID after removing the greatest: %d, want 4", d.ID)
+	}
+
+	dl = newTestDistrictList(t, dir)
+	if d, _ := dl.Add(protocol.District{Name: "E"}); d.ID != 5 {
+		t.Fatalf("ID after the restart: %d, want 5", d.ID)
+	}
+	if _, err := dl.Add(protocol.District{Name: "C", ID: 3}); err == nil {
+		t.Fatal("retired ID 3 given again")
+	}
+	if _, err := dl.Add(protocol.District{Name: "F", ID: 2}); err == nil {
+		t.Fatal("ID 2 given twice")
+	}
+	// an ID asked for above the next one moves the next one
+	if d, err := dl.Add(protocol.District{Name: "G", ID: 9}); err != nil || d.ID != 9 {
+		t.Fatalf("Add with ID 9: %+v, %v", d, err)
+	}
+	if d, _ := dl.Add(protocol.District{Name: "H"}); d.ID != 10 {
+		t.Fatalf("ID after 9: %d, want 10", d.ID)
+	}
+	if n := len(newTestDistrictList(t, dir).Items); n != 6 {
+		t.Fatalf("%d districts after the restart, want 6", n)
+	}
+}
@@ -0,0 +1,3618 @@
+/***************************************************************************
+        Distributed Architecture for Judiciary Processes Distribution
+        ===== District Agent ====
+
+        Authors:
+                Antonio Gilberto de Moura (A - AGM)
+                Fernado Maurício Gomes (F - FMG)
+
+        Rel 1.1.0
+
+Revision History for court.go:
+
+   Release   Author   Date           Description
+    1.0.0    A/F      19/Nov/2025    Initial stable release
+    1.1.0    A        28/Jan/2026    Translation to English
+
+***************************************************************************/
+
+package district
+
+import (
+	"bufio"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"judiciary/internal/console"
+	"judiciary/internal/persist"
+	"judiciary/internal/protocol"
+	"judiciary/internal/query"
+	"judiciary/internal/storage"
+	"judiciary/internal/transport"
+)
+
+// Release identification
+const Release = "1.1.0" // Translation to English
+
+// Identity of this district in the envelopes and name in the lawsuits' history (set in Main)
+var localSender = "district"
+var localName = ""
+
+// Transport used with the court, trials and other districts (-transport flag)
+var tport transport.Transport = transport.UDP{}
+
+
+// ---------- Local list of districts (mirror of Court) ----------
+
+type DistrictList struct {
+	mu    sync.RWMutex
+	Items []protocol.District
+	store storage.Backend
+}
+
+// Keys of the lists in the storage (json: -districts and -trials files)
+const (
+	keyDistricts     = "districts"
+	keyTrials        = "trials"
+	keyTrialIDs      = "trial_ids"
+	keyCompensations = "compensations"
+)
+
+func NewDistrictList(store storage.Backend) *DistrictList {
+	return &DistrictList{
+		Items: make([]protocol.District, 0),
+		store: store,
+	}
+}
+
+func (dl *DistrictList) Load() error {
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+
+	var items []protocol.District
+	found, err := dl.store.Get(keyDistricts, &items)
+	if err != nil || !found {
+		return err
+	}
+	dl.Items = items
+	return nil
+}
+
+func (dl *DistrictList) Save() error {
+	dl.mu.RLock()
+	defer dl.mu.RUnlock()
+
+	return dl.store.Update(func(tx storage.Tx) error {
+		return tx.Put(keyDistricts, dl.Items)
+	})
+}
+
+func (dl *DistrictList) SetAll(list []protocol.District) error {
+	dl.mu.Lock()
+	dl.Items = list
+	dl.mu.Unlock()
+	return dl.Save()
+}
+
+func (dl *DistrictList) GetAll() []protocol.District {
+	dl.mu.RLock()
+	defer dl.mu.RUnlock()
+	res := make([]protocol.District, len(dl.Items))
+	copy(res, dl.Items)
+	return res
+}
+
+
+// ---------- Local list of district's trials ----------
+
+type Trial struct {
+	ID       int    `json:"id"`
+	Address  string `json:"address"`
+
+	// Being removed (see drainTrial): no lawsuit by free distribution
+	Draining bool `json:"draining,omitempty"`
+}
+
+type TrialList struct {
+	mu    sync.RWMutex
+	Items []Trial
+	store storage.Backend
+
+	// IDs given and removed trials (see TrialIDs)
+	ids TrialIDs
+}
+
+// A new trial with the ID of a removed one would issue lawsuit IDs
+// ("district.trial.seq") of the removed trial, still cited in Connected lists
+// and in other districts: the next ID only goes up and each removed trial
+// leaves a tombstone; its ID is never given again.
+type TrialIDs struct {
+	NextID  int            `json:"next_id"`
+	Retired []RetiredTrial `json:"retired,omitempty"`
+}
+
+type RetiredTrial struct {
+	ID        int       `json:"id"`
+	Address   string    `json:"address"`
+	RemovedAt time.Time `json:"removed_at"`
+}
+
+func NewTrialList(store storage.Backend) *TrialList {
+	return &TrialList{
+		Items: make([]Trial, 0),
+		store: store,
+	}
+}
+
+func (tl *TrialList) Load() error {
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+
+	var items []Trial
+	found, err := tl.store.Get(keyTrials, &items)
+	if err != nil {
+		return err
+	}
+	if found {
+		tl.Items = items
+	}
+	var ids TrialIDs
+	if _, err := tl.store.Get(keyTrialIDs, &ids); err != nil {
+		return err
+	}
+	tl.ids = ids
+	// previous versions kept no registry: the next ID comes after the greater one
+	for _, t := range tl.Items {
+		if t.ID >= tl.ids.NextID {
+			tl.ids.NextID = t.ID + 1
+		}
+	}
+	for _, r := range tl.ids.Retired {
+		if r.ID >= tl.ids.NextID {
+			tl.ids.NextID = r.ID + 1
+		}
+	}
+	return nil
+}
+
+// The list and the IDs' registry, in one transaction
+func (tl *TrialList) Save() error {
+	tl.mu.RLock()
+	defer tl.mu.RUnlock()
+
+	return tl.store.Update(func(tx storage.Tx) error {
+		if err := tx.Put(keyTrials, tl.Items); err != nil {
+			return err
+		}
+		return tx.Put(keyTrialIDs, tl.ids)
+	})
+}
+
+// next ID: never one given before, even if its trial was removed (tl.mu must
+// be locked)
+func (tl *TrialList) nextID() int {
+	if tl.ids.NextID < 1 {
+		tl.ids.NextID = 1
+	}
+	id := tl.ids.NextID
+	tl.ids.NextID++
+	return id
+}
+
+// Removed trial with the ID, if any
+func (tl *TrialList) Retired(id int) (RetiredTrial, bool) {
+	tl.mu.RLock()
+	defer tl.mu.RUnlock()
+	for _, r := range tl.ids.Retired {
+		if r.ID == id {
+			return r, true
+		}
+	}
+	return RetiredTrial{}, false
+}
+
+func (tl *TrialList) GetRetired() []RetiredTrial {
+	tl.mu.RLock()
+	defer tl.mu.RUnlock()
+	return append([]RetiredTrial(nil), tl.ids.Retired...)
+}
+
+func (tl *TrialList) Add(address string) (Trial, error) {
+	tl.mu.Lock()
+	t := Trial{
+		ID:      tl.nextID(),
+		Address: address,
+	}
+	tl.Items = append(tl.Items, t)
+	tl.mu.Unlock()
+
+	if err := tl.Save(); err != nil {
+		return Trial{}, err
+	}
+	return t, nil
+}
+
+func (tl *TrialList) RemoveByID(id int) (Trial, error) {
+	tl.mu.Lock()
+	idx := -1
+	var removed Trial
+	for i, t := range tl.Items {
+		if t.ID == id {
+			idx = i
+			removed = t
+			break
+		}
+	}
+	if idx == -1 {
+		tl.mu.Unlock()
+		return Trial{}, fmt.Errorf("trial with ID %d not found", id)
+	}
+	tl.Items = append(tl.Items[:idx], tl.Items[idx+1:]...)
+	tl.ids.Retired = append(tl.ids.Retired, RetiredTrial{ID: removed.ID, Address: removed.Address, RemovedAt: time.Now()})
+	tl.mu.Unlock()
+
+	if err := tl.Save(); err != nil {
+		return Trial{}, err
+	}
+	return removed, nil
+}
+
+func (tl *TrialList) SetDraining(id int, draining bool) error {
+	tl.mu.Lock()
+	found := false
+	for i := range tl.Items {
+		if tl.Items[i].ID == id {
+			tl.Items[i].Draining = draining
+			found = true
+		}
+	}
+	tl.mu.Unlock()
+	if !found {
+		return fmt.Errorf("trial with ID %d not found", id)
+	}
+	return tl.Save()
+}
+
+func (tl *TrialList) GetAll() []Trial {
+	tl.mu.RLock()
+	defer tl.mu.RUnlock()
+	res := make([]Trial, len(tl.Items))
+	copy(res, tl.Items)
+	return res
+}
+
+func (tl *TrialList) Count() int {
+	tl.mu.RLock()
+	defer tl.mu.RUnlock()
+	return len(tl.Items)
+}
+
+// New: search trial by ID (used by the response to trial_info)
+func (tl *TrialList) FindByID(id int) (Trial, bool) {
+	tl.mu.RLock()
+	defer tl.mu.RUnlock()
+	for _, t := range tl.Items {
+		if t.ID == id {
+			return t, true
+		}
+	}
+	return Trial{}, false
+}
+
+
+// ---------- Compensation of the transfers between trials ----------
+
+// A lawsuit transferred to a trial (see transferLawsuit) is one case more for
+// it, not given by the free distribution. It counts once in the workload of
+// the trial while it is pending there: while active it is one of the active
+// lawsuits, and suspended, archived provisionally or on appeal it is counted
+// by its compensation (the trial tells which ones, see verifyWorkloadTrial).
+// Dismissed or transferred again, it no longer counts.
+type Compensation struct {
+	At        time.Time `json:"at"`
+	LawsuitID string    `json:"lawsuit_id"` // ID in the target trial
+	FormerID  string    `json:"former_id"`  // ID in the source trial
+	FromTrial int       `json:"from_trial"`
+	ToTrial   int       `json:"to_trial"`
+	Reason    string    `json:"reason"`
+	Actor     string    `json:"actor,omitempty"`
+}
+
+type CompensationList struct {
+	mu    sync.RWMutex
+	Items []Compensation
+	store storage.Backend
+}
+
+func NewCompensationList(store storage.Backend) *CompensationList {
+	return &CompensationList{
+		Items: make([]Compensation, 0),
+		store: store,
+	}
+}
+
+func (cl *CompensationList) Load() error {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+
+	var items []Compensation
+	found, err := cl.store.Get(keyCompensations, &items)
+	if err != nil || !found {
+		return err
+	}
+	cl.Items = items
+	return nil
+}
+
+func (cl *CompensationList) Save() error {
+	cl.mu.RLock()
+	defer cl.mu.RUnlock()
+
+	return cl.store.Update(func(tx storage.Tx) error {
+		return tx.Put(keyCompensations, cl.Items)
+	})
+}
+
+// Keeps the entry, unless the same transfer is already there (transfer repeated
+// after a fault)
+func (cl *CompensationList) Add(c Compensation) error {
+	cl.mu.Lock()
+	for _, x := range cl.Items {
+		if x.FormerID == c.FormerID && x.LawsuitID == c.LawsuitID {
+			cl.mu.Unlock()
+			return nil
+		}
+	}
+	cl.Items = append(cl.Items, c)
+	cl.mu.Unlock()
+
+	return cl.Save()
+}
+
+func (cl *CompensationList) GetAll() []Compensation {
+	cl.mu.RLock()
+	defer cl.mu.RUnlock()
+	res := make([]Compensation, len(cl.Items))
+	copy(res, cl.Items)
+	return res
+}
+
+// IDs (in the target trial) of the lawsuits received by transfer by each trial
+func (cl *CompensationList) ByTrial() map[int][]string {
+	cl.mu.RLock()
+	defer cl.mu.RUnlock()
+	res := map[int][]string{}
+	for _, c := range cl.Items {
+		res[c.ToTrial] = append(res[c.ToTrial], c.LawsuitID)
+	}
+	return res
+}
+
+
+// ---------- Persistence for district's NAME and ADDRESS ----------
+
+const nameDistrictFile = "district_name.txt"
+const addrDistrictFile = "district_addr.txt"
+
+func loadDistrictName(path string) string {
+	name, err := persist.LoadLine(path)
+	if err != nil {
+		log.Printf("Error after trying to read the names' file for district (%s): %v", path, err)
+	}
+	return name
+}
+
+func saveNameDistrict(path, name string) {
+	if err := persist.SaveLine(path, name); err != nil {
+		log.Printf("Error after trying to save the district's name in %s: %v", path, err)
+	}
+}
+
+func loadDistrictAddress(path string) string {
+	addr, err := persist.LoadLine(path)
+	if err != nil {
+		log.Printf("Error after trying to read the address' file for district (%s): %v", path, err)
+	}
+	return addr
+}
+
+func saveAddressDistrict(path, addr string) {
+	if err := persist.SaveLine(path, addr); err != nil {
+		log.Printf("Error after trying to save the district's address in %s: %v", path, err)
+	}
+}
+
+
+// ---------- Communication with the Court ----------
+
+// The request keeps the trace of the caller (req.TraceID), if any
+func sendToCourt(courtAddr string, req protocol.Request) (protocol.Response, error) {
+	var resp protocol.Response
+	req.Envelope = protocol.NewEnvelope(localSender, req.TraceID)
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return resp, fmt.Errorf("error while coding JSON: %v", err)
+	}
+
+	log.Printf("[DISTRICT->COURT] %s - trace=%s sending req type=%q name=%q trials=%d to %s",
+		time.Now().Format(time.RFC3339), req.TraceID,
+		req.Type, req.Name, req.Trials,
+		courtAddr,
+	)
+
+	reply, err := protocol.Exchange(tport, courtAddr, data, req.MsgID, time.Now().Add(2*time.Second))
+	if err != nil {
+		return resp, fmt.Errorf("error while receiving response from the Court: %v", err)
+	}
+
+	if err := json.Unmarshal(reply, &resp); err != nil {
+		return resp, fmt.Errorf("error while decoding response JSON: %v", err)
+	}
+
+	log.Printf("[COURT->DISTRICT] %s - trace=%s response success=%v msg=%q districts=%d",
+		time.Now().Format(time.RFC3339), req.TraceID,
+		resp.Success, resp.Message, len(resp.Districts),
+	)
+
+	return resp, nil
+}
+
+func updateDistrictsOfCourt(courtAddr string, dl *DistrictList) error {
+	req := protocol.Request{Type: "list"}
+	resp, err := sendToCourt(courtAddr, req)
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf("court responded with error: %s", resp.Message)
+	}
+	if err := dl.SetAll(resp.Districts); err != nil {
+		return fmt.Errorf("error while saving local districts' list: %v", err)
+	}
+	return nil
+}
+
+func sendUpdateTrials(courtAddr, nameDistrict string, totalTrials int) error {
+	req := protocol.Request{
+		Type:  "update_trials",
+		Name:  nameDistrict,
+		Trials: totalTrials,
+	}
+	_, err := sendToCourt(courtAddr, req)
+	return err
+}
+
+
+// ---------- Lawsuit fingerprint lease (Court) ----------
+
+// Time the lease is kept in the Court without renewal (covers the clerk's confirmation)
+const leaseTTL = 2 * time.Minute
+
+// Canonical fingerprint of the lawsuit: the same plaintiff, defendant, cause and
+// claims (case, spaces, order and repetition of claims ignored) give the same value,
+// the same criteria used by the trials for identical lawsuits
+func lawsuitFingerprint(lawsuit NewLawsuit) string {
+	claims := append([]int(nil), lawsuit.Claims...)
+	sort.Ints(claims)
+	var sb strings.Builder
+	sb.WriteString(strings.ToLower(strings.TrimSpace(lawsuit.Plaintiff)))
+	sb.WriteByte(0x1f)
+	sb.WriteString(strings.ToLower(strings.TrimSpace(lawsuit.Defendant)))
+	sb.WriteByte(0x1f)
+	sb.WriteString(strconv.Itoa(lawsuit.CauseID))
+	sb.WriteByte(0x1f)
+	for i, c := range claims {
+		if i > 0 && c == claims[i-1] {
+			continue
+		}
+		sb.WriteString(strconv.Itoa(c))
+		sb.WriteByte(',')
+	}
+	sum := sha256.Sum256([]byte(sb.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+// The request is retransmitted when the response is lost (see exchangeWithRetry):
+// the token is created by the district, so a retransmitted acquire finds the
+// lease granted to it and renews it
+func sendLeaseToCourt(courtAddr string, req protocol.LeaseRequest) (protocol.LeaseResponse, error) {
+	var resp protocol.LeaseResponse
+	req.Envelope = protocol.NewEnvelope(localSender, req.TraceID)
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return resp, fmt.Errorf("error while coding JSON: %v", err)
+	}
+
+	log.Printf("[DISTRICT->COURT] %s - trace=%s sending %s fingerprint=%.12s to %s",
+		time.Now().Format(time.RFC3339), req.TraceID, req.Type, req.Fingerprint, courtAddr)
+
+	reply, err := exchangeWithRetry(courtAddr, data, req.MsgID, 2*time.Second)
+	if err != nil {
+		return resp, fmt.Errorf("error while receiving response from the Court: %v", err)
+	}
+
+	if err := json.Unmarshal(reply, &resp); err != nil {
+		return resp, fmt.Errorf("error while decoding response JSON: %v", err)
+	}
+
+	log.Printf("[COURT->DISTRICT] %s - trace=%s response %s success=%v msg=%q",
+		time.Now().Format(time.RFC3339), req.TraceID, req.Type, resp.Success, resp.Message)
+
+	return resp, nil
+}
+
+// Lease held by this district while one lawsuit is filed
+type filingLease struct {
+	courtAddr string
+	trace     string
+	lease     protocol.Lease
+}
+
+// Acquire the lease for the fingerprint. If another district holds it, returns
+// the current lease with held=false. The error means the Court was not reached.
+// The token is created here: if the Court granted the lease but its response was
+// lost, the retransmission (same token) gets the lease instead of finding it held.
+func acquireFilingLease(courtAddr, holder, fingerprint, trace string) (fl *filingLease, current *protocol.Lease, err error) {
+	resp, err := sendLeaseToCourt(courtAddr, protocol.LeaseRequest{
+		Envelope:    protocol.Envelope{TraceID: trace},
+		Type:        "lease_acquire",
+		Fingerprint: fingerprint,
+		Holder:      holder,
+		Token:       newRequestID(),
+		TTLMs:       leaseTTL.Milliseconds(),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.Lease == nil {
+		return nil, nil, fmt.Errorf("court refused the lease: %s", resp.Message)
+	}
+	if !resp.Success {
+		return nil, resp.Lease, nil
+	}
+	return &filingLease{courtAddr: courtAddr, trace: trace, lease: *resp.Lease}, nil, nil
+}
+
+// Renew the lease before the create/merge. If it expired and was taken by another
+// district, returns the current lease (the filing must not go on).
+func (fl *filingLease) renew() (current *protocol.Lease, err error) {
+	resp, err := sendLeaseToCourt(fl.courtAddr, protocol.LeaseRequest{
+		Envelope:    protocol.Envelope{TraceID: fl.trace},
+		Type:        "lease_acquire",
+		Fingerprint: fl.lease.Fingerprint,
+		Holder:      fl.lease.Holder,
+		Token:       fl.lease.Token,
+		TTLMs:       leaseTTL.Milliseconds(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Lease == nil {
+		return nil, fmt.Errorf("court refused the lease: %s", resp.Message)
+	}
+	if !resp.Success {
+		return resp.Lease, nil
+	}
+	fl.lease = *resp.Lease
+	return nil, nil
+}
+
+func (fl *filingLease) release() {
+	if _, err := sendLeaseToCourt(fl.courtAddr, protocol.LeaseRequest{
+		Envelope:    protocol.Envelope{TraceID: fl.trace},
+		Type:        "lease_release",
+		Fingerprint: fl.lease.Fingerprint,
+		Token:       fl.lease.Token,
+	}); err != nil {
+		// The lease expires by itself in the Court
+		log.Printf("Error while releasing lease %.12s: %v", fl.lease.Fingerprint, err)
+	}
+}
+
+
+// ---------- Specific handler for "trial_info" ----------
+
+func handleTrialInfo(w transport.Replier, data []byte, nameDistrict string, dl *DistrictList, tl *TrialList) {
+	var req protocol.DistrictInfoRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		log.Printf("Erro ao decodificar DistrictInfoRequest: %v", err)
+		log.Printf("Error while decoding DistrictInfoRequest: %v", err)
+		return
+	}
+
+	log.Printf("[TRIAL->DISTRICT] %s - trace=%s trial_info received from %s (TrialID=%d)",
+		time.Now().Format(time.RFC3339), req.TraceID,
+		w.Remote(), req.TrialID,
+	)
+
+	// Search district's ID from the local mirror (if existent)
+	districtID := 0
+	districts := dl.GetAll()
+	for _, d := range districts {
+		if d.Name == nameDistrict {
+			districtID = d.ID
+			break
+		}
+	}
+
+	// Search a trial by ID (the ID of a removed trial is not given again)
+	t, ok := tl.FindByID(req.TrialID)
+	if !ok {
+		msg, why := fmt.Sprintf("Trial with ID %d not found in this district.", req.TrialID), "not found"
+		r, retired := tl.Retired(req.TrialID)
+		if retired {
+			msg = fmt.Sprintf("Trial with ID %d was removed from this district at %s: its ID is not reused (add a new trial to get a new ID).",
+				req.TrialID, r.RemovedAt.Local().Format("2006-01-02 15:04:05"))
+			why = "retired ID"
+		}
+		resp := protocol.DistrictInfoResponse{
+			Envelope: req.Reply(localSender),
+			Success:  false,
+			Message:  msg,
+			Retired:  retired,
+		}
+		b, _ := json.Marshal(resp)
+		_ = w.Reply(b)
+		log.Printf("[DISTRICT->TRIAL] trial_info fault for %s (TrialID=%d): %s",
+			w.Remote(), req.TrialID, why)
+		return
+	}
+
+	// Assemble the response 
+	resp := protocol.DistrictInfoResponse{
+		Envelope:     req.Reply(localSender),
+		Success:     true,
+		Message:     "Information from the trial sucessfully obtained.",
+		DistrictID:   districtID,
+		DistrictName: nameDistrict,
+		TrialID:      t.ID,
+		TrialAddr:    t.Address,
+	}
+
+	b, err := json.Marshal(resp)
+	if err != nil {
+		log.Printf("Error while coding response trial_info: %v", err)
+		return
+	}
+
+	if err := w.Reply(b); err != nil {
+		log.Printf("Error while sending response trial_info to %s: %v", w.Remote(), err)
+		return
+	}
+
+	log.Printf("[DISTRICT->TRIAL] trace=%s trial_info OK for %s (TrialID=%d, Addr=%s, DistrictID=%d, Name=%s)",
+		req.TraceID, w.Remote(), t.ID, t.Address, districtID, nameDistrict)
+}
+
+
+// ---------- Handler for "lawsuit_query" from the OTHER DISTRICT ----------
+
+// This handler enables that ONE district act as "aggregator" of its trials
+// for another district. The other district send a TrialActionQueryRequest (lawsuit_query)
+// straight to the district's address, and here it is forwarded to ALL the local trials
+// with searchTrialsLocalStage and it is returned a TrialActionQueryResponse
+func handleActionQueryDistrict(
+	w transport.Replier,
+	data []byte,
+	nameDistrict string,
+	dl *DistrictList,
+	tl *TrialList,
+) {
+	var req protocol.TrialActionQueryRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		log.Printf("Error while decoding TrialActionQueryRequest (from %s): %v", w.Remote(), err)
+		return
+	}
+
+	log.Printf("[DISTRICT<-DISTRICT] %s - trace=%s lawsuit_query stage=%s received from %s",
+		time.Now().Format(time.RFC3339), req.TraceID, req.Stage, w.Remote())
+
+	// Convert ActionQuery -> NewLawsuit to reuse searchTrialsLocalStage
+	new_lawsuit := actionQueryToNewLawsuit(req.Lawsuit)
+
+	// Verify ALL the local trials for the requested stage
+	respLocal, err := verifyLocalTrialsStage(tl, req.Stage, new_lawsuit, req.TraceID, aggregatorTimeout)
+	if err != nil {
+		log.Printf("Error while verifying local trials (as aggregator DISTRICT) stage=%s: %v", req.Stage, err)
+		failed := protocol.TrialActionQueryResponse{
+			Envelope: req.Reply(localSender),
+			Success:  false,
+			Stage:    req.Stage,
+			Message:  err.Error(),
+		}
+		b, _ := json.Marshal(failed)
+		_ = w.Reply(b)
+		return
+	}
+
+	// If not found, return "none"
+	if respLocal == nil || !respLocal.Success || respLocal.Match == "" || respLocal.Match == "none" {
+		empty := protocol.TrialActionQueryResponse{
+			Envelope: req.Reply(localSender),
+			Success: true,
+			Stage:   req.Stage,
+			Match:   "none",
+			Message: "No corresponding lawsuit was found in this district.",
+		}
+		b, _ := json.Marshal(empty)
+		_ = w.Reply(b)
+		log.Printf("[DISTRICT->DISTRICT] %s - trace=%s lawsuit_query stage=%s without correponding, returning 'none' for %s",
+			time.Now().Format(time.RFC3339), req.TraceID, req.Stage, w.Remote())
+		return
+	}
+
+	// Grants that district's name/ID are filled 
+	if respLocal.DistrictName == "" || respLocal.DistrictID == 0 {
+		districts := dl.GetAll()
+		for _, d := range districts {
+			if d.Name == nameDistrict {
+				respLocal.DistrictID = d.ID
+				respLocal.DistrictName = d.Name
+				break
+			}
+		}
+	}
+
+	respLocal.Envelope = req.Reply(localSender)
+	b, err := json.Marshal(respLocal)
+	if err != nil {
+		log.Printf("Error while coding response lawsuit_query (aggregator district): %v", err)
+		return
+	}
+
+	if err := w.Reply(b); err != nil {
+		log.Printf("Error while sending response lawsuit_query (aggregator district) to %s: %v", w.Remote(), err)
+		return
+	}
+
+	log.Printf("[DISTRICT->DISTRICT] %s - trace=%s lawsuit_query stage=%s match=%s msg=%q to %s",
+		time.Now().Format(time.RFC3339), req.TraceID, respLocal.Stage, respLocal.Match, respLocal.Message, w.Remote())
+}
+
+
+// ---------- Handler for "lawsuit_classify" from the OTHER DISTRICT ----------
+
+// Aggregate form of handleActionQueryDistrict: the other district sends ONE
+// lawsuit_classify and receives the matches of ALL the local trials for all the stages.
+func handleClassifyDistrict(
+	w transport.Replier,
+	data []byte,
+	nameDistrict string,
+	dl *DistrictList,
+	tl *TrialList,
+) {
+	var req protocol.TrialClassifyRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		log.Printf("Error while decoding TrialClassifyRequest (from %s): %v", w.Remote(), err)
+		return
+	}
+
+	log.Printf("[DISTRICT<-DISTRICT] %s - trace=%s lawsuit_classify stages=%v received from %s",
+		time.Now().Format(time.RFC3339), req.TraceID, req.Stages, w.Remote())
+
+	new_lawsuit := actionQueryToNewLawsuit(req.Lawsuit)
+	resp := classifyLocalTrials(tl, req.Stages, new_lawsuit, req.TraceID, aggregatorTimeout)
+	resp.Envelope = req.Reply(localSender)
+
+	districtID := 0
+	for _, d := range dl.GetAll() {
+		if d.Name == nameDistrict {
+			districtID = d.ID
+			break
+		}
+	}
+	resp.DistrictID = districtID
+	resp.DistrictName = nameDistrict
+	for i := range resp.Stages {
+		for j := range resp.Stages[i].Matches {
+			m := &resp.Stages[i].Matches[j]
+			if m.DistrictID == 0 {
+				m.DistrictID = districtID
+			}
+			if m.DistrictName == "" {
+				m.DistrictName = nameDistrict
+			}
+		}
+	}
+
+	b, err := json.Marshal(resp)
+	if err != nil {
+		log.Printf("Error while coding response lawsuit_classify (aggregator district): %v", err)
+		return
+	}
+
+	if err := w.Reply(b); err != nil {
+		log.Printf("Error while sending response lawsuit_classify (aggregator district) to %s: %v", w.Remote(), err)
+		return
+	}
+
+	log.Printf("[DISTRICT->DISTRICT] %s - trace=%s lawsuit_classify msg=%q to %s",
+		time.Now().Format(time.RFC3339), req.TraceID, resp.Message, w.Remote())
+}
+
+
+// ---------- Handler for "search_lawsuit" from the OTHER DISTRICT ----------
+
+// Position of a search in the local trials (opaque for the other district):
+// trial (by ID) where the next page starts, cursor inside that trial, results
+// of that trial's page already returned and the total counted in the first page
+type districtSearchCursor struct {
+	TrialID int    `json:"t"`
+	Cursor  string `json:"c,omitempty"`
+	Skip    int    `json:"s,omitempty"`
+	Total   int    `json:"n"`
+}
+
+// Aggregate form of search_lawsuit: the other district sends ONE request and
+// receives the lawsuits of ALL the local trials, labelled with district and trial
+func handleSearchDistrict(
+	w transport.Replier,
+	data []byte,
+	nameDistrict string,
+	dl *DistrictList,
+	tl *TrialList,
+) {
+	var req protocol.TrialSearchLawsuitsRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		log.Printf("Error while decoding TrialSearchLawsuitsRequest (from %s): %v", w.Remote(), err)
+		return
+	}
+
+	log.Printf("[DISTRICT<-DISTRICT] %s - trace=%s search_lawsuit field=%s value=%q query=%q limit=%d received from %s",
+		time.Now().Format(time.RFC3339), req.TraceID, req.Field, req.Value, req.Query, req.Limit, w.Remote())
+
+	resp := searchLocalTrials(tl, req, aggregatorTimeout)
+	resp.Envelope = req.Reply(localSender)
+
+	districtID := 0
+	for _, d := range dl.GetAll() {
+		if d.Name == nameDistrict {
+			districtID = d.ID
+			break
+		}
+	}
+	resp.DistrictID = districtID
+	resp.DistrictName = nameDistrict
+	for i := range resp.Results {
+		r := &resp.Results[i]
+		if r.DistrictID == 0 {
+			r.DistrictID = districtID
+		}
+		if r.DistrictName == "" {
+			r.DistrictName = nameDistrict
+		}
+	}
+
+	b, err := json.Marshal(resp)
+	if err != nil {
+		log.Printf("Error while coding response search_lawsuit (aggregator district): %v", err)
+		return
+	}
+
+	if err := w.Reply(b); err != nil {
+		log.Printf("Error while sending response search_lawsuit (aggregator district) to %s: %v", w.Remote(), err)
+		return
+	}
+
+	log.Printf("[DISTRICT->DISTRICT] %s - trace=%s search_lawsuit results=%d total=%d more=%v msg=%q to %s",
+		time.Now().Format(time.RFC3339), req.TraceID, len(resp.Results), resp.Total, resp.NextCursor != "", resp.Message, w.Remote())
+}
+
+// Search the request in the local trials. The first page asks every trial at the
+// same time (all the results without limit, one page each with limit) and takes
+// the totals from those answers; the next pages go on trial by trial (in the
+// order of the trials' list) from where the cursor tells.
+func searchLocalTrials(tl *TrialList, req protocol.TrialSearchLawsuitsRequest, timeout time.Duration) *protocol.TrialSearchLawsuitsResponse {
+	trials := tl.GetAll()
+	resp := &protocol.TrialSearchLawsuitsResponse{Success: true, Results: []protocol.TrialSearchResult{}}
+
+	field, value := req.Field, req.Value
+	if req.Query != "" {
+		field, value = "query", req.Query
+	}
+	var faults []string
+	label := func(t Trial, results []protocol.TrialSearchResult) []protocol.TrialSearchResult {
+		for i := range results {
+			if results[i].TrialID == 0 {
+				results[i].TrialID = t.ID
+			}
+			if results[i].TrialAddr == "" {
+				results[i].TrialAddr = t.Address
+			}
+		}
+		return results
+	}
+
+	var pos districtSearchCursor
+	if req.Cursor != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(req.Cursor)
+		if err == nil {
+			err = json.Unmarshal(raw, &pos)
+		}
+		if err != nil {
+			resp.Success = false
+			resp.Message = "invalid cursor"
+			return resp
+		}
+	}
+
+	remaining := req.Limit
+	var next *districtSearchCursor
+	// Takes the page of trial i asked from pos (start of the trial when from is
+	// nil) and marks where the next page starts, if the trial has more
+	take := func(i int, r *protocol.TrialSearchLawsuitsResponse, from *districtSearchCursor) {
+		results := r.Results
+		if req.Limit <= 0 {
+			resp.Results = append(resp.Results, label(trials[i], results)...)
+			return
+		}
+		n := len(results)
+		if n > remaining {
+			n = remaining
+		}
+		resp.Results = append(resp.Results, label(trials[i], results[:n])...)
+		remaining -= n
+		switch {
+		case n < len(results):
+			// page cut in the middle: the next one asks the same page again
+			next = &districtSearchCursor{TrialID: trials[i].ID, Skip: n}
+			if from != nil {
+				next.Cursor, next.Skip = from.Cursor, from.Skip+n
+			}
+		case r.NextCursor != "":
+			next = &districtSearchCursor{TrialID: trials[i].ID, Cursor: r.NextCursor}
+		}
+	}
+
+	if req.Cursor == "" {
+		pages := make([]*protocol.TrialSearchLawsuitsResponse, len(trials))
+		errs := make([]error, len(trials))
+		fanOut(len(trials), func(i int) {
+			pages[i], errs[i] = searchLawsuitsAtTrial(trials[i].Address, field, value, searchPage{Sort: req.Sort, Limit: req.Limit}, req.TraceID, timeout)
+		})
+		for i, r := range pages {
+			if errs[i] != nil || !r.Success {
+				faults = append(faults, fmt.Sprintf("trial %d", trials[i].ID))
+				continue
+			}
+			total := r.Total
+			if total == 0 {
+				total = len(r.Results)
+			}
+			pos.Total += total
+			if next != nil {
+				continue
+			}
+			if req.Limit > 0 && remaining <= 0 {
+				// page full: the next one starts in the first trial with results
+				if total > 0 {
+					next = &districtSearchCursor{TrialID: trials[i].ID}
+				}
+				continue
+			}
+			take(i, r, nil)
+		}
+	} else {
+		start := -1
+		for i, t := range trials {
+			if t.ID == pos.TrialID {
+				start = i
+				break
+			}
+		}
+		if start < 0 {
+			resp.Success = false
+			resp.Message = fmt.Sprintf("invalid or stale cursor: trial %d is no longer in this district", pos.TrialID)
+			return resp
+		}
+		for i := start; i < len(trials) && remaining > 0 && next == nil; i++ {
+			page := searchPage{Sort: req.Sort, Limit: remaining}
+			from := &districtSearchCursor{}
+			if i == start {
+				// the results of the trial's page already returned are asked again and skipped
+				page.Cursor, page.Limit = pos.Cursor, remaining+pos.Skip
+				from.Cursor, from.Skip = pos.Cursor, pos.Skip
+			}
+			r, err := searchLawsuitsAtTrial(trials[i].Address, field, value, page, req.TraceID, timeout)
+			if err != nil || !r.Success {
+				faults = append(faults, fmt.Sprintf("trial %d", trials[i].ID))
+				continue
+			}
+			if from.Skip > 0 {
+				skip := from.Skip
+				if skip > len(r.Results) {
+					skip = len(r.Results)
+				}
+				r.Results = r.Results[skip:]
+			}
+			take(i, r, from)
+			if next == nil && remaining <= 0 {
+				// page full at the end of the trial: the next one starts in the
+				// next trial with results (none: this is the last page)
+				for j := i + 1; j < len(trials); j++ {
+					r, err := searchLawsuitsAtTrial(trials[j].Address, field, value, searchPage{Sort: req.Sort, Limit: 1}, req.TraceID, timeout)
+					if err != nil || !r.Success {
+						faults = append(faults, fmt.Sprintf("trial %d", trials[j].ID))
+						continue
+					}
+					if len(r.Results) > 0 {
+						next = &districtSearchCursor{TrialID: trials[j].ID}
+						break
+					}
+				}
+			}
+		}
+	}
+	resp.Total = pos.Total
+	if next != nil {
+		next.Total = pos.Total
+		raw, _ := json.Marshal(next)
+		resp.NextCursor = base64.RawURLEncoding.EncodeToString(raw)
+	}
+
+	resp.Message = fmt.Sprintf("%d lawsuits found in %d trials", resp.Total, len(trials))
+	if len(faults) > 0 {
+		resp.Message += " (without response: " + strings.Join(faults, ", ") + ")"
+	}
+	return resp
+}
+
+
+// ---------- District server (for trials and other districts) ----------
+
+// The listener stays open until the district finishes
+func startTrialsServer(districtAddr, nameDistrict string, dl *DistrictList, tl *TrialList) {
+	_, err := transport.Listen(tport, districtAddr, func(w transport.Replier, data []byte) {
+		// Detect the message type
+		var base struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(data, &base); err != nil {
+			log.Printf("Error while decoding the message type of the trial (%s): %v", w.Remote(), err)
+			return
+		}
+
+		switch base.Type {
+		case "trial_info":
+			handleTrialInfo(w, data, nameDistrict, dl, tl)
+
+		case "lawsuit_query":
+			// request from OTHER DISTRICT for this district to verify
+			// ALL its trials for the indicated stage
+			go handleActionQueryDistrict(w, data, nameDistrict, dl, tl)
+
+		case "lawsuit_classify":
+			// request from OTHER DISTRICT for this district to classify
+			// the lawsuit in ALL its trials, for all the stages, in one round
+			go handleClassifyDistrict(w, data, nameDistrict, dl, tl)
+
+		case "search_lawsuit":
+			// request from OTHER DISTRICT for the lawsuits of ALL its trials
+			go handleSearchDistrict(w, data, nameDistrict, dl, tl)
+
+		default:
+			log.Printf("[DISTRICT] %s - unknown message type %q from %s",
+				time.Now().Format(time.RFC3339), base.Type, w.Remote())
+		}
+	})
+	if err != nil {
+		log.Printf("Error while opening %s for trials at %s: %v", tport.Name(), districtAddr, err)
+		return
+	}
+
+	log.Printf("TRIALS server of district listening at %s (%s)", districtAddr, tport.Name())
+}
+
+
+// ---------- Simple structure for new lawsuit ----------
+type NewLawsuit struct {
+	Plaintiff  string
+	Defendant  string
+	CauseID    int
+	Claims     []int
+}
+
+func newLawsuitToActionQuery(a NewLawsuit) protocol.ActionQuery {
+	return protocol.ActionQuery{
+		Plaintiff: a.Plaintiff,
+		Defendant: a.Defendant,
+		CauseID:   a.CauseID,
+		Claims:    a.Claims,
+	}
+}
+
+// Convert ActionQuery (used in messages) back to NewLawsuit 
+func actionQueryToNewLawsuit(q protocol.ActionQuery) NewLawsuit {
+	return NewLawsuit{
+		Plaintiff: q.Plaintiff,
+		Defendant: q.Defendant,
+		CauseID:   q.CauseID,
+		// make a copy of slice to avoid aliasing
+		Claims: append([]int(nil), q.Claims...),
+	}
+}
+
+
+// ---------- Aux functions for communication with TRIALS ----------
+
+func verifyTrialStage(trialAddr string, stage string, lawsuit NewLawsuit, trace string, timeout time.Duration) (*protocol.TrialActionQueryResponse, error) {
+	req := protocol.TrialActionQueryRequest{
+		Envelope: protocol.NewEnvelope(localSender, trace),
+		Type:     "lawsuit_query",
+		Stage:    stage,
+		Lawsuit:  newLawsuitToActionQuery(lawsuit),
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("error while coding JSON for trial %s: %v", trialAddr, err)
+	}
+
+	log.Printf("[DISTRICT->TRIAL] %s - trace=%s sending lawsuit_query stage=%s to %s",
+		time.Now().Format(time.RFC3339), trace, stage, trialAddr)
+
+	reply, err := protocol.Exchange(tport, trialAddr, data, req.MsgID, time.Now().Add(timeout))
+	if err != nil {
+		return nil, fmt.Errorf("error while receiving response from trial %s: %v", trialAddr, err)
+	}
+
+	var resp protocol.TrialActionQueryResponse
+	if err := json.Unmarshal(reply, &resp); err != nil {
+		return nil, fmt.Errorf("error while decoding response of trial %s: %v", trialAddr, err)
+	}
+
+	log.Printf("[TRIAL->DISTRICT] %s - trace=%s response stage=%s match=%s msg=%q of trial %s",
+		time.Now().Format(time.RFC3339), trace, resp.Stage, resp.Match, resp.Message, trialAddr)
+
+	return &resp, nil
+}
+
+// Deadline used by a district when it acts as aggregator of its trials for another
+// district: shorter than the 2s of the requester, so the aggregated answer arrives in time
+// even if some local trial is down.
+const aggregatorTimeout = 1500 * time.Millisecond
+
+// Execute fn(i) for i in [0, n) concurrently and wait for all of them.
+// Each fn must store its result in the position i of a slice owned by the caller,
+// so the results are read in the list order, independent of the responses order.
+func fanOut(n int, fn func(i int)) {
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer wg.Done()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// it queries all the trials of the local district (concurrently, with a global
+// deadline), for deteminated stage/rule, and returns the positive response
+// (res judicata, lis pendens, etc.) chosen by prevention (see preventionLess).
+// Without a match, it is an error if some trial did not answer: "none" would
+// not be sure.
+func verifyLocalTrialsStage(tl *TrialList, stage string, lawsuit NewLawsuit, trace string, timeout time.Duration) (*protocol.TrialActionQueryResponse, error) {
+	trials := tl.GetAll()
+	deadline := time.Now().Add(timeout)
+
+	results := make([]*protocol.TrialActionQueryResponse, len(trials))
+	failures := make([]string, len(trials))
+	fanOut(len(trials), func(i int) {
+		resp, err := verifyTrialStage(trials[i].Address, stage, lawsuit, trace, time.Until(deadline))
+		if err != nil {
+			log.Printf("Warning: fault while verifying trial %s in the stage %s: %v", trials[i].Address, stage, err)
+			failures[i] = fmt.Sprintf("trial %d (%s): %v", trials[i].ID, trials[i].Address, err)
+			return
+		}
+		if !resp.Success {
+			failures[i] = fmt.Sprintf("trial %d (%s) refused the lawsuit_query: %s", trials[i].ID, trials[i].Address, resp.Message)
+		}
+		results[i] = resp
+	})
+
+	var best *protocol.TrialActionQueryResponse
+	for i, resp := range results {
+		if resp != nil && resp.Success && resp.Match != "" && resp.Match != "none" {
+			// If the trial does not fullfill DistricName/DistrictID,
+			// at least grants the address.
+			if resp.TrialAddr == "" {
+				resp.TrialAddr = trials[i].Address
+			}
+			if best == nil || preventionLess(resp, best) {
+				best = resp
+			}
+		}
+	}
+	if best == nil {
+		if failed := nonEmpty(failures); len(failed) > 0 {
+			return nil, fmt.Errorf("%d of %d trials did not answer: %s", len(failed), len(trials), strings.Join(failed, "; "))
+		}
+	}
+	return best, nil
+}
+
+// The non-empty strings, in order
+func nonEmpty(list []string) []string {
+	var res []string
+	for _, x := range list {
+		if x != "" {
+			res = append(res, x)
+		}
+	}
+	return res
+}
+
+// Other districts of the mirror (different than the local district, with address)
+func otherDistricts(nameDistrictLocal string, dl *DistrictList) []protocol.District {
+	var res []protocol.District
+	for _, d := range dl.GetAll() {
+		if strings.EqualFold(d.Name, nameDistrictLocal) {
+			// jump the own district
+			continue
+		}
+		if strings.TrimSpace(d.Address) == "" {
+			continue
+		}
+		res = append(res, d)
+	}
+	return res
+}
+
+// Send ONE lawsuit_classify to an address (trial or aggregator district)
+func classifyAtAddr(targetAddr string, stages []string, lawsuit NewLawsuit, trace string, timeout time.Duration) (*protocol.TrialClassifyResponse, error) {
+	req := protocol.TrialClassifyRequest{
+		Envelope: protocol.NewEnvelope(localSender, trace),
+		Type:     "lawsuit_classify",
+		Stages:   stages,
+		Lawsuit:  newLawsuitToActionQuery(lawsuit),
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("error while coding JSON (lawsuit_classify) for %s: %v", targetAddr, err)
+	}
+
+	log.Printf("[DISTRICT->] %s - trace=%s sending lawsuit_classify stages=%v to %s",
+		time.Now().Format(time.RFC3339), trace, stages, targetAddr)
+
+	reply, err := protocol.Exchange(tport, targetAddr, data, req.MsgID, time.Now().Add(timeout))
+	if err != nil {
+		return nil, fmt.Errorf("error while receiving response lawsuit_classify from %s: %v", targetAddr, err)
+	}
+
+	var resp protocol.TrialClassifyResponse
+	if err := json.Unmarshal(reply, &resp); err != nil {
+		return nil, fmt.Errorf("error while decoding response lawsuit_classify from %s: %v", targetAddr, err)
+	}
+
+	log.Printf("[->DISTRICT] %s - trace=%s response lawsuit_classify success=%v msg=%q from %s",
+		time.Now().Format(time.RFC3339), trace, resp.Success, resp.Message, targetAddr)
+
+	return &resp, nil
+}
+
+// Add the matches of one response to the aggregated response (same stages order)
+func mergeClassify(dst *protocol.TrialClassifyResponse, src *protocol.TrialClassifyResponse) {
+	for _, sm := range src.Stages {
+		idx := -1
+		for i := range dst.Stages {
+			if dst.Stages[i].Stage == sm.Stage {
+				idx = i
+				break
+			}
+		}
+		if idx == -1 {
+			dst.Stages = append(dst.Stages, protocol.StageMatches{Stage: sm.Stage})
+			idx = len(dst.Stages) - 1
+		}
+		dst.Stages[idx].Matches = append(dst.Stages[idx].Matches, sm.Matches...)
+	}
+}
+
+// Count the matches of an aggregated response
+func countClassify(resp *protocol.TrialClassifyResponse) int {
+	total := 0
+	for _, sm := range resp.Stages {
+		total += len(sm.Matches)
+	}
+	return total
+}
+
+// Classify the lawsuit in ALL the trials of the local district (one round,
+// concurrently and with a global deadline). Trials that fail are skipped and
+// listed in the Warnings of the response. The matches are merged in the
+// trials' list order.
+func classifyLocalTrials(tl *TrialList, stages []string, lawsuit NewLawsuit, trace string, timeout time.Duration) *protocol.TrialClassifyResponse {
+	agg := &protocol.TrialClassifyResponse{Success: true, Stages: []protocol.StageMatches{}}
+	for _, stage := range stages {
+		agg.Stages = append(agg.Stages, protocol.StageMatches{Stage: stage})
+	}
+
+	trials := tl.GetAll()
+	deadline := time.Now().Add(timeout)
+
+	results := make([]*protocol.TrialClassifyResponse, len(trials))
+	failures := make([]string, len(trials))
+	fanOut(len(trials), func(i int) {
+		t := trials[i]
+		resp, err := classifyAtAddr(t.Address, stages, lawsuit, trace, time.Until(deadline))
+		if err != nil {
+			log.Printf("Warning: fault while classifying the lawsuit in the trial %s: %v", t.Address, err)
+			failures[i] = fmt.Sprintf("trial %d (%s) was not classified: %v", t.ID, t.Address, err)
+			return
+		}
+		if !resp.Success {
+			log.Printf("Warning: trial %s refused the lawsuit_classify: %s", t.Address, resp.Message)
+			failures[i] = fmt.Sprintf("trial %d (%s) refused the lawsuit_classify: %s", t.ID, t.Address, resp.Message)
+			return
+		}
+		results[i] = resp
+	})
+	agg.Warnings = nonEmpty(failures)
+
+	answered := 0
+	for i, resp := range results {
+		if resp == nil {
+			continue
+		}
+		answered++
+		// If the trial does not fullfill its address, at least grants it
+		for s := range resp.Stages {
+			for j := range resp.Stages[s].Matches {
+				if resp.Stages[s].Matches[j].TrialAddr == "" {
+					resp.Stages[s].Matches[j].TrialAddr = trials[i].Address
+				}
+			}
+		}
+		mergeClassify(agg, resp)
+	}
+
+	agg.Message = fmt.Sprintf("%d of %d trials classified, %d matches found", answered, len(trials), countClassify(agg))
+	return agg
+}
+
+// Classify the lawsuit in ALL the OTHER districts (one lawsuit_classify per district,
+// that aggregates its trials through handleClassifyDistrict), concurrently and with
+// a global deadline. The matches are merged in the mirror order; the districts
+// that fail and the trials their aggregators could not classify are listed in
+// the Warnings of the response.
+func classifyOtherDistricts(nameDistrictLocal string, dl *DistrictList, stages []string, lawsuit NewLawsuit, trace string, timeout time.Duration) *protocol.TrialClassifyResponse {
+	agg := &protocol.TrialClassifyResponse{Success: true, Stages: []protocol.StageMatches{}}
+	for _, stage := range stages {
+		agg.Stages = append(agg.Stages, protocol.StageMatches{Stage: stage})
+	}
+
+	districts := otherDistricts(nameDistrictLocal, dl)
+	deadline := time.Now().Add(timeout)
+
+	results := make([]*protocol.TrialClassifyResponse, len(districts))
+	failures := make([]string, len(districts))
+	fanOut(len(districts), func(i int) {
+		d := districts[i]
+		districtAddr := strings.TrimSpace(d.Address)
+		resp, err := classifyAtAddr(districtAddr, stages, lawsuit, trace, time.Until(deadline))
+		if err != nil {
+			log.Printf("Warning: fault while classifying the lawsuit in the district %s (%s): %v", d.Name, districtAddr, err)
+			failures[i] = fmt.Sprintf("district %s (%s) was not classified: %v", d.Name, districtAddr, err)
+			return
+		}
+		if !resp.Success {
+			log.Printf("Warning: district %s refused the lawsuit_classify: %s", d.Name, resp.Message)
+			failures[i] = fmt.Sprintf("district %s refused the lawsuit_classify: %s", d.Name, resp.Message)
+			return
+		}
+		results[i] = resp
+	})
+
+	for i, resp := range results {
+		if resp == nil {
+			agg.Warnings = append(agg.Warnings, failures[i])
+			continue
+		}
+		for _, w := range resp.Warnings {
+			agg.Warnings = append(agg.Warnings, fmt.Sprintf("district %s: %s", districts[i].Name, w))
+		}
+		// Grants district's info, if came empty
+		for s := range resp.Stages {
+			for j := range resp.Stages[s].Matches {
+				m := &resp.Stages[s].Matches[j]
+				if m.DistrictID == 0 {
+					m.DistrictID = districts[i].ID
+				}
+				if m.DistrictName == "" {
+					m.DistrictName = districts[i].Name
+				}
+			}
+		}
+		mergeClassify(agg, resp)
+	}
+
+	agg.Message = fmt.Sprintf("%d matches found in other districts", countClassify(agg))
+	return agg
+}
+
+
+// ---------- Prevention (choice between several matches of the same stage) ----------
+
+// Numbers of a lawsuit ID "ID_District.ID_Trial.Sequence" (0 for invalid parts)
+func lawsuitIDParts(id string) [3]int {
+	var parts [3]int
+	for i, p := range strings.SplitN(id, ".", 3) {
+		n, err := strconv.Atoi(p)
+		if err == nil {
+			parts[i] = n
+		}
+	}
+	return parts
+}
+
+// preventionLess reports if the lawsuit found in a precedes the one found in b by
+// the prevention criterion: earliest filing (distribution) date of the related lawsuit.
+// Lawsuits without date (registered before the dates were kept) are older than any
+// dated lawsuit. Ties are decided by the lawsuit ID (district, trial, sequence).
+func preventionLess(a, b *protocol.TrialActionQueryResponse) bool {
+	if !a.FiledAt.Equal(b.FiledAt) {
+		return a.FiledAt.Before(b.FiledAt)
+	}
+	pa, pb := lawsuitIDParts(a.LawsuitID), lawsuitIDParts(b.LawsuitID)
+	for i := range pa {
+		if pa[i] != pb[i] {
+			return pa[i] < pb[i]
+		}
+	}
+	return a.LawsuitID < b.LawsuitID
+}
+
+// Sort the matches by prevention (the first one is the winner)
+func rankByPrevention(matches []protocol.TrialActionQueryResponse) {
+	sort.SliceStable(matches, func(i, j int) bool {
+		return preventionLess(&matches[i], &matches[j])
+	})
+}
+
+// Reason of the choice of the first (ranked) match
+func preventionReason(ranked []protocol.TrialActionQueryResponse) string {
+	if len(ranked) == 0 {
+		return ""
+	}
+	w := ranked[0]
+	when := "filing date unknown (registered before the dates were kept)"
+	if !w.FiledAt.IsZero() {
+		when = "filed at " + w.FiledAt.Local().Format("2006-01-02 15:04:05")
+	}
+	if len(ranked) == 1 {
+		return fmt.Sprintf("only lawsuit found: %s (%s)", w.LawsuitID, when)
+	}
+	return fmt.Sprintf("prevention: lawsuit %s %s, the earliest among %d lawsuits found", w.LawsuitID, when, len(ranked))
+}
+
+
+// ---------- Retransmission of mutating requests ----------
+
+// lawsuit_create_checked and lawsuit_merge_claims carry a request ID: if the response
+// is lost, the same message (same request ID and msg_id) is sent again and the trial
+// repeats the first response instead of creating/merging twice. The lease requests
+// to the Court are sent the same way (see sendLeaseToCourt).
+const (
+	mutationAttempts = 4
+	retryBackoff     = 200 * time.Millisecond
+)
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := crand.Read(b); err != nil {
+		return fmt.Sprintf("%x-%x", time.Now().UnixNano(), rand.Int63())
+	}
+	return hex.EncodeToString(b)
+}
+
+// Send the message and wait the response up to timeout; without response, send
+// again after a backoff that doubles at each attempt
+func exchangeWithRetry(addr string, data []byte, msgID string, timeout time.Duration) ([]byte, error) {
+	backoff := retryBackoff
+	var lastErr error
+	for attempt := 1; attempt <= mutationAttempts; attempt++ {
+		if attempt > 1 {
+			log.Printf("[DISTRICT] %s - no response from %s (%v); retransmission %d of %d after %v",
+				time.Now().Format(time.RFC3339), addr, lastErr, attempt-1, mutationAttempts-1, backoff)
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		reply, err := protocol.Exchange(tport, addr, data, msgID, time.Now().Add(timeout))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return reply, nil
+	}
+	return nil, fmt.Errorf("%v (after %d attempts)", lastErr, mutationAttempts)
+}
+
+// Send request to create a lawsuit for a specific trial (check-and-create in the trial:
+// lawsuit_create_checked; see Conflict in the response)
+func createLawsuitInTrialAddr(trialAddr, reason, related string, lawsuit NewLawsuit, trace string, timeout time.Duration) (*protocol.TrialCreateActionResponse, error) {
+	req := protocol.TrialCreateActionRequest{
+		Envelope:  protocol.NewEnvelope(localSender, trace),
+		Type:      "lawsuit_create_checked",
+		Reason:    reason,
+		Lawsuit:   newLawsuitToActionQuery(lawsuit),
+		Related:   related,
+		RequestID: newRequestID(),
+		Actor:     console.UserName(),
+		District:  localName,
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("error while decoding JSON (lawsuit_create_checked) to trial %s: %v", trialAddr, err)
+	}
+
+	log.Printf("[DISTRICT->TRIAL] %s - trace=%s sending lawsuit_create_checked reason=%s request_id=%s to %s (related=%s)",
+		time.Now().Format(time.RFC3339), trace, reason, req.RequestID, trialAddr, related)
+
+	reply, err := exchangeWithRetry(trialAddr, data, req.MsgID, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("error while receiving response from lawsuit_create_checked of trial %s: %v", trialAddr, err)
+	}
+
+	var resp protocol.TrialCreateActionResponse
+	if err := json.Unmarshal(reply, &resp); err != nil {
+		return nil, fmt.Errorf("error while decoding response lawsuit_create_checked from trial %s: %v", trialAddr, err)
+	}
+
+	log.Printf("[TRIAL->DISTRICT] %s - trace=%s response lawsuit_create_checked success=%v lawsuit_id=%s conflict=%v msg=%q (trial=%s)",
+		time.Now().Format(time.RFC3339), trace, resp.Success, resp.LawsuitID, resp.Conflict, resp.Message, trialAddr)
+
+	return &resp, nil
+}
+
+// Send request to merge claims in lawsuit already existent (containment)
+func sendMergeClaimsToTrialAddr(trialAddr, lawsuitID string, newClaims []int, trace string, timeout time.Duration) (*protocol.TrialMergeClaimsResponse, error) {
+	req := protocol.TrialMergeClaimsRequest{
+		Envelope:  protocol.NewEnvelope(localSender, trace),
+		Type:      "lawsuit_merge_claims",
+		LawsuitID: lawsuitID,
+		NewClaims: newClaims,
+		RequestID: newRequestID(),
+		Actor:     console.UserName(),
+		District:  localName,
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("error while coding JSON (lawsuit_merge_claims) to trial %s: %v", trialAddr, err)
+	}
+
+	log.Printf("[DISTRICT->TRIAL] %s - trace=%s sending lawsuit_merge_claims lawsuit_id=%s request_id=%s to %s",
+		time.Now().Format(time.RFC3339), trace, lawsuitID, req.RequestID, trialAddr)
+
+	reply, err := exchangeWithRetry(trialAddr, data, req.MsgID, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("error while receiving response lawsuit_merge_claims from trial %s: %v", trialAddr, err)
+	}
+
+	var resp protocol.TrialMergeClaimsResponse
+	if err := json.Unmarshal(reply, &resp); err != nil {
+		return nil, fmt.Errorf("error while decoding response lawsuit_merge_claims from trial %s: %v", trialAddr, err)
+	}
+
+	log.Printf("[TRIAL->DISTRICT] %s - trace=%s response lawsuit_merge_claims success=%v msg=%q (trial=%s)",
+		time.Now().Format(time.RFC3339), trace, resp.Success, resp.Message, trialAddr)
+
+	return &resp, nil
+}
+
+// Page of the search in a trial: sort, limit and cursor as in TrialSearchLawsuitsRequest
+type searchPage struct {
+	Sort   string
+	Limit  int
+	Cursor string
+}
+
+// ---------- NEW: Function to send search request to a trial ----------
+func searchLawsuitsAtTrial(trialAddr, field, value string, page searchPage, trace string, timeout time.Duration) (*protocol.TrialSearchLawsuitsResponse, error) {
+	req := protocol.TrialSearchLawsuitsRequest{
+		Envelope: protocol.NewEnvelope(localSender, trace),
+		Type:     "search_lawsuit",
+		Field:    field,
+		Value:    value,
+		Sort:     page.Sort,
+		Limit:    page.Limit,
+		Cursor:   page.Cursor,
+	}
+	if field == "query" {
+		req.Field, req.Value, req.Query = "", "", value
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("error while coding JSON (search_lawsuit) for trial %s: %v", trialAddr, err)
+	}
+
+	log.Printf("[DISTRICT->TRIAL] %s - trace=%s sending search_lawsuit field=%s value=%q to %s",
+		time.Now().Format(time.RFC3339), trace, field, value, trialAddr)
+
+	reply, err := protocol.Exchange(tport, trialAddr, data, req.MsgID, time.Now().Add(timeout))
+	if err != nil {
+		return nil, fmt.Errorf("error while receiving response search_lawsuit from trial %s: %v", trialAddr, err)
+	}
+
+	var resp protocol.TrialSearchLawsuitsResponse
+	if err := json.Unmarshal(reply, &resp); err != nil {
+		return nil, fmt.Errorf("error while decoding response search_lawsuit from trial %s: %v", trialAddr, err)
+	}
+
+	log.Printf("[TRIAL->DISTRICT] %s - trace=%s response search_lawsuit success=%v results=%d total=%d msg=%q (trial=%s)",
+		time.Now().Format(time.RFC3339), trace, resp.Success, len(resp.Results), resp.Total, resp.Message, trialAddr)
+
+	return &resp, nil
+}
+
+// Verify the workload (actives lawsuits) for a specific trial
+// Workload of the trial: its active lawsuits, and how many of the lawsuits
+// transferred to it (received) are still pending there but not active
+func verifyWorkloadTrial(trialAddr string, received []string, trace string, timeout time.Duration) (int, int, error) {
+	req := protocol.WorkloadInfoRequest{Envelope: protocol.NewEnvelope(localSender, trace), Type: "workload_info", Received: received}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error while coding JSON (workload_info) for trial %s: %v", trialAddr, err)
+	}
+
+	log.Printf("[DISTRICT->TRIAL] %s - trace=%s sending workload_info to %s",
+		time.Now().Format(time.RFC3339), trace, trialAddr)
+
+	reply, err := protocol.Exchange(tport, trialAddr, data, req.MsgID, time.Now().Add(timeout))
+	if err != nil {
+		return 0, 0, fmt.Errorf("error while receiving workload response for trial %s: %v", trialAddr, err)
+	}
+
+	var resp protocol.WorkloadInfoResponse
+	if err := json.Unmarshal(reply, &resp); err != nil {
+		return 0, 0, fmt.Errorf("error while decoding workload response for trial %s: %v", trialAddr, err)
+	}
+
+	if !resp.Success {
+		return 0, 0, fmt.Errorf("trial %s fault response in the workload verification: %s", trialAddr, resp.Message)
+	}
+
+	return resp.ActiveWorkload, resp.Received, nil
+}
+
+
+// ---------- FREE Distribution (rule 6) ----------
+
+// Choose the trial with SMALL workload and create the lawsuit there. The workload
+// of a trial is its active lawsuits plus the lawsuits it received by transfer
+// still pending but not active (cl; nil: no compensation).
+// The decision receives the chosen trial, the created lawsuit and the criteria used.
+func lawsuitFreeDistribution(tl *TrialList, cl *CompensationList, lawsuit NewLawsuit, timeout time.Duration, d *Decision) error {
+	all := tl.GetAll()
+	var trials []Trial
+	for _, t := range all {
+		if !t.Draining {
+			trials = append(trials, t)
+		}
+	}
+	if len(all) == 0 {
+		return fmt.Errorf("no registered trials in this district")
+	}
+	if len(trials) == 0 {
+		return fmt.Errorf("all trials are being drained: no trial of this district receives new lawsuits")
+	}
+
+	// Choose the trial with SMALL workload (fewer number of active lawsuits)
+	var (
+		bestTrial    Trial
+		bestWorkload int
+		bestReceived int
+		found        bool
+	)
+	received := map[int][]string{}
+	if cl != nil {
+		received = cl.ByTrial()
+	}
+
+	// Workloads queried concurrently; ties are decided by the trials' list order
+	deadline := time.Now().Add(timeout)
+	workloads := make([]int, len(trials))
+	compensated := make([]int, len(trials))
+	ok := make([]bool, len(trials))
+	fanOut(len(trials), func(i int) {
+		workload, pending, err := verifyWorkloadTrial(trials[i].Address, received[trials[i].ID], d.TraceID, time.Until(deadline))
+		if err != nil {
+			log.Printf("Warning: fault while getting the workload for the trial %s: %v", trials[i].Address, err)
+			return
+		}
+		workloads[i] = workload + pending
+		compensated[i] = pending
+		ok[i] = true
+	})
+
+	for i, t := range trials {
+		if !ok[i] {
+			continue
+		}
+		if !found || workloads[i] < bestWorkload {
+			found = true
+			bestWorkload = workloads[i]
+			bestReceived = compensated[i]
+			bestTrial = t
+		}
+	}
+
+	// If not possible to get the workload for the trials, goes to random fallback 
+	if !found {
+		rand.Seed(time.Now().UnixNano())
+		bestTrial = trials[rand.Intn(len(trials))]
+		log.Printf("Free distribution: workload not get; choosing a random trial: %s", bestTrial.Address)
+	} else {
+		log.Printf("Free distribution: choosing trial %s with workload %d", bestTrial.Address, bestWorkload)
+	}
+
+	d.TrialID = bestTrial.ID
+	d.TrialAddr = bestTrial.Address
+	if found {
+		d.Workload = bestWorkload
+		d.Message = fmt.Sprintf("Criteria: trial with small workload (active lawsuits= %d) in the district.", bestWorkload)
+		if n := bestReceived; n > 0 {
+			d.Message = fmt.Sprintf("Criteria: trial with small workload (active lawsuits= %d, received by transfer and not active= %d) in the district.", bestWorkload-n, n)
+		}
+	} else {
+		d.Workload = -1
+		d.Message = "Criteria: not possible to get the workload for the trials; used random choice."
+	}
+
+	createResp, err := createLawsuitInTrialAddr(bestTrial.Address, "free", "", lawsuit, d.TraceID, timeout)
+	if err != nil {
+		return fmt.Errorf("error while creating lawsuit with free distribution at trial %s: %v", bestTrial.Address, err)
+	}
+	if createResp.Conflict {
+		d.setConflict(createResp)
+		return nil
+	}
+	if !createResp.Success {
+		return fmt.Errorf("trial refused create lawsuit by free distribution: %s", createResp.Message)
+	}
+
+	d.Outcome = "created"
+	d.LawsuitID = createResp.LawsuitID
+	if d.LawsuitID == "" {
+		d.LawsuitID = "(ID not returned by trial)"
+	}
+	if createResp.TrialID != 0 {
+		d.TrialID = createResp.TrialID
+	}
+	return nil
+}
+
+
+// ---------- Transfer of a lawsuit to another trial ----------
+
+// Result of a transfer (the compensation entry recorded)
+type TransferResult struct {
+	Compensation
+	From    Trial
+	To      Trial
+	TraceID string
+}
+
+// Moves a pending lawsuit of a trial of this district to another one (recusal
+// of the judge, declination of competence): lawsuit_export to the source trial,
+// lawsuit_import to the target (new ID) and lawsuit_transferred to the source,
+// then the compensation of the target is recorded. A transfer interrupted
+// after the import can be repeated: the target answers with the lawsuit
+// already imported.
+func transferLawsuit(dl *DistrictList, tl *TrialList, cl *CompensationList, lawsuitID string, targetID int, reason string, timeout time.Duration) (*TransferResult, error) {
+	res := &TransferResult{TraceID: protocol.NewMsgID()}
+	res.FormerID, res.ToTrial, res.Reason, res.Actor = lawsuitID, targetID, reason, console.UserName()
+
+	parts := lawsuitIDParts(lawsuitID)
+	for _, d := range dl.GetAll() {
+		if d.Name == localName && d.ID != 0 && d.ID != parts[0] {
+			return res, fmt.Errorf("lawsuit %s is not of this district (ID %d)", lawsuitID, d.ID)
+		}
+	}
+	from, ok := tl.FindByID(parts[1])
+	if !ok {
+		return res, fmt.Errorf("trial of the lawsuit %s (ID %d) not found in this district", lawsuitID, parts[1])
+	}
+	to, ok := tl.FindByID(targetID)
+	if !ok {
+		return res, fmt.Errorf("target trial with ID %d not found", targetID)
+	}
+	if from.ID == to.ID {
+		return res, fmt.Errorf("lawsuit %s is already in the trial %d", lawsuitID, to.ID)
+	}
+	if to.Draining {
+		return res, fmt.Errorf("the trial %d is being removed from the district", to.ID)
+	}
+	res.From, res.To, res.FromTrial = from, to, from.ID
+
+	log.Printf("[TRANSFER] %s - trace=%s lawsuit %s: trial %d (%s) -> trial %d (%s), reason=%s",
+		time.Now().Format(time.RFC3339), res.TraceID, lawsuitID, from.ID, from.Address, to.ID, to.Address, reason)
+
+	exported, err := exportLawsuitFromTrial(from.Address, lawsuitID, reason, res.TraceID, timeout)
+	if err != nil {
+		return res, err
+	}
+	newID, err := moveLawsuit(from, to, *exported, reason, res.TraceID, timeout)
+	res.LawsuitID = newID
+	if err != nil {
+		return res, err
+	}
+
+	res.At = time.Now()
+	if err := cl.Add(res.Compensation); err != nil {
+		return res, fmt.Errorf("lawsuit transferred, but the compensation was not saved: %v", err)
+	}
+	log.Printf("[TRANSFER] %s - trace=%s lawsuit %s transferred as %s; compensation recorded for the trial %d",
+		time.Now().Format(time.RFC3339), res.TraceID, lawsuitID, newID, to.ID)
+	return res, nil
+}
+
+// Import of the exported lawsuit in the trial to and its registration as
+// transferred in the trial from; returns the new ID (also with the error, if
+// the import was done)
+func moveLawsuit(from, to Trial, exported protocol.TransferLawsuit, reason, trace string, timeout time.Duration) (string, error) {
+	newID, err := importLawsuitInTrial(to.Address, exported, reason, trace, timeout)
+	if err != nil {
+		return "", err
+	}
+	if err := markTransferredInTrial(from.Address, exported.ID, newID, reason, trace, timeout); err != nil {
+		return newID, fmt.Errorf("lawsuit imported in the trial %d as %s, but not registered as transferred in the trial %d (repeat the transfer): %v",
+			to.ID, newID, from.ID, err)
+	}
+	return newID, nil
+}
+
+func exportLawsuitFromTrial(trialAddr, lawsuitID, reason, trace string, timeout time.Duration) (*protocol.TransferLawsuit, error) {
+	req := protocol.TrialExportRequest{
+		Envelope:  protocol.NewEnvelope(localSender, trace),
+		Type:      "lawsuit_export",
+		LawsuitID: lawsuitID,
+		Reason:    reason,
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("error while coding JSON (lawsuit_export) to trial %s: %v", trialAddr, err)
+	}
+
+	log.Printf("[DISTRICT->TRIAL] %s - trace=%s sending lawsuit_export lawsuit_id=%s to %s",
+		time.Now().Format(time.RFC3339), trace, lawsuitID, trialAddr)
+
+	reply, err := protocol.Exchange(tport, trialAddr, data, req.MsgID, time.Now().Add(timeout))
+	if err != nil {
+		return nil, fmt.Errorf("error while receiving response lawsuit_export from trial %s: %v", trialAddr, err)
+	}
+
+	var resp protocol.TrialExportResponse
+	if err := json.Unmarshal(reply, &resp); err != nil {
+		return nil, fmt.Errorf("error while decoding response lawsuit_export from trial %s: %v", trialAddr, err)
+	}
+
+	log.Printf("[TRIAL->DISTRICT] %s - trace=%s response lawsuit_export success=%v msg=%q (trial=%s)",
+		time.Now().Format(time.RFC3339), trace, resp.Success, resp.Message, trialAddr)
+
+	if !resp.Success || resp.Lawsuit == nil {
+		return nil, fmt.Errorf("trial %s: %s", trialAddr, resp.Message)
+	}
+	return resp.Lawsuit, nil
+}
+
+func importLawsuitInTrial(trialAddr string, lawsuit protocol.TransferLawsuit, reason, trace string, timeout time.Duration) (string, error) {
+	req := protocol.TrialImportRequest{
+		Envelope:  protocol.NewEnvelope(localSender, trace),
+		Type:      "lawsuit_import",
+		Lawsuit:   lawsuit,
+		RequestID: newRequestID(),
+		Reason:    reason,
+		Actor:     console.UserName(),
+		District:  localName,
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("error while coding JSON (lawsuit_import) to trial %s: %v", trialAddr, err)
+	}
+
+	log.Printf("[DISTRICT->TRIAL] %s - trace=%s sending lawsuit_import lawsuit_id=%s request_id=%s to %s",
+		time.Now().Format(time.RFC3339), trace, lawsuit.ID, req.RequestID, trialAddr)
+
+	reply, err := exchangeWithRetry(trialAddr, data, req.MsgID, timeout)
+	if err != nil {
+		return "", fmt.Errorf("error while receiving response lawsuit_import from trial %s: %v", trialAddr, err)
+	}
+
+	var resp protocol.TrialImportResponse
+	if err := json.Unmarshal(reply, &resp); err != nil {
+		return "", fmt.Errorf("error while decoding response lawsuit_import from trial %s: %v", trialAddr, err)
+	}
+
+	log.Printf("[TRIAL->DISTRICT] %s - trace=%s response lawsuit_import success=%v lawsuit_id=%s msg=%q (trial=%s)",
+		time.Now().Format(time.RFC3339), trace, resp.Success, resp.LawsuitID, resp.Message, trialAddr)
+
+	if !resp.Success || resp.LawsuitID == "" {
+		return "", fmt.Errorf("trial %s refused the lawsuit: %s", trialAddr, resp.Message)
+	}
+	return resp.LawsuitID, nil
+}
+
+func markTransferredInTrial(trialAddr, lawsuitID, newID, reason, trace string, timeout time.Duration) error {
+	req := protocol.TrialTransferredRequest{
+		Envelope:  protocol.NewEnvelope(localSender, trace),
+		Type:      "lawsuit_transferred",
+		LawsuitID: lawsuitID,
+		NewID:     newID,
+		RequestID: newRequestID(),
+		Reason:    reason,
+		Actor:     console.UserName(),
+		District:  localName,
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("error while coding JSON (lawsuit_transferred) to trial %s: %v", trialAddr, err)
+	}
+
+	log.Printf("[DISTRICT->TRIAL] %s - trace=%s sending lawsuit_transferred lawsuit_id=%s new_id=%s request_id=%s to %s",
+		time.Now().Format(time.RFC3339), trace, lawsuitID, newID, req.RequestID, trialAddr)
+
+	reply, err := exchangeWithRetry(trialAddr, data, req.MsgID, timeout)
+	if err != nil {
+		return fmt.Errorf("error while receiving response lawsuit_transferred from trial %s: %v", trialAddr, err)
+	}
+
+	var resp protocol.TrialTransferredResponse
+	if err := json.Unmarshal(reply, &resp); err != nil {
+		return fmt.Errorf("error while decoding response lawsuit_transferred from trial %s: %v", trialAddr, err)
+	}
+
+	log.Printf("[TRIAL->DISTRICT] %s - trace=%s response lawsuit_transferred success=%v msg=%q (trial=%s)",
+		time.Now().Format(time.RFC3339), trace, resp.Success, resp.Message, trialAddr)
+
+	if !resp.Success {
+		return fmt.Errorf("trial %s: %s", trialAddr, resp.Message)
+	}
+	return nil
+}
+
+
+// ---------- Removal of a trial (drain) ----------
+
+// Rounds of the drain: lawsuits created in the trial while it is drained (a
+// repeated request or connection to one of its lawsuits) are moved in the next
+const drainRounds = 3
+
+type DrainResult struct {
+	Trial         Trial
+	TraceID       string
+	Pending       int               // pending lawsuits moved
+	Dismissed     int               // dismissed lawsuits moved
+	Moved         map[string]string // old ID -> new ID
+	Failed        []string          // lawsuits not moved, with the error
+	Uncompensated []string          // pending lawsuits moved whose compensation was not saved, with the error
+	Unreachable   bool              // the lawsuits of the trial could not be listed (nothing moved)
+	Removed       bool
+}
+
+// Removes the trial id from the district only after its lawsuits are in the
+// other trials: it stops receiving lawsuits by free distribution (Draining),
+// each lawsuit not transferred yet is transferred (reason "decommission") to
+// the trial with the smallest workload (as in the free distribution), the
+// dismissed ones too so that they still count for res judicata, and then the
+// trial is removed. Each pending lawsuit moved is a compensation for its
+// target, as in a transfer. If a lawsuit is not moved, the trial stays in the
+// list, draining; the removal can be repeated.
+func drainTrial(tl *TrialList, cl *CompensationList, id int, timeout time.Duration) (*DrainResult, error) {
+	res := &DrainResult{TraceID: protocol.NewMsgID(), Moved: map[string]string{}}
+	trial, ok := tl.FindByID(id)
+	if !ok {
+		return res, fmt.Errorf("trial with ID %d not found", id)
+	}
+	res.Trial = trial
+
+	if err := tl.SetDraining(id, true); err != nil {
+		return res, err
+	}
+	log.Printf("[DRAIN] %s - trace=%s trial %d (%s) draining: no new lawsuits by free distribution",
+		time.Now().Format(time.RFC3339), res.TraceID, trial.ID, trial.Address)
+
+	// Targets and their workloads
+	var others []Trial
+	for _, t := range tl.GetAll() {
+		if t.ID != id && !t.Draining {
+			others = append(others, t)
+		}
+	}
+	received := cl.ByTrial()
+	workloads := make([]int, len(others))
+	reachable := make([]bool, len(others))
+	deadline := time.Now().Add(timeout)
+	fanOut(len(others), func(i int) {
+		workload, pending, err := verifyWorkloadTrial(others[i].Address, received[others[i].ID], res.TraceID, time.Until(deadline))
+		if err != nil {
+			log.Printf("[DRAIN] trace=%s trial %s left out of the targets: %v", res.TraceID, others[i].Address, err)
+			return
+		}
+		workloads[i] = workload + pending
+		reachable[i] = true
+	})
+	target := func() int {
+		best := -1
+		for i := range others {
+			if reachable[i] && (best < 0 || workloads[i] < workloads[best]) {
+				best = i
+			}
+		}
+		return best
+	}
+
+	for round := 1; ; round++ {
+		ids, err := lawsuitIDsOfTrial(trial.Address, res.TraceID, timeout)
+		if err != nil {
+			res.Unreachable = round == 1
+			return res, fmt.Errorf("the lawsuits of the trial %d could not be listed: %v", id, err)
+		}
+		if len(ids) == 0 {
+			break
+		}
+		if round > drainRounds {
+			return res, fmt.Errorf("the trial %d still has %d lawsuit(s) after %d rounds: it was not removed (repeat the removal)", id, len(ids), drainRounds)
+		}
+		if target() < 0 {
+			return res, fmt.Errorf("no other trial of this district (reachable) to receive the %d lawsuit(s) of the trial %d", len(ids), id)
+		}
+
+		for _, lid := range ids {
+			exported, err := exportLawsuitFromTrial(trial.Address, lid, protocol.TransferDecommission, res.TraceID, timeout)
+			if err != nil {
+				res.Failed = append(res.Failed, fmt.Sprintf("%s: %v", lid, err))
+				continue
+			}
+			best := target()
+			newID, err := moveLawsuit(trial, others[best], *exported, protocol.TransferDecommission, res.TraceID, timeout)
+			if err != nil {
+				res.Failed = append(res.Failed, fmt.Sprintf("%s: %v", lid, err))
+				continue
+			}
+			res.Moved[lid] = newID
+			if strings.HasPrefix(exported.Status, "dismissed") {
+				res.Dismissed++
+			} else {
+				// pending: in the workload of the target (active or by its compensation)
+				res.Pending++
+				workloads[best]++
+				c := Compensation{At: time.Now(), LawsuitID: newID, FormerID: lid, FromTrial: trial.ID, ToTrial: others[best].ID,
+					Reason: protocol.TransferDecommission, Actor: console.UserName()}
+				if err := cl.Add(c); err != nil {
+					log.Printf("[DRAIN] trace=%s compensation of the lawsuit %s (%s) not saved: %v", res.TraceID, lid, newID, err)
+					res.Uncompensated = append(res.Uncompensated, fmt.Sprintf("%s -> %s: %v", lid, newID, err))
+				}
+			}
+			log.Printf("[DRAIN] trace=%s lawsuit %s (%s) -> %s in the trial %d",
+				res.TraceID, lid, exported.Status, newID, others[best].ID)
+		}
+		if len(res.Failed) > 0 {
+			return res, fmt.Errorf("%d lawsuit(s) not moved: the trial %d was not removed (it stays draining; repeat the removal)", len(res.Failed), id)
+		}
+	}
+
+	if _, err := tl.RemoveByID(id); err != nil {
+		return res, err
+	}
+	res.Removed = true
+	log.Printf("[DRAIN] %s - trace=%s trial %d removed (%d pending and %d dismissed lawsuit(s) moved)",
+		time.Now().Format(time.RFC3339), res.TraceID, id, res.Pending, res.Dismissed)
+	return res, nil
+}
+
+// IDs of the lawsuits of the trial not transferred yet, in pages
+func lawsuitIDsOfTrial(trialAddr, trace string, timeout time.Duration) ([]string, error) {
+	var ids []string
+	page := searchPage{Sort: protocol.SortByID, Limit: 100}
+	for {
+		resp, err := searchLawsuitsAtTrial(trialAddr, "query", "list != transferred", page, trace, timeout)
+		if err != nil {
+			return nil, err
+		}
+		if !resp.Success {
+			return nil, fmt.Errorf("trial %s: %s", trialAddr, resp.Message)
+		}
+		for _, r := range resp.Results {
+			ids = append(ids, r.ID)
+		}
+		if resp.NextCursor == "" {
+			return ids, nil
+		}
+		page.Cursor = resp.NextCursor
+	}
+}
+
+
+// ---------- Distribution engine (rules 1 to 6) ----------
+
+// Result of the distribution procedure for a new lawsuit.
+// Outcome can be:
+//   - "refused": no lawsuit created (res judicata, lis pendens or contained joinder)
+//   - "merged":  claims merged into an existent lawsuit (continent joinder)
+//   - "created": new lawsuit created (repeated request, connection or free distribution)
+//   - "cancelled": the clerk did not confirm the target lawsuit
+//   - "busy": the same lawsuit is being filed by another district (fingerprint lease)
+type Decision struct {
+	Stage   string `json:"stage"`   // "res_judicata", "lis_pendens", "repeated_request", "joinder", "connection", "free"
+	Match   string `json:"match"`   // match returned by the trial (ex: "joinder_contained"); "free" for free distribution
+	Outcome string `json:"outcome"` // see above
+
+	// Shared by every message and log line of this filing (court, districts and trials)
+	TraceID string `json:"trace_id"`
+
+	// The trial refused the creation (check-and-create): Match and LawsuitID describe the winning lawsuit
+	Conflict bool `json:"conflict,omitempty"`
+
+	// Response of the trial (or aggregator district) that matched the stage; nil for free distribution
+	Evidence *protocol.TrialActionQueryResponse `json:"evidence,omitempty"`
+
+	// Created or merged lawsuit (for "refused", the lawsuit that blocked the filing)
+	LawsuitID    string `json:"lawsuit_id,omitempty"`
+	DistrictName string `json:"district_name,omitempty"`
+	TrialID      int    `json:"trial_id,omitempty"`
+	TrialAddr    string `json:"trial_addr,omitempty"`
+
+	// Every match of the stage (ranked by prevention) and the reason of the choice
+	Candidates []protocol.TrialActionQueryResponse `json:"candidates,omitempty"`
+	Reason     string                              `json:"reason,omitempty"`
+
+	// "busy": lease of the lawsuit fingerprint held by another district
+	LeaseHolder    string    `json:"lease_holder,omitempty"`
+	LeaseExpiresAt time.Time `json:"lease_expires_at,omitzero"`
+
+	Workload int      `json:"workload,omitempty"` // free distribution: workload of the chosen trial, with its compensations (-1 if random choice)
+	Message  string   `json:"message,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Engine that executes the distribution rules for a new lawsuit, in the order
+// of the pipeline (default: res_judicata -> lis_pendens -> repeated_request -> joinder -> connection),
+// and then the free distribution.
+// Used by the menu and by any other entry point able to file a lawsuit.
+type DistributionEngine struct {
+	nameDistrict string
+	dl           *DistrictList
+	tl           *TrialList
+	rules        []DistrictRule
+	timeout      time.Duration
+
+	// Optional callback called before each stage is verified (ex: progress in the menu)
+	OnStage func(stage string)
+
+	// Chooses the target lawsuit before merging or creating (default: autoChooser)
+	Chooser TargetChooser
+
+	// Court holding the fingerprint leases (empty: filing without lease)
+	CourtAddr string
+
+	// Transfers received by the trials, counted in the free distribution (nil: none)
+	Compensations *CompensationList
+}
+
+func NewDistributionEngine(nameDistrict string, dl *DistrictList, tl *TrialList, rules []DistrictRule, timeout time.Duration) *DistributionEngine {
+	return &DistributionEngine{
+		nameDistrict: nameDistrict,
+		dl:           dl,
+		tl:           tl,
+		rules:        rules,
+		timeout:      timeout,
+		Chooser:      autoChooser{},
+	}
+}
+
+func isPositiveMatch(resp *protocol.TrialActionQueryResponse) bool {
+	return resp != nil && resp.Success && resp.Match != "" && resp.Match != "none"
+}
+
+// Stages enabled in the pipeline (sent in the lawsuit_classify)
+func (e *DistributionEngine) stages() []string {
+	stages := make([]string, 0, len(e.rules))
+	for _, rule := range e.rules {
+		stages = append(stages, rule.Stage())
+	}
+	return stages
+}
+
+// Every match of the stage accepted by the rule (local trials and OTHERS districts),
+// ranked by prevention
+func stageCandidates(rule DistrictRule, local, others *protocol.TrialClassifyResponse) []protocol.TrialActionQueryResponse {
+	var candidates []protocol.TrialActionQueryResponse
+	for _, r := range []*protocol.TrialClassifyResponse{local, others} {
+		for _, m := range r.MatchesOf(rule.Stage()) {
+			if isPositiveMatch(&m) && rule.Accepts(&m) {
+				candidates = append(candidates, m)
+			}
+		}
+	}
+	rankByPrevention(candidates)
+	if f, ok := rule.(candidateFilter); ok {
+		candidates = f.Filter(candidates)
+	}
+	return candidates
+}
+
+// The trial refused the creation because an identical lawsuit (or joinder) was created
+// meanwhile: the filing is refused and the decision points to the winning lawsuit
+func (d *Decision) setConflict(resp *protocol.TrialCreateActionResponse) {
+	d.Outcome = "refused"
+	d.Conflict = true
+	d.Match = resp.ConflictMatch
+	d.LawsuitID = resp.ConflictLawsuitID
+	if resp.DistrictName != "" {
+		d.DistrictName = resp.DistrictName
+	}
+	if resp.TrialID != 0 {
+		d.TrialID = resp.TrialID
+	}
+	if resp.TrialAddr != "" {
+		d.TrialAddr = resp.TrialAddr
+	}
+	d.Reason = fmt.Sprintf("conflict at creation: lawsuit %s (%s) was registered in the trial before this filing", resp.ConflictLawsuitID, resp.ConflictMatch)
+}
+
+// Fill the decision with the matched stage and the trial where the match happened
+func (d *Decision) setEvidence(stage string, resp *protocol.TrialActionQueryResponse) {
+	d.Stage = stage
+	d.Match = resp.Match
+	d.Evidence = resp
+	d.LawsuitID = resp.LawsuitID
+	d.DistrictName = resp.DistrictName
+	d.TrialID = resp.TrialID
+	d.TrialAddr = resp.TrialAddr
+}
+
+// Execute the configured stages for the new lawsuit and return the decision taken.
+// If no stage matches, the lawsuit goes to free distribution.
+// The error is returned only if the action decided by the stage (create/merge) failed;
+// in this case the decision is also returned, describing the stage that matched.
+// With CourtAddr, the lawsuit fingerprint is leased in the Court from the first
+// check until the create, so the same lawsuit is not filed at the same time
+// in two districts; if the Court is not reached, the filing goes on with a warning.
+func (e *DistributionEngine) Distribute(lawsuit NewLawsuit) (*Decision, error) {
+	d := &Decision{TraceID: protocol.NewMsgID()}
+
+	log.Printf("[ENGINE] %s - trace=%s distributing lawsuit plaintiff=%q defendant=%q cause=%d claims=%v",
+		time.Now().Format(time.RFC3339), d.TraceID, lawsuit.Plaintiff, lawsuit.Defendant, lawsuit.CauseID, lawsuit.Claims)
+
+	var lease *filingLease
+	if e.CourtAddr != "" {
+		fl, current, err := acquireFilingLease(e.CourtAddr, e.nameDistrict, lawsuitFingerprint(lawsuit), d.TraceID)
+		switch {
+		case err != nil:
+			log.Printf("[ENGINE] %s - lease not acquired: %v", time.Now().Format(time.RFC3339), err)
+			d.Warnings = append(d.Warnings, "it was not possible to reserve the lawsuit in the Court; concurrent filings of the same lawsuit are not prevented")
+		case current != nil:
+			d.setBusy(current)
+			return d, nil
+		default:
+			lease = fl
+			defer lease.release()
+		}
+	}
+
+	// One round of lawsuit_classify: local trials and OTHERS districts
+	stages := e.stages()
+	var local, others *protocol.TrialClassifyResponse
+	fanOut(2, func(i int) {
+		if i == 0 {
+			local = classifyLocalTrials(e.tl, stages, lawsuit, d.TraceID, e.timeout)
+		} else {
+			others = classifyOtherDistricts(e.nameDistrict, e.dl, stages, lawsuit, d.TraceID, e.timeout)
+		}
+	})
+	// a trial or district not classified may hold a match: the decision is partial
+	d.Warnings = append(d.Warnings, local.Warnings...)
+	d.Warnings = append(d.Warnings, others.Warnings...)
+
+	// Precedence decided locally, in the pipeline order
+	for _, rule := range e.rules {
+		if e.OnStage != nil {
+			e.OnStage(rule.Stage())
+		}
+		candidates := stageCandidates(rule, local, others)
+		if len(candidates) == 0 {
+			continue
+		}
+		for i := range candidates {
+			if candidates[i].DistrictName == "" {
+				candidates[i].DistrictName = e.nameDistrict
+			}
+		}
+		d.Candidates = candidates
+		d.Reason = preventionReason(candidates)
+
+		chosen := 0
+		if rule.NeedsTarget(candidates) {
+			idx, err := e.Chooser.Choose(rule.Stage(), lawsuit, candidates)
+			if err != nil {
+				d.setEvidence(rule.Stage(), &candidates[0])
+				return d, fmt.Errorf("error while choosing the target lawsuit: %v", err)
+			}
+			if idx < 0 || idx >= len(candidates) {
+				d.setEvidence(rule.Stage(), &candidates[0])
+				d.Outcome = "cancelled"
+				d.Reason = "filing cancelled by the clerk"
+				return d, nil
+			}
+			if idx != 0 {
+				d.Reason = fmt.Sprintf("lawsuit %s chosen by the clerk (candidate %d of %d; %s)",
+					candidates[idx].LawsuitID, idx+1, len(candidates), preventionReason(candidates))
+			}
+			chosen = idx
+		}
+
+		resp := &candidates[chosen]
+		d.setEvidence(rule.Stage(), resp)
+		if !e.keepLease(lease, d) {
+			return d, nil
+		}
+		err := rule.Apply(e, lawsuit, resp, d)
+		return d, err
+	}
+
+	// FREE DISTRIBUTION
+	if e.OnStage != nil {
+		e.OnStage(protocol.StageFree)
+	}
+	d.Stage = protocol.StageFree
+	d.Match = protocol.StageFree
+	d.DistrictName = e.nameDistrict
+	if !e.keepLease(lease, d) {
+		return d, nil
+	}
+	if err := lawsuitFreeDistribution(e.tl, e.Compensations, lawsuit, e.timeout, d); err != nil {
+		return d, err
+	}
+	return d, nil
+}
+
+// Renew the lease before the create/merge (the clerk may have taken long to confirm).
+// Returns false (decision "busy") if the lease expired and another district took it.
+func (e *DistributionEngine) keepLease(lease *filingLease, d *Decision) bool {
+	if lease == nil {
+		return true
+	}
+	current, err := lease.renew()
+	if err != nil {
+		log.Printf("[ENGINE] %s - lease not renewed: %v", time.Now().Format(time.RFC3339), err)
+		d.Warnings = append(d.Warnings, "it was not possible to renew the lawsuit reservation in the Court")
+		return true
+	}
+	if current != nil {
+		d.setBusy(current)
+		return false
+	}
+	return true
+}
+
+// The same lawsuit is being filed by another district
+func (d *Decision) setBusy(current *protocol.Lease) {
+	d.Outcome = "busy"
+	d.LeaseHolder = current.Holder
+	d.LeaseExpiresAt = current.ExpiresAt
+	d.Reason = fmt.Sprintf("the same lawsuit is being filed by district %s (reservation until %s)",
+		current.Holder, current.ExpiresAt.Format(time.RFC3339))
+}
+
+
+// ---------- Distribution rules (stages) handled by the district ----------
+
+// Rule that handles the outcome of one stage in the district.
+// The matching itself is done by the trials (lawsuit_query with the same stage).
+type DistrictRule interface {
+	Stage() string
+	// Accepts reports if the positive response of the trials decides the distribution
+	Accepts(resp *protocol.TrialActionQueryResponse) bool
+	// NeedsTarget reports if Apply merges or creates on the lawsuit found,
+	// so the target among the candidates (ranked by prevention) must be
+	// confirmed (see TargetChooser)
+	NeedsTarget(candidates []protocol.TrialActionQueryResponse) bool
+	// Apply executes the outcome (refuse, merge or create) and completes the decision
+	Apply(e *DistributionEngine, lawsuit NewLawsuit, resp *protocol.TrialActionQueryResponse, d *Decision) error
+}
+
+// Optionally implemented by a rule whose matches do not all weigh the same:
+// Filter keeps (in the prevention order) only the candidates that decide the stage
+type candidateFilter interface {
+	Filter(ranked []protocol.TrialActionQueryResponse) []protocol.TrialActionQueryResponse
+}
+
+// Registry of the rules known by the district (stage -> rule)
+var districtRules = map[string]DistrictRule{}
+
+func registerDistrictRule(r DistrictRule) {
+	districtRules[r.Stage()] = r
+}
+
+func init() {
+	registerDistrictRule(resJudicataRule{})
+	registerDistrictRule(lisPendensRule{})
+	registerDistrictRule(repeatedRequestRule{})
+	registerDistrictRule(joinderRule{})
+	registerDistrictRule(connectionRule{})
+}
+
+// 1) Res judicata: the filing is refused
+type resJudicataRule struct{}
+
+func (resJudicataRule) Stage() string { return protocol.StageResJudicata }
+
+func (resJudicataRule) NeedsTarget([]protocol.TrialActionQueryResponse) bool { return false }
+
+func (resJudicataRule) Accepts(resp *protocol.TrialActionQueryResponse) bool {
+	return resp.Match == "res_judicata"
+}
+
+func (resJudicataRule) Apply(e *DistributionEngine, lawsuit NewLawsuit, resp *protocol.TrialActionQueryResponse, d *Decision) error {
+	d.Outcome = "refused"
+	return nil
+}
+
+// 2) Lis pendens: the filing is refused
+type lisPendensRule struct{}
+
+func (lisPendensRule) Stage() string { return protocol.StageLisPendens }
+
+func (lisPendensRule) NeedsTarget([]protocol.TrialActionQueryResponse) bool { return false }
+
+func (lisPendensRule) Accepts(resp *protocol.TrialActionQueryResponse) bool {
+	return resp.Match == "lis_pendens"
+}
+
+func (lisPendensRule) Apply(e *DistributionEngine, lawsuit NewLawsuit, resp *protocol.TrialActionQueryResponse, d *Decision) error {
+	d.Outcome = "refused"
+	return nil
+}
+
+// 3) Repeated request: new lawsuit in the SAME trial of the judgment without merits resolution
+type repeatedRequestRule struct{}
+
+func (repeatedRequestRule) Stage() string { return protocol.StageRepeatedRequest }
+
+func (repeatedRequestRule) NeedsTarget([]protocol.TrialActionQueryResponse) bool { return true }
+
+func (repeatedRequestRule) Accepts(resp *protocol.TrialActionQueryResponse) bool {
+	return resp.Match == "repeated_request"
+}
+
+func (repeatedRequestRule) Apply(e *DistributionEngine, lawsuit NewLawsuit, resp *protocol.TrialActionQueryResponse, d *Decision) error {
+	createResp, err := createLawsuitInTrialAddr(resp.TrialAddr, "repeated_request", resp.LawsuitID, lawsuit, d.TraceID, e.timeout)
+	if err != nil {
+		return fmt.Errorf("error while creating lawsuit due repetead request: %v", err)
+	}
+	if createResp.Conflict {
+		d.setConflict(createResp)
+		return nil
+	}
+	if !createResp.Success {
+		return fmt.Errorf("trial refused the lawsuit creation due repeated request: %s", createResp.Message)
+	}
+	d.Outcome = "created"
+	d.LawsuitID = createResp.LawsuitID
+	return nil
+}
+
+// 4) Joinder: contained lawsuit is refused; continent lawsuit has its claims merged
+type joinderRule struct{}
+
+func (joinderRule) Stage() string { return protocol.StageJoinder }
+
+// joinder_contained is a refusal: the clerk chooses only between several
+// lawsuits that the claims can be merged into
+func (joinderRule) NeedsTarget(candidates []protocol.TrialActionQueryResponse) bool {
+	continent := 0
+	for _, c := range candidates {
+		if c.Match == "joinder_continent" {
+			continent++
+		}
+	}
+	return continent > 1
+}
+
+func (joinderRule) Accepts(resp *protocol.TrialActionQueryResponse) bool {
+	return resp.Match == "joinder_contained" || resp.Match == "joinder_continent"
+}
+
+// A continent candidate takes the claims whatever the prevention of the
+// contained ones: the filing is refused only if no lawsuit can be expanded
+func (joinderRule) Filter(ranked []protocol.TrialActionQueryResponse) []protocol.TrialActionQueryResponse {
+	var continent []protocol.TrialActionQueryResponse
+	for _, c := range ranked {
+		if c.Match == "joinder_continent" {
+			continent = append(continent, c)
+		}
+	}
+	if len(continent) == 0 {
+		return ranked
+	}
+	return continent
+}
+
+func (joinderRule) Apply(e *DistributionEngine, lawsuit NewLawsuit, resp *protocol.TrialActionQueryResponse, d *Decision) error {
+	if resp.Match == "joinder_contained" {
+		d.Outcome = "refused"
+		return nil
+	}
+
+	mergeResp, err := sendMergeClaimsToTrialAddr(resp.TrialAddr, resp.LawsuitID, lawsuit.Claims, d.TraceID, e.timeout)
+	if err != nil {
+		return fmt.Errorf("error while sending merge of claims to the trial: %v", err)
+	}
+	if !mergeResp.Success {
+		return fmt.Errorf("trial refused the merge of claims: %s", mergeResp.Message)
+	}
+	d.Outcome = "merged"
+	return nil
+}
+
+// 5) Connection: new lawsuit in the SAME trial, for joint judgment
+type connectionRule struct{}
+
+func (connectionRule) Stage() string { return protocol.StageConnection }
+
+func (connectionRule) NeedsTarget([]protocol.TrialActionQueryResponse) bool { return true }
+
+func (connectionRule) Accepts(resp *protocol.TrialActionQueryResponse) bool {
+	return resp.Match == "connection"
+}
+
+func (connectionRule) Apply(e *DistributionEngine, lawsuit NewLawsuit, resp *protocol.TrialActionQueryResponse, d *Decision) error {
+	createResp, err := createLawsuitInTrialAddr(resp.TrialAddr, "connection", resp.LawsuitID, lawsuit, d.TraceID, e.timeout)
+	if err != nil {
+		return fmt.Errorf("error while creating lawsuit by connection: %v", err)
+	}
+	if createResp.Conflict {
+		d.setConflict(createResp)
+		return nil
+	}
+	if !createResp.Success {
+		return fmt.Errorf("trial refused to create lawsuit by connection: %s", createResp.Message)
+	}
+	d.Outcome = "created"
+	d.LawsuitID = createResp.LawsuitID
+	return nil
+}
+
+
+// ---------- Choice of the target lawsuit (merge / create) ----------
+
+// Chooses the target lawsuit among the candidates of a stage (ranked by prevention)
+// before the district merges claims or creates a lawsuit.
+// Returns the index of the chosen candidate, or -1 to cancel the filing.
+type TargetChooser interface {
+	Choose(stage string, lawsuit NewLawsuit, ranked []protocol.TrialActionQueryResponse) (int, error)
+}
+
+// Non-interactive policy (no clerk present): the winner by prevention
+type autoChooser struct{}
+
+func (autoChooser) Choose(stage string, lawsuit NewLawsuit, ranked []protocol.TrialActionQueryResponse) (int, error) {
+	return 0, nil
+}
+
+// The clerk confirms (or changes) the target in the menu
+type consoleChooser struct {
+	reader *bufio.Reader
+}
+
+func (c consoleChooser) Choose(stage string, lawsuit NewLawsuit, ranked []protocol.TrialActionQueryResponse) (int, error) {
+	fmt.Printf("\n--- %s: LAWSUITS FOUND (ranked by prevention) ---\n", strings.ToUpper(stageTitle(stage)))
+	for i, m := range ranked {
+		when := "unknown date"
+		if !m.FiledAt.IsZero() {
+			when = m.FiledAt.Local().Format("2006-01-02 15:04:05")
+		}
+		sharedCause := "no"
+		if m.SharedCause {
+			sharedCause = "yes"
+		}
+		fmt.Printf("%d) %s | District: %s | Trial: ID %d (%s) | Filed: %s | Match: %s | Overlapping claims: %v | Shared cause: %s\n",
+			i+1, m.LawsuitID, m.DistrictName, m.TrialID, m.TrialAddr, when, m.Match, m.OverlapClaims, sharedCause)
+	}
+
+	for {
+		fmt.Printf("Target lawsuit (ENTER = 1, 0 = cancel the filing)> ")
+		line, err := c.reader.ReadString('\n')
+		if err != nil {
+			return -1, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			return 0, nil
+		}
+		n, err := strconv.Atoi(line)
+		if err != nil || n < 0 || n > len(ranked) {
+			fmt.Println("Invalid option.")
+			continue
+		}
+		return n - 1, nil
+	}
+}
+
+
+// ---------- Pipeline configuration (order and enabled stages) ----------
+
+// Content of the pipeline file (ex: pipeline.json):
+//
+//	{ "stages": ["res_judicata", "lis_pendens", "repeated_request", "joinder", "connection"] }
+//
+// Stages not listed are disabled. Free distribution is always executed at the end.
+type PipelineConfig struct {
+	Stages []string `json:"stages"`
+}
+
+func defaultPipelineConfig() PipelineConfig {
+	return PipelineConfig{
+		Stages: []string{protocol.StageResJudicata, protocol.StageLisPendens, protocol.StageRepeatedRequest, protocol.StageJoinder, protocol.StageConnection},
+	}
+}
+
+// Load the pipeline configuration. If the file does not exist, uses the default pipeline.
+func loadPipelineConfig(path string) (PipelineConfig, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return defaultPipelineConfig(), nil
+		}
+		return PipelineConfig{}, err
+	}
+	defer f.Close()
+
+	var cfg PipelineConfig
+	if err := json.NewDecoder(f).Decode(&cfg); err != nil {
+		return PipelineConfig{}, fmt.Errorf("error while decoding pipeline file %s: %v", path, err)
+	}
+	return cfg, nil
+}
+
+// Resolve the stages of the configuration in the rules registry
+func buildPipeline(cfg PipelineConfig) ([]DistrictRule, error) {
+	rules := make([]DistrictRule, 0, len(cfg.Stages))
+	seen := make(map[string]bool, len(cfg.Stages))
+	for _, stage := range cfg.Stages {
+		stage = strings.TrimSpace(stage)
+		if stage == protocol.StageFree {
+			return nil, fmt.Errorf("stage %q is always the last one and must not be configured", protocol.StageFree)
+		}
+		rule, ok := districtRules[stage]
+		if !ok {
+			return nil, fmt.Errorf("unknown stage %q in the pipeline", stage)
+		}
+		if seen[stage] {
+			return nil, fmt.Errorf("stage %q repeated in the pipeline", stage)
+		}
+		seen[stage] = true
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+
+// ---------- Presentation of the decision in the menu ----------
+
+// Title of the stage shown during the verification
+func stageTitle(stage string) string {
+	switch stage {
+	case protocol.StageResJudicata:
+		return "Res judicata"
+	case protocol.StageLisPendens:
+		return "Lis pendens"
+	case protocol.StageRepeatedRequest:
+		return "Repeated request (judged WITHOUT merits resolution)"
+	case protocol.StageJoinder:
+		return "Joinder"
+	case protocol.StageConnection:
+		return "Connection"
+	case protocol.StageFree:
+		return "FREE Distribution"
+	}
+	return stage
+}
+
+func printDecision(d *Decision, lawsuit NewLawsuit) {
+	if d.Outcome == "busy" {
+		fmt.Println("\n*** LAWSUIT BEING FILED ***")
+		fmt.Printf("The same lawsuit (plaintiff, defendant, cause of action and claims) is being filed by district %s.\n", d.LeaseHolder)
+		fmt.Printf("Reservation in the Court until: %s\n", d.LeaseExpiresAt.Format("2006-01-02 15:04:05"))
+		fmt.Println("A new lawsuit will not be created now. Verify the filing again later, if necessary.")
+		return
+	}
+	if d.Outcome == "cancelled" {
+		fmt.Printf("\nFiling cancelled: the target lawsuit was not confirmed (stage %s).\n", stageTitle(d.Stage))
+		return
+	}
+	if d.Conflict {
+		fmt.Println("\n*** CONFLICT AT CREATION ***")
+		fmt.Printf("While this filing was verified, the lawsuit %s (%s) was registered.\n", d.LawsuitID, d.Match)
+		fmt.Printf("District: %s\n", d.DistrictName)
+		fmt.Printf("Trial: ID %d (%s)\n", d.TrialID, d.TrialAddr)
+		fmt.Println("A new lawsuit will not be created. Verify the filing again, if necessary.")
+		return
+	}
+
+	switch d.Stage {
+	case protocol.StageResJudicata:
+		fmt.Println("\n*** RES JUDICATA ***")
+		fmt.Println("It was found identical lawsuit (same plaintiff, defendant, cause of action and claims) already judged WITH merits resolution.")
+		fmt.Printf("District: %s (ID %d)\n", d.DistrictName, d.Evidence.DistrictID)
+		fmt.Printf("Trial: ID %d (%s)\n", d.TrialID, d.TrialAddr)
+		fmt.Printf("Lawsuit identification: %s\n", d.LawsuitID)
+		fmt.Println("It is not possible to create a new identical lawsuit, because there is already final judgment.")
+
+	case protocol.StageLisPendens:
+		fmt.Println("\n*** LIS PENDENS ***")
+		fmt.Println("It was found identical lawsuit (same plaintiff, defendant, cause of action and claims) in the ACTIVE lawsuits list.")
+		fmt.Printf("District: %s\n", d.DistrictName)
+		fmt.Printf("Trial: ID %d (%s)\n", d.TrialID, d.TrialAddr)
+		fmt.Printf("Identification of pending lawsuit: %s\n", d.LawsuitID)
+		fmt.Println("A new lawsuit will not be created, because it is case of lis pendens.")
+
+	case protocol.StageRepeatedRequest:
+		fmt.Println("\n*** REPEATED REQUEST ***")
+		fmt.Println("Its was found identical lawsuit in the lawsuits judged WITHOUT merits resolution.")
+		fmt.Printf("District: %s\n", d.DistrictName)
+		fmt.Printf("Trial: ID %d (%s)\n", d.TrialID, d.TrialAddr)
+		fmt.Printf("Identification for the already judged lawsuit: %s\n", d.Evidence.LawsuitID)
+		fmt.Println("A new lawsuit will be created (new sequential number) in the SAME trial where take place the jugement without merits resolution.")
+		if d.Outcome == "created" {
+			fmt.Printf("\nNew lawsuit created as REPEATED REQUEST.\nIdentification for the new lawsuit: %s\n", d.LawsuitID)
+		}
+
+	case protocol.StageJoinder:
+		if d.Match == "joinder_contained" {
+			fmt.Println("\n*** JOINDER (CONTAINED LAWSUIT) ***")
+			fmt.Println("It was found CONTINENT lawsuit (bigger claim) with same parties and same cause of action.")
+			fmt.Printf("District: %s\n", d.DistrictName)
+			fmt.Printf("Trial: ID %d (%s)\n", d.TrialID, d.TrialAddr)
+			fmt.Printf("Identification of CONTINENT lawsuit: %s\n", d.LawsuitID)
+			fmt.Println("A new lawsuit will not be created because the new lawsuit's claim is CONTAINED in the CONTINENT lawsuit.")
+		} else {
+			fmt.Println("\n*** JOINDER (CONTINENT LAWSUIT) ***")
+			fmt.Println("It was found a CONTAINED lawsuit (lower claim) with same parties and same cause of action.")
+			fmt.Printf("District: %s\n", d.DistrictName)
+			fmt.Printf("Trial: ID %d (%s)\n", d.TrialID, d.TrialAddr)
+			fmt.Printf("Identification of CONTAINED lawsuit (to be expanded): %s\n", d.LawsuitID)
+			fmt.Println("The lawsuits will be CONSOLIDATED, adding the new lawsuit claims to the list of claims for the CONTINENT lawsuit.")
+			if d.Outcome == "merged" {
+				fmt.Println("New lawsuit's claims sent to be consolidated at new CONTINENT lawsuit (old CONTAINED lawsuit).")
+			}
+		}
+
+	case protocol.StageConnection:
+		fmt.Println("\n*** CONNECTION ***")
+		fmt.Println("It was found CONNECTED lawsuit (same cause of action and/or same claims).")
+		fmt.Printf("District: %s\n", d.DistrictName)
+		fmt.Printf("Trial: ID %d (%s)\n", d.TrialID, d.TrialAddr)
+		fmt.Printf("Identification of already existent lawsuit: %s\n", d.Evidence.LawsuitID)
+		fmt.Println("The new lawsuit will be created in the SAME trial, for joint judgment (due the connection).")
+		if d.Outcome == "created" {
+			fmt.Printf("\nNew lawsuit created as CONNECTED.\nIdentification of the new lawsuit: %s\n", d.LawsuitID)
+			fmt.Println("The trial (server side) must internally register the connection between the connected lawsuits for joint judgment.")
+		}
+
+	case protocol.StageFree:
+		if d.Outcome != "created" {
+			return
+		}
+		fmt.Println()
+		fmt.Printf("FREE DISTRIBUTION.\n\nDistrict: %s\nTrial: ID %d (address %s)\nIdentification for the created lawsuit: %s\n\nPlaintiff: %s\nDefendant: %s\nCause (ID): %d\nClaims (IDs): %v\n",
+			strings.ToUpper(d.DistrictName),
+			d.TrialID, d.TrialAddr,
+			d.LawsuitID,
+			lawsuit.Plaintiff, lawsuit.Defendant, lawsuit.CauseID, lawsuit.Claims,
+		)
+		fmt.Printf("\n%s\n", d.Message)
+	}
+
+	if d.Reason != "" {
+		fmt.Printf("\nCriteria: %s\n", d.Reason)
+	}
+}
+
+
+// ---------- Parser for the claims (IDs separated by commas) ----------
+
+func parseClaimsInput(input string) ([]int, error) {
+	s := strings.TrimSpace(input)
+	if s == "" {
+		return nil, fmt.Errorf("claim not informed")
+	}
+	parties := strings.Split(s, ",")
+	var claims []int
+	for _, p := range parties {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		id, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid claim: %q (integer expected)", p)
+		}
+		claims = append(claims, id)
+	}
+	if len(claims) == 0 {
+		return nil, fmt.Errorf("no valid claim informed")
+	}
+	return claims, nil
+}
+
+
+// ---------- Interactive Menu ----------
+
+func Main(args []string) {
+	fs := flag.NewFlagSet("district", flag.ExitOnError)
+	// Flags
+	helpFlag := fs.Bool("h", false, "Show help")
+	infoFlag := fs.Bool("info", false, "Show information about option flags")
+	nameFlag := fs.String("name", "", "District name (if empty, uses the name saved in file district_name.txt)")
+	courtAddr := fs.String("court", "127.0.0.1:9000", "Court's address")
+	addrFlag := fs.String("addr", "", "Address for this district (for trials). If empty, uses information in the file district_addr.txt or search in the Court.")
+	transportFlag := fs.String("transport", "udp", "Transport of the messages: udp, tcp, unix or mem")
+	districtsFile := fs.String("districts", "districts_local.json", "Districts' local file")
+	trialsFile := fs.String("trials", "trials.json", "Trials' local file")
+	compensationsFile := fs.String("compensations", "compensations.json", "File of the compensations of the transfers between trials")
+	trialIDsFile := fs.String("trialids", "trial_ids.json", "File of the trial IDs already given (removed trials' IDs are not reused)")
+	pipelineFile := fs.String("pipeline", "pipeline.json", "Pipeline file with the order of the distribution stages (if absent, uses the default order)")
+	logFlag := fs.String("log", "", "Log file (or 'term' for log in the terminal; default: district.log)")
+	storageFlag := fs.String("storage", "json", "Storage of the lists: json (-districts, -trials, -trialids and -compensations files) or kv (district.db)")
+	fs.Parse(args)
+
+	if *helpFlag {
+		fmt.Println("Program used to simulate the descentralization of the procedure for adding") 
+		fmt.Println("a new lawsuit in one of the existing trials in one of the various judicial districts")
+		fmt.Println("of the Justice Court of São Paulo (Tribunal de Justiça de São Paulo), in Brazil.")
+		fmt.Println("\n Release:", Release)
+		fmt.Println()
+		fmt.Println("Usage: judiciary district [-h] [-info] [-addr <address>] [-court <address>] [-name <district name>] [-log <file_name|term>]")
+		fmt.Println("                [-pipeline <json_file>] [-transport <udp|tcp|unix|mem>] [-storage <json|kv>]")
+		fmt.Println("       at least -name option must be given if there isn't the file district_name.txt at current folder")
+		return
+	}
+
+	// Uses -info as the default behavior for -h
+	if *infoFlag {
+		fs.Usage()
+		os.Exit(0)
+	}
+
+	t, err := transport.ByName(*transportFlag)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	tport = t
+
+	// 1) Resolves district's NAME
+	nameFromFile := loadDistrictName(nameDistrictFile)
+	nameDistrict := strings.TrimSpace(*nameFlag)
+
+	if nameDistrict == "" {
+		if nameFromFile == "" {
+			fmt.Println("Error: district's name not informed by -name or found in file district_name.txt.")
+			fs.Usage()
+			os.Exit(1)
+		}
+		nameDistrict = nameFromFile
+	}
+
+	if nameDistrict != nameFromFile {
+		saveNameDistrict(nameDistrictFile, nameDistrict)
+	}
+	localSender = "district:" + nameDistrict
+	localName = nameDistrict
+
+	// LOG Configuration (if a valid district's name)
+	if *logFlag == "" {
+		logFile, err := os.OpenFile("district.log",
+			os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			fmt.Println("Error while opening default log file (district.log):", err)
+		} else {
+			log.SetOutput(logFile)
+		}
+	} else if *logFlag == "term" {
+		// stay with default output (stderr)
+	} else {
+		logFile, err := os.OpenFile(*logFlag,
+			os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			fmt.Println("Error while opening log file:", err)
+		} else {
+			log.SetOutput(logFile)
+		}
+	}
+
+	// Storage of the local lists
+	files := map[string]string{keyDistricts: *districtsFile, keyTrials: *trialsFile, keyTrialIDs: *trialIDsFile, keyCompensations: *compensationsFile}
+	store, err := storage.Open(*storageFlag, "district.db", files)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	defer store.Close()
+	if err := storage.Import(store, files); err != nil {
+		log.Printf("Error while importing the JSON lists into the storage: %v", err)
+	}
+
+	// Local districts' list
+	dl := NewDistrictList(store)
+	if err := dl.Load(); err != nil {
+		log.Printf("Error while loading local districts: %v", err)
+	}
+
+	// 2) Resolves district's ADDRESS
+	districtAddr := strings.TrimSpace(*addrFlag)
+	if districtAddr == "" {
+		addrFromFile := loadDistrictAddress(addrDistrictFile)
+		if addrFromFile != "" {
+			districtAddr = addrFromFile
+		} else {
+			log.Printf("District's address was neither provided nor found in file. Trying to get it from the Court for the district %q...", nameDistrict)
+			if err := updateDistrictsOfCourt(*courtAddr, dl); err != nil {
+				log.Printf("Error while trying to get the list of districts from the Court: %v", err)
+			} else {
+				districts := dl.GetAll()
+				for _, d := range districts {
+					if d.Name == nameDistrict {
+						districtAddr = strings.TrimSpace(d.Address)
+						if districtAddr != "" {
+							break
+						}
+					}
+				}
+			}
+
+			if districtAddr == "" {
+				fmt.Println("Error: it was not possible to set the UDP address for the district.")
+				fmt.Println("Enter it by the flag -addr or configure the file", addrDistrictFile, "(the Court must be running if not used -addr flag).")
+				os.Exit(1)
+			}
+		}
+	}
+
+	addrFromFile := loadDistrictAddress(addrDistrictFile)
+	if districtAddr != addrFromFile {
+		saveAddressDistrict(addrDistrictFile, districtAddr)
+	}
+
+	log.Printf("Starting DISTRICT %q. Court in %s. District listening trials in %s.",
+		nameDistrict, *courtAddr, districtAddr)
+
+	// Updates districts of Court (best effort)
+	if err := updateDistrictsOfCourt(*courtAddr, dl); err != nil {
+		log.Printf("It was not possible to update the districts from the Court: %v", err)
+		log.Printf("Using local list (if existent).")
+	}
+
+	// Trials' local list
+	tl := NewTrialList(store)
+	if err := tl.Load(); err != nil {
+		log.Printf("Error while loading local trials: %v", err)
+	}
+
+	// Compensations of the transfers between trials (counted in the free distribution)
+	cl := NewCompensationList(store)
+	if err := cl.Load(); err != nil {
+		log.Printf("Error while loading the compensations: %v", err)
+	}
+
+	// Distribution pipeline (stages order and enabled set)
+	pipelineCfg, err := loadPipelineConfig(*pipelineFile)
+	if err != nil {
+		fmt.Println("Error while loading the pipeline configuration:", err)
+		os.Exit(1)
+	}
+	pipeline, err := buildPipeline(pipelineCfg)
+	if err != nil {
+		fmt.Println("Error in the pipeline configuration:", err)
+		os.Exit(1)
+	}
+	log.Printf("Distribution pipeline: %v -> %s", pipelineCfg.Stages, protocol.StageFree)
+
+	console.ClearScreen()
+	time.Sleep(100 * time.Millisecond)
+	console.ClearScreen()
+	fmt.Printf("DISTRICT %q. Court in %s. District listening trials in %s.",
+		nameDistrict, *courtAddr, districtAddr)
+	time.Sleep(2000 * time.Millisecond)
+	console.ClearScreen()
+
+	// UDP server for trials (now with access to the list of districts/trial and district's name)
+	startTrialsServer(districtAddr, nameDistrict, dl, tl)
+
+	// Interactive Menu
+	reader := bufio.NewReader(os.Stdin)
+	const udpTimeout = 2 * time.Second
+	const searchPageSize = 20
+
+	for {
+		fmt.Printf("\n========== DISTRICT - %s ==========\n", strings.ToUpper(nameDistrict))
+		fmt.Println("1 (E) - Enter a lawsuit")
+		fmt.Println("2 (S) - Search for lawsuits")
+		fmt.Println("3 (D) - List the districts")
+		fmt.Println("4 (T) - List the trials")
+		fmt.Println("5 (A) - Add a trial")
+		fmt.Println("6 (M) - Remove a trial")
+		fmt.Println("7 (Q) - Quit")
+		fmt.Println("8 (R) - Refresh (clear the screen)")
+		fmt.Println("9 (X) - Transfer a lawsuit to another trial (recusal, declination of competence)")
+		fmt.Print("Your option> ")
+
+		line, _ := reader.ReadString('\n')
+		opt := strings.TrimSpace(line)
+
+		switch opt {
+
+		case "8", "r", "R":
+			console.ClearScreen()
+			continue
+
+		case "1", "E", "e":
+			// 1) Try to update the districts' list in the Court
+			fmt.Println("\nUpdating the districts' list in the Court...")
+			if err := updateDistrictsOfCourt(*courtAddr, dl); err != nil {
+				fmt.Println("Warning: it was not possible to connect to the Court. Using local list.")
+				log.Printf("Fault while updating the Court's districts before adding a new lawsuit: %v", err)
+			} else {
+				fmt.Println("Districts' list updated from the Court.")
+			}
+
+			// 2) Ask for new lawsuit data 
+			fmt.Print("\nPlaintiff: ")
+			plaintiff, _ := reader.ReadString('\n')
+			plaintiff = strings.TrimSpace(plaintiff)
+
+			fmt.Print("Defendant: ")
+			defendant, _ := reader.ReadString('\n')
+			defendant = strings.TrimSpace(defendant)
+
+			fmt.Print("Cause of action (numeric ID): ")
+			causeStr, _ := reader.ReadString('\n')
+			causeStr = strings.TrimSpace(causeStr)
+			causeID, err := strconv.Atoi(causeStr)
+			if err != nil || causeID <= 0 {
+				fmt.Println("Invalid cause of action (must be an integer).")
+				fmt.Print("\nPress ENTER to return to menu...")
+				reader.ReadString('\n')
+				console.ClearScreen()
+				continue
+			}
+
+			fmt.Print("Claims (numeric IDs separated by commas; ex.: 10 or 10,20,30): ")
+			pedStr, _ := reader.ReadString('\n')
+			pedStr = strings.TrimSpace(pedStr)
+			claims, err := parseClaimsInput(pedStr)
+			if err != nil {
+				fmt.Println("Error:", err)
+				fmt.Print("\nPress ENTER to return to menu...")
+				reader.ReadString('\n')
+				console.ClearScreen()
+				continue
+			}
+
+			new_lawsuit := NewLawsuit{
+				Plaintiff: plaintiff,
+				Defendant: defendant,
+				CauseID:   causeID,
+				Claims:    claims,
+			}
+
+			fmt.Println("\nStarting the verification for the lawsuit distribution...")
+			engine := NewDistributionEngine(nameDistrict, dl, tl, pipeline, udpTimeout)
+			engine.Chooser = consoleChooser{reader: reader}
+			engine.CourtAddr = *courtAddr
+			engine.Compensations = cl
+			step := 0
+			engine.OnStage = func(stage string) {
+				step++
+				fmt.Printf("%d) %s\n", step, stageTitle(stage))
+			}
+
+			decision, err := engine.Distribute(new_lawsuit)
+			for _, w := range decision.Warnings {
+				fmt.Println("Warning:", w)
+			}
+			printDecision(decision, new_lawsuit)
+			if err != nil {
+				fmt.Println("\nError:", err)
+			}
+			fmt.Printf("\nTrace of this filing in the logs: %s\n", decision.TraceID)
+
+			fmt.Print("\nPress ENTER to return to menu...")
+			reader.ReadString('\n')
+			console.ClearScreen()
+
+		case "2", "S", "s":
+			// ---------- SEARCH FOR LAWSUITS IN ALL DISTRICT'S TRIALS (OR IN ALL THE DISTRICTS) ----------
+			trials := tl.GetAll()
+
+			console.ClearScreen()
+			fmt.Println()
+			fmt.Println("Search for lawsuits in ALL the trials of this district (or of all the districts).")
+			fmt.Println("Buscar por:")
+			fmt.Println("Search for:")
+			fmt.Println("1 (I) - Lawsuit ID")
+			fmt.Println("2 (P) - Plaintiff")
+			fmt.Println("3 (D) - Defendant")
+			fmt.Println("4 (C) - Cause of action (exact number)")
+			fmt.Println("5 (M) - Claim (exact number)")
+			fmt.Println("6 (Q) - Compound query (e.g.: defendant contains Banco AND cause=12 AND list=Active)")
+			fmt.Println("7 (R) - Return to  menu")
+			fmt.Print("Your option> ")
+			fieldStr, _ := reader.ReadString('\n')
+			fieldStr = strings.TrimSpace(fieldStr)
+
+			var field string
+			switch fieldStr {
+			case "1", "I", "i":
+				field = "id"
+			case "2", "P", "p":
+				field = "plaintiff"
+			case "3", "D", "d":
+				field = "defendant"
+			case "4", "C", "c":
+				field = "cause"
+			case "5", "M", "m":
+				field = "claim"
+			case "6", "Q", "q":
+				field = "query"
+			case "7", "R", "r":
+				console.ClearScreen()
+				continue
+			default:
+				fmt.Println("Invalid option.")
+				fmt.Print("\nPress ENTER to return to menu...")
+				reader.ReadString('\n')
+				console.ClearScreen()
+				continue
+			}
+
+			fmt.Print("Value for serach> ")
+			val, _ := reader.ReadString('\n')
+			val = strings.TrimSpace(val)
+			if val == "" {
+				fmt.Println("Empty search value.")
+				fmt.Print("\nPress ENTER to return to menu...")
+				reader.ReadString('\n')
+				console.ClearScreen()
+				continue
+			}
+
+			if field == "query" {
+				// the trials parse the query again; here only to show the error before sending
+				if _, err := query.Parse(val); err != nil {
+					fmt.Println("Invalid query:", err)
+					fmt.Print("\nPress ENTER to return to menu...")
+					reader.ReadString('\n')
+					console.ClearScreen()
+					continue
+				}
+			}
+
+			fmt.Print("Sort by: 1 (I) - ID, 2 (L) - list, 3 (P) - plaintiff [ENTER: ID]> ")
+			sortStr, _ := reader.ReadString('\n')
+			sortBy := protocol.SortByID
+			switch strings.TrimSpace(sortStr) {
+			case "2", "L", "l":
+				sortBy = protocol.SortByList
+			case "3", "P", "p":
+				sortBy = protocol.SortByPlaintiff
+			}
+
+			fmt.Print("Search in: 1 (L) - this district, 2 (A) - all the districts [ENTER: this district]> ")
+			scopeStr, _ := reader.ReadString('\n')
+			allDistricts := false
+			switch strings.TrimSpace(scopeStr) {
+			case "2", "A", "a":
+				allDistricts = true
+			}
+
+			// Sources of the search: the local trials and, for all the districts, each
+			// other district (it searches its own trials, see handleSearchDistrict)
+			type searchSource struct {
+				name    string
+				addr    string
+				trialID int // 0 for other district
+			}
+			sources := []searchSource{}
+			for _, t := range trials {
+				sources = append(sources, searchSource{name: fmt.Sprintf("Trial ID %d", t.ID), addr: t.Address, trialID: t.ID})
+			}
+			if allDistricts {
+				for _, d := range otherDistricts(nameDistrict, dl) {
+					sources = append(sources, searchSource{name: "District " + d.Name, addr: d.Address})
+				}
+			}
+			if len(sources) == 0 {
+				fmt.Println("There are no trials to search.")
+				fmt.Print("\nPress ENTER to return to menu...")
+				reader.ReadString('\n')
+				console.ClearScreen()
+				continue
+			}
+
+			if allDistricts {
+				fmt.Println("\nSearching in all trials of this district and in the other districts...")
+			} else {
+				fmt.Println("\nSearching in all trials of this district...")
+			}
+			totalFound := 0
+			totalShown := 0
+			trace := protocol.NewMsgID()
+			stop := false
+
+			// First pages asked to all the sources at the same time
+			firstPage := searchPage{Sort: sortBy, Limit: searchPageSize}
+			firsts := make([]*protocol.TrialSearchLawsuitsResponse, len(sources))
+			firstErrs := make([]error, len(sources))
+			fanOut(len(sources), func(i int) {
+				firsts[i], firstErrs[i] = searchLawsuitsAtTrial(sources[i].addr, field, val, firstPage, trace, udpTimeout)
+			})
+
+			// Results source by source, one page at a time
+			for i, src := range sources {
+				page := firstPage
+				resp, err := firsts[i], firstErrs[i]
+				shown := 0
+				for {
+					if err != nil {
+						fmt.Printf("Warning: fault while searching in the %s (%s): %v\n", src.name, src.addr, err)
+						break
+					}
+					if !resp.Success {
+						fmt.Printf("Warning: %s (%s) returned error: %s\n", src.name, src.addr, resp.Message)
+						break
+					}
+					if len(resp.Results) == 0 {
+						break
+					}
+
+					total := resp.Total
+					if total == 0 {
+						// trial without pages: every result in one response
+						total = len(resp.Results)
+					}
+
+					if shown == 0 {
+						if totalFound == 0 {
+							fmt.Println("\n--- SEARCH RESULTS ---")
+						}
+						fmt.Printf("\n[%s - %s] %d lawsuit(s) found\n", src.name, src.addr, total)
+						totalFound += total
+					}
+					for _, r := range resp.Results {
+						districtName, trialID, trialAddr := r.DistrictName, r.TrialID, r.TrialAddr
+						if districtName == "" && src.trialID != 0 {
+							districtName = nameDistrict
+						}
+						if trialID == 0 {
+							trialID = src.trialID
+						}
+						if trialAddr == "" && src.trialID != 0 {
+							trialAddr = src.addr
+						}
+						fmt.Printf("[District %s - Trial %d - %s] [%s] ID: %s | Plaintiff: %s | Defendant: %s | Cause: %d | Claims: %v\n",
+							districtName, trialID, trialAddr,
+							r.List,
+							r.ID, r.Plaintiff, r.Defendant, r.CauseAction, r.Claims)
+						for _, h := range r.History {
+							fmt.Println("    " + h.String())
+						}
+					}
+					shown += len(resp.Results)
+					totalShown += len(resp.Results)
+
+					if resp.NextCursor == "" {
+						break
+					}
+					fmt.Printf("(%d of %d) ENTER - next page | N - next trial/district | Q - stop the search> ", shown, total)
+					opt, _ := reader.ReadString('\n')
+					opt = strings.ToUpper(strings.TrimSpace(opt))
+					if opt == "Q" {
+						stop = true
+						break
+					}
+					if opt == "N" {
+						break
+					}
+					page.Cursor = resp.NextCursor
+					resp, err = searchLawsuitsAtTrial(src.addr, field, val, page, trace, udpTimeout)
+				}
+				if stop {
+					break
+				}
+			}
+
+			if totalFound == 0 {
+				if allDistricts {
+					fmt.Println("no lawsuit found in the districts' trials.")
+				} else {
+					fmt.Println("no lawsuit found in this district's trials.")
+				}
+			} else {
+				fmt.Printf("\nTotal of found lawsuits: %d (shown: %d)\n", totalFound, totalShown)
+			}
+
+			fmt.Print("\nPress ENTER to return to menu...")
+			reader.ReadString('\n')
+			console.ClearScreen()
+
+		case "3", "D", "d":
+			fmt.Println("\nSearching districts' list in the Court...")
+			err := updateDistrictsOfCourt(*courtAddr, dl)
+			if err != nil {
+				fmt.Println("It was not possible to connect to the Court. Using local list.")
+				log.Printf("Fault while updating the Court's districts: %v", err)
+			} else {
+				fmt.Println("Districts list updated from the Court.")
+			}
+
+			districts := dl.GetAll()
+			if len(districts) == 0 {
+				fmt.Println("(none district in the list)")
+			} else {
+				fmt.Println("\n--- DISTRICTS ---")
+				for _, d := range districts {
+					fmt.Printf("ID %d | %s | %s | %d trials\n",
+						d.ID, d.Name, d.Address, d.Trials)
+				}
+			}
+
+			fmt.Print("\nPress ENTER to return to menu...")
+			reader.ReadString('\n')
+			console.ClearScreen()
+
+		case "4", "T", "t":
+			trials := tl.GetAll()
+			if len(trials) == 0 {
+				fmt.Println("(no trials registered for this district)")
+			} else {
+				received := cl.ByTrial()
+				fmt.Println("\n--- TRIALS ---")
+				for _, t := range trials {
+					fmt.Printf("ID %d | Endereço UDP: %s", t.ID, t.Address)
+					if n := len(received[t.ID]); n > 0 {
+						fmt.Printf(" | Received by transfer: %d", n)
+					}
+					if t.Draining {
+						fmt.Print(" | Draining (no new lawsuits)")
+					}
+					fmt.Println()
+				}
+			}
+			if retired := tl.GetRetired(); len(retired) > 0 {
+				fmt.Println("\n--- REMOVED TRIALS (IDs not reused) ---")
+				for _, r := range retired {
+					fmt.Printf("ID %d | Endereço UDP: %s | Removed at %s\n",
+						r.ID, r.Address, r.RemovedAt.Local().Format("2006-01-02 15:04:05"))
+				}
+			}
+			if comps := cl.GetAll(); len(comps) > 0 {
+				fmt.Println("\n--- COMPENSATIONS (transfers between trials) ---")
+				for _, c := range comps {
+					fmt.Printf("%s | %s (trial %d) -> %s (trial %d) | Reason: %s | By: %s\n",
+						c.At.Local().Format("2006-01-02 15:04:05"), c.FormerID, c.FromTrial, c.LawsuitID, c.ToTrial, c.Reason, c.Actor)
+				}
+			}
+
+			fmt.Print("\nPress ENTER to return to menu...")
+			reader.ReadString('\n')
+			console.ClearScreen()
+
+		case "5", "A", "a":
+			fmt.Print("UDP address for the new trial (ex: 127.0.0.1:9201): ")
+			endStr, _ := reader.ReadString('\n')
+			endStr = strings.TrimSpace(endStr)
+			if endStr == "" {
+				fmt.Println("Invalid address.")
+
+				fmt.Print("\nPress ENTER to return to menu...")
+				reader.ReadString('\n')
+				console.ClearScreen()
+				continue
+			}
+
+			t, err := tl.Add(endStr)
+			if err != nil {
+				fmt.Println("Error while adding trial:", err)
+				log.Printf("Error while adding trial: %v", err)
+
+				fmt.Print("\nPress ENTER to return to menu...")
+				reader.ReadString('\n')
+				console.ClearScreen()
+				continue
+			}
+			fmt.Println()
+			fmt.Printf("Trial added: ID %d, address %s\n", t.ID, t.Address)
+
+			totalTrials := tl.Count()
+			if err := sendUpdateTrials(*courtAddr, nameDistrict, totalTrials); err != nil {
+				fmt.Println("Warning: it was not possible notify the Court about the number of trials.")
+				log.Printf("Error while sending update_trials to the Court: %v", err)
+			} else {
+				fmt.Println("Court notified about the number of trials.")
+			}
+
+			fmt.Print("\nPress ENTER to return to menu...")
+			reader.ReadString('\n')
+			console.ClearScreen()
+
+		case "6", "M", "m":
+			fmt.Print("ID da trial to be removed: ")
+			idStr, _ := reader.ReadString('\n')
+			idStr = strings.TrimSpace(idStr)
+			id, err := strconv.Atoi(idStr)
+			if err != nil {
+				fmt.Println("Invalid ID.")
+
+				fmt.Print("\nPress ENTER to return to menu...")
+				reader.ReadString('\n')
+				console.ClearScreen()
+				continue
+			}
+
+			if _, ok := tl.FindByID(id); !ok {
+				fmt.Printf("Error while removing trial: trial with ID %d not found\n", id)
+
+				fmt.Print("\nPress ENTER to return to menu...")
+				reader.ReadString('\n')
+				console.ClearScreen()
+				continue
+			}
+			fmt.Printf("\nThe trial %d will receive no new lawsuits and its lawsuits will be transferred to the other trials\n", id)
+			fmt.Println("of the district (by workload; the dismissed ones too, so that they still count for res judicata).")
+			fmt.Print("Only then it is removed. Confirm (y/N)> ")
+			confirm, _ := reader.ReadString('\n')
+			if c := strings.ToLower(strings.TrimSpace(confirm)); c != "y" && c != "yes" {
+				fmt.Println("Operation cancelled.")
+
+				fmt.Print("\nPress ENTER to return to menu...")
+				reader.ReadString('\n')
+				console.ClearScreen()
+				continue
+			}
+
+			fmt.Println("\nDraining the trial...")
+			res, err := drainTrial(tl, cl, id, udpTimeout)
+			if len(res.Moved) > 0 {
+				fmt.Printf("%d pending and %d dismissed lawsuit(s) transferred:\n", res.Pending, res.Dismissed)
+				old := make([]string, 0, len(res.Moved))
+				for o := range res.Moved {
+					old = append(old, o)
+				}
+				sort.Strings(old)
+				for _, o := range old {
+					fmt.Printf("  %s -> %s\n", o, res.Moved[o])
+				}
+			}
+			for _, f := range res.Failed {
+				fmt.Println("Not transferred:", f)
+			}
+			for _, u := range res.Uncompensated {
+				fmt.Println("Transferred, but the compensation was not saved:", u)
+			}
+			if err != nil {
+				fmt.Println("\nError:", err)
+				log.Printf("Error while removing trial: %v", err)
+				if res.Unreachable {
+					fmt.Print("Remove the trial anyway (its lawsuits become unreachable)? (y/N)> ")
+					force, _ := reader.ReadString('\n')
+					if f := strings.ToLower(strings.TrimSpace(force)); f == "y" || f == "yes" {
+						if _, err := tl.RemoveByID(id); err == nil {
+							res.Removed = true
+							log.Printf("[DRAIN] trace=%s trial %d removed without drain", res.TraceID, id)
+						}
+					} else if err := tl.SetDraining(id, false); err == nil {
+						fmt.Println("The trial was kept (and receives new lawsuits again).")
+					}
+				}
+			}
+			fmt.Printf("\nTrace of this removal in the logs: %s\n", res.TraceID)
+			if !res.Removed {
+				fmt.Print("\nPress ENTER to return to menu...")
+				reader.ReadString('\n')
+				console.ClearScreen()
+				continue
+			}
+			t := res.Trial
+			fmt.Println()
+			fmt.Printf("Trial removed: ID %d, address %s\n", t.ID, t.Address)
+
+			totalTrials := tl.Count()
+			if err := sendUpdateTrials(*courtAddr, nameDistrict, totalTrials); err != nil {
+				fmt.Println("Warning: it was not possible notify the Court about the number of trials.")
+				log.Printf("Error while sending update_trials to the Court: %v", err)
+			} else {
+				fmt.Println("Court notified about the new number of trials.")
+			}
+
+			fmt.Print("\nPress ENTER to return to menu...")
+			reader.ReadString('\n')
+			console.ClearScreen()
+
+		case "9", "X", "x":
+			// Transfer of one lawsuit between trials of this district (see transferLawsuit)
+			fmt.Print("\nID of the lawsuit to be transferred: ")
+			idStr, _ := reader.ReadString('\n')
+			idStr = strings.TrimSpace(idStr)
+			fmt.Print("ID of the target trial: ")
+			targetStr, _ := reader.ReadString('\n')
+			targetID, err := strconv.Atoi(strings.TrimSpace(targetStr))
+			if idStr == "" || err != nil {
+				fmt.Println("Invalid lawsuit or trial ID.")
+				fmt.Print("\nPress ENTER to return to menu...")
+				reader.ReadString('\n')
+				console.ClearScreen()
+				continue
+			}
+			fmt.Print("Reason: 1 (R) - recusal of the judge, 2 (D) - declination of competence, or other text> ")
+			reasonStr, _ := reader.ReadString('\n')
+			reason := strings.TrimSpace(reasonStr)
+			switch reason {
+			case "1", "R", "r":
+				reason = protocol.TransferRecusal
+			case "2", "D", "d":
+				reason = protocol.TransferDeclination
+			}
+			if reason == "" {
+				fmt.Println("The reason of the transfer is required.")
+				fmt.Print("\nPress ENTER to return to menu...")
+				reader.ReadString('\n')
+				console.ClearScreen()
+				continue
+			}
+
+			fmt.Println("\nTransferring the lawsuit...")
+			res, err := transferLawsuit(dl, tl, cl, idStr, targetID, reason, udpTimeout)
+			if err != nil {
+				fmt.Println("\nError:", err)
+			} else {
+				fmt.Printf("\nLawsuit %s (trial %d, %s) transferred to the trial %d (%s).\n", res.FormerID, res.From.ID, res.From.Address, res.To.ID, res.To.Address)
+				fmt.Printf("Identification of the lawsuit in the new trial: %s\n", res.LawsuitID)
+				fmt.Printf("Compensation recorded: the trial %d has received %d lawsuit(s) by transfer.\n", res.To.ID, len(cl.ByTrial()[res.To.ID]))
+			}
+			fmt.Printf("\nTrace of this transfer in the logs: %s\n", res.TraceID)
+
+			fmt.Print("\nPress ENTER to return to menu...")
+			reader.ReadString('\n')
+			console.ClearScreen()
+
+		case "7", "Q", "q":
+			// Quit
+			if err := tl.Save(); err != nil {
+				log.Printf("Error while saving trials during quit: %v", err)
+			}
+			if err := tl.Save(); err != nil {
+				log.Printf("Error while saving trials during quit: %v", err)
+			}
+			saveNameDistrict(nameDistrictFile, nameDistrict)
+			saveAddressDistrict(addrDistrictFile, districtAddr)
+			fmt.Println("Data saved. Finishing district.")
+			return
+
+		default:
+			fmt.Println("Invalid option.")
+			fmt.Print("\nPress ENTER to return to menu...")
+			reader.ReadString('\n')
+			console.ClearScreen()
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package district
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"judiciary/internal/protocol"
+	"judiciary/internal/trial"
+)
+
+// Trial 1 with pending, dismissed and already transferred lawsuits is drained
+// into the trials 2 and 3 (workload 1 each): the pending ones go each to the
+// smallest workload and are compensated, the dismissed one is only moved
+func TestDrainTrial(t *testing.T) {
+	td := newTestDistrict(t, 3)
+	t1 := td.trials[1]
+	gone := seedLawsuit(t, t1, trial.StatusActive, NewLawsuit{"Ann", "Bank", 1, []int{1}})
+	active := seedLawsuit(t, t1, trial.StatusActive, NewLawsuit{"Ben", "Bank", 2, []int{2}})
+	suspended := seedLawsuit(t, t1, trial.StatusSuspended, NewLawsuit{"Cid", "Bank", 3, []int{3}})
+	dismissed := seedLawsuit(t, t1, trial.StatusDisWithMerit, NewLawsuit{"Dee", "Bank", 4, []int{4}})
+	seedLawsuit(t, td.trials[2], trial.StatusActive, NewLawsuit{"Eli", "Shop", 5, []int{5}})
+	tr, err := transferLawsuit(td.dl, td.tl, td.cl, gone.ID, 3, protocol.TransferRecusal, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := drainTrial(td.tl, td.cl, 1, time.Second)
+	if err != nil {
+		t.Fatalf("drainTrial: %v (%+v)", err, res)
+	}
+	if !res.Removed || res.Pending != 2 || res.Dismissed != 1 || len(res.Moved) != 3 || len(res.Failed) > 0 || len(res.Uncompensated) > 0 {
+		t.Fatalf("result %+v", res)
+	}
+	if _, ok := res.Moved[gone.ID]; ok {
+		t.Errorf("lawsuit transferred before the drain moved again: %v", res.Moved)
+	}
+	if _, ok := td.tl.FindByID(1); ok {
+		t.Error("trial 1 still in the district")
+	}
+
+	for _, w := range []struct {
+		old    trial.Lawsuit
+		status string
+		target int
+	}{
+		{active, trial.StatusActive, 2},          // tie: the first trial of the list
+		{suspended, trial.StatusSuspended, 3},    // 2 has now workload 2
+		{dismissed, trial.StatusDisWithMerit, 2}, // no workload: the first again
+	} {
+		newID := res.Moved[w.old.ID]
+		if !strings.HasPrefix(newID, fmt.Sprintf("1.%d.", w.target)) {
+			t.Errorf("lawsuit %s moved as %s, want the trial %d", w.old.ID, newID, w.target)
+			continue
+		}
+		if got := lookupLawsuit(t, td.trials[w.target], newID); got.Status != w.status || got.Plaintiff != w.old.Plaintiff {
+			t.Errorf("lawsuit %s in the target %+v", w.old.ID, got)
+		}
+		if got := lookupLawsuit(t, t1, w.old.ID); got.Status != trial.StatusTransferred || got.TransferredTo != newID {
+			t.Errorf("lawsuit %s in the drained trial %s -> %s", w.old.ID, got.Status, got.TransferredTo)
+		}
+	}
+
+	// compensations: the transfer and the two pending lawsuits, none for the dismissed one
+	want := map[string]Compensation{
+		gone.ID:      {LawsuitID: tr.LawsuitID, FromTrial: 1, ToTrial: 3, Reason: protocol.TransferRecusal},
+		active.ID:    {LawsuitID: res.Moved[active.ID], FromTrial: 1, ToTrial: 2, Reason: protocol.TransferDecommission},
+		suspended.ID: {LawsuitID: res.Moved[suspended.ID], FromTrial: 1, ToTrial: 3, Reason: protocol.TransferDecommission},
+	}
+	all := td.cl.GetAll()
+	if len(all) != len(want) {
+		t.Fatalf("compensations %+v", all)
+	}
+	for _, c := range all {
+		w, ok := want[c.FormerID]
+		if !ok || c.LawsuitID != w.LawsuitID || c.FromTrial != w.FromTrial || c.ToTrial != w.ToTrial || c.Reason != w.Reason {
+			t.Errorf("compensation %+v, want %+v", c, w)
+		}
+	}
+}
+
+// A trial being drained receives nothing by free distribution, even with the
+// smallest workload
+func TestFreeDistributionSkipsDraining(t *testing.T) {
+	td := newTestDistrict(t, 2)
+	seedLawsuit(t, td.trials[2], trial.StatusActive, NewLawsuit{"Eli", "Shop", 5, []int{5}})
+	seedLawsuit(t, td.trials[2], trial.StatusActive, NewLawsuit{"Fay", "Shop", 6, []int{6}})
+	if err := td.tl.SetDraining(1, true); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := td.engine().Distribute(NewLawsuit{"Gus", "Bank", 7, []int{7}})
+	if err != nil {
+		t.Fatalf("Distribute: %v", err)
+	}
+	if d.Stage != protocol.StageFree || d.Outcome != "created" || d.TrialID != 2 || d.Workload != 2 {
+		t.Fatalf("decision %s/%s in the trial %d (workload %d)", d.Stage, d.Outcome, d.TrialID, d.Workload)
+	}
+	if n := len(td.trials[1].GetByStatus(trial.StatusActive)); n != 0 {
+		t.Errorf("%d lawsuits created in the trial being drained", n)
+	}
+
+	if err := td.tl.SetDraining(2, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := td.engine().Distribute(NewLawsuit{"Hal", "Bank", 8, []int{8}}); err == nil || !strings.Contains(err.Error(), "being drained") {
+		t.Fatalf("Distribute with every trial drained: %v", err)
+	}
+}
@@ -0,0 +1,368 @@
+package district
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"judiciary/internal/protocol"
+	"judiciary/internal/storage"
+	"judiciary/internal/transport"
+	"judiciary/internal/trial"
+)
+
+// District "A" (ID 1) whose trials run in the test process, on the mem transport
+type testDistrict struct {
+	name   string
+	tl     *TrialList
+	dl     *DistrictList
+	cl     *CompensationList
+	trials map[int]*trial.TrialStore
+	prefix string // of the mem addresses
+}
+
+func newTestDistrict(t *testing.T, trials int) *testDistrict {
+	t.Helper()
+	dir := t.TempDir()
+	store := storage.NewJSONFiles(map[string]string{
+		keyDistricts:     filepath.Join(dir, "districts.json"),
+		keyTrials:        filepath.Join(dir, "trials.json"),
+		keyTrialIDs:      filepath.Join(dir, "trial_ids.json"),
+		keyCompensations: filepath.Join(dir, "compensations.json"),
+	})
+	td := &testDistrict{
+		name:   "A",
+		tl:     NewTrialList(store),
+		dl:     NewDistrictList(store),
+		cl:     NewCompensationList(store),
+		trials: map[int]*trial.TrialStore{},
+		prefix: strings.ReplaceAll(t.Name(), "/", "-"),
+	}
+	for i := 0; i < trials; i++ {
+		td.addTrial(t)
+	}
+	return td
+}
+
+// New trial of the district, listening at mem://<test>-t<ID>
+func (td *testDistrict) addTrial(t *testing.T) *trial.TrialStore {
+	t.Helper()
+	n := len(td.trials) + 1
+	addr := fmt.Sprintf("mem://%s-t%d", td.prefix, n)
+	tr, err := td.tl.Add(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := trial.NewTrialStore(filepath.Join(t.TempDir(), "lawsuits.json"), nil)
+	if err := ts.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.UpdateInfo(1, td.name, tr.ID, addr); err != nil {
+		t.Fatal(err)
+	}
+	ln, err := trial.Serve(ts, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	td.trials[tr.ID] = ts
+	return ts
+}
+
+func (td *testDistrict) engine() *DistributionEngine {
+	rules, err := buildPipeline(defaultPipelineConfig())
+	if err != nil {
+		panic(err)
+	}
+	e := NewDistributionEngine(td.name, td.dl, td.tl, rules, time.Second)
+	e.Compensations = td.cl
+	return e
+}
+
+// Lawsuit registered in the trial with the status
+func seedLawsuit(t *testing.T, ts *trial.TrialStore, status string, q NewLawsuit) trial.Lawsuit {
+	t.Helper()
+	a, err := ts.CreateLawsuit(q.Plaintiff, q.Defendant, q.CauseID, q.Claims, nil, protocol.Audit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != trial.StatusActive {
+		if a, err = ts.SetStatus(a.ID, status, protocol.Audit{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return a
+}
+
+func lookupLawsuit(t *testing.T, ts *trial.TrialStore, id string) trial.Lawsuit {
+	t.Helper()
+	a, _, err := ts.NextStatuses(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestDistributeStages(t *testing.T) {
+	type seed struct {
+		trial  int
+		status string
+		q      NewLawsuit
+	}
+	for _, tc := range []struct {
+		name    string
+		seeds   []seed
+		lawsuit NewLawsuit
+
+		stage, match, outcome string
+		trial                 int  // trial of the decision
+		onSeed                bool // LawsuitID is the (first) seeded lawsuit; otherwise a new one
+	}{
+		{
+			name:    "res judicata",
+			seeds:   []seed{{2, trial.StatusDisWithMerit, NewLawsuit{"Alice", "Bank", 1, []int{1}}}},
+			lawsuit: NewLawsuit{"alice", "BANK", 1, []int{1}},
+			stage:   protocol.StageResJudicata, match: "res_judicata", outcome: "refused", trial: 2, onSeed: true,
+		},
+		{
+			name:    "lis pendens",
+			seeds:   []seed{{1, trial.StatusSuspended, NewLawsuit{"Bob", "Bank", 2, []int{2, 3}}}},
+			lawsuit: NewLawsuit{"Bob", "Bank", 2, []int{3, 2}},
+			stage:   protocol.StageLisPendens, match: "lis_pendens", outcome: "refused", trial: 1, onSeed: true,
+		},
+		{
+			name:    "repeated request",
+			seeds:   []seed{{2, trial.StatusDisWithoutMerit, NewLawsuit{"Carl", "Bank", 3, []int{4}}}},
+			lawsuit: NewLawsuit{"Carl", "Bank", 3, []int{4}},
+			stage:   protocol.StageRepeatedRequest, match: "repeated_request", outcome: "created", trial: 2,
+		},
+		{
+			name:    "joinder contained",
+			seeds:   []seed{{1, trial.StatusActive, NewLawsuit{"Dan", "Bank", 4, []int{5, 6}}}},
+			lawsuit: NewLawsuit{"Dan", "Bank", 4, []int{5}},
+			stage:   protocol.StageJoinder, match: "joinder_contained", outcome: "refused", trial: 1, onSeed: true,
+		},
+		{
+			name:    "joinder continent",
+			seeds:   []seed{{2, trial.StatusActive, NewLawsuit{"Eve", "Bank", 5, []int{7}}}},
+			lawsuit: NewLawsuit{"Eve", "Bank", 5, []int{7, 8}},
+			stage:   protocol.StageJoinder, match: "joinder_continent", outcome: "merged", trial: 2, onSeed: true,
+		},
+		{
+			name:    "connection",
+			seeds:   []seed{{2, trial.StatusActive, NewLawsuit{"Fay", "Shop", 6, []int{9}}}},
+			lawsuit: NewLawsuit{"Gus", "Bank", 6, []int{10}},
+			stage:   protocol.StageConnection, match: "connection", outcome: "created", trial: 2,
+		},
+		{
+			// the trial 1 has the smaller workload
+			name: "free",
+			seeds: []seed{
+				{2, trial.StatusActive, NewLawsuit{"Zed", "Shop", 90, []int{90}}},
+				{1, trial.StatusDisWithMerit, NewLawsuit{"Zoe", "Shop", 91, []int{91}}},
+			},
+			lawsuit: NewLawsuit{"Hal", "Bank", 7, []int{11}},
+			stage:   protocol.StageFree, match: protocol.StageFree, outcome: "created", trial: 1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			td := newTestDistrict(t, 2)
+			var seeded []trial.Lawsuit
+			for _, s := range tc.seeds {
+				seeded = append(seeded, seedLawsuit(t, td.trials[s.trial], s.status, s.q))
+			}
+
+			d, err := td.engine().Distribute(tc.lawsuit)
+			if err != nil {
+				t.Fatalf("Distribute: %v (decision %+v)", err, d)
+			}
+			if d.Stage != tc.stage || d.Match != tc.match || d.Outcome != tc.outcome {
+				t.Fatalf("decision %s/%s/%s, want %s/%s/%s (%s)", d.Stage, d.Match, d.Outcome, tc.stage, tc.match, tc.outcome, d.Reason)
+			}
+			tr, _ := td.tl.FindByID(tc.trial)
+			if d.TrialID != tc.trial || d.TrialAddr != tr.Address || d.DistrictName != "A" {
+				t.Errorf("decision in the trial %d (%s) of %q, want %d (%s) of A", d.TrialID, d.TrialAddr, d.DistrictName, tc.trial, tr.Address)
+			}
+			if d.TraceID == "" || len(d.Warnings) > 0 || d.Conflict {
+				t.Errorf("trace %q, warnings %v, conflict %v", d.TraceID, d.Warnings, d.Conflict)
+			}
+
+			ts := td.trials[tc.trial]
+			if tc.stage == protocol.StageFree {
+				if d.Evidence != nil || len(d.Candidates) > 0 || d.Workload != 0 {
+					t.Errorf("free distribution with evidence %+v, candidates %v, workload %d", d.Evidence, d.Candidates, d.Workload)
+				}
+			} else {
+				if d.Evidence == nil || d.Evidence.LawsuitID != seeded[0].ID || d.Evidence.Match != tc.match {
+					t.Errorf("evidence %+v, want the lawsuit %s", d.Evidence, seeded[0].ID)
+				}
+				if len(d.Candidates) != 1 || d.Candidates[0].LawsuitID != seeded[0].ID || !strings.HasPrefix(d.Reason, "only lawsuit found: "+seeded[0].ID) {
+					t.Errorf("candidates %v, reason %q", d.Candidates, d.Reason)
+				}
+			}
+			if tc.onSeed {
+				if d.LawsuitID != seeded[0].ID {
+					t.Fatalf("decision on %s, want %s", d.LawsuitID, seeded[0].ID)
+				}
+			} else if created := lookupLawsuit(t, ts, d.LawsuitID); created.Status != trial.StatusActive || created.Plaintiff != tc.lawsuit.Plaintiff {
+				t.Fatalf("lawsuit created %+v", created)
+			}
+
+			switch tc.outcome {
+			case "refused":
+				if n := len(ts.GetByStatus(trial.StatusActive)) + len(ts.GetByStatus(trial.StatusSuspended)); n > 1 {
+					t.Errorf("%d pending lawsuits in the trial after a refusal", n)
+				}
+			case "merged":
+				if got := lookupLawsuit(t, ts, seeded[0].ID); !sameIntSet(got.Claims, tc.lawsuit.Claims) {
+					t.Errorf("claims after the merge %v, want %v", got.Claims, tc.lawsuit.Claims)
+				}
+			}
+			if tc.stage == protocol.StageConnection {
+				if got := lookupLawsuit(t, ts, d.LawsuitID); !hasStr(got.Connected, seeded[0].ID) {
+					t.Errorf("%s connected to %v, want %s", got.ID, got.Connected, seeded[0].ID)
+				}
+			}
+		})
+	}
+}
+
+// A lawsuit that contains the filing and one contained in it, filed in both
+// orders: the claims are merged into the contained lawsuit, whatever the prevention
+func TestDistributeJoinderContinentFirst(t *testing.T) {
+	q := NewLawsuit{"Ivy", "Bank", 8, []int{7, 8}}
+	bigger := NewLawsuit{"Ivy", "Bank", 8, []int{7, 8, 9}}
+	smaller := NewLawsuit{"Ivy", "Bank", 8, []int{7}}
+	for _, tc := range []struct {
+		name         string
+		biggerTrial  int // the lawsuit of the trial 1 is filed first
+		smallerTrial int
+	}{
+		{"contained earlier", 1, 2},
+		{"continent earlier", 2, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			td := newTestDistrict(t, 2)
+			var big, small trial.Lawsuit
+			if tc.biggerTrial == 1 {
+				big = seedLawsuit(t, td.trials[1], trial.StatusActive, bigger)
+				small = seedLawsuit(t, td.trials[2], trial.StatusActive, smaller)
+			} else {
+				small = seedLawsuit(t, td.trials[1], trial.StatusActive, smaller)
+				big = seedLawsuit(t, td.trials[2], trial.StatusActive, bigger)
+			}
+
+			d, err := td.engine().Distribute(q)
+			if err != nil {
+				t.Fatalf("Distribute: %v (decision %+v)", err, d)
+			}
+			if d.Match != "joinder_continent" || d.Outcome != "merged" || d.LawsuitID != small.ID || d.TrialID != tc.smallerTrial {
+				t.Fatalf("decision %s/%s on %s in the trial %d, want joinder_continent/merged on %s in %d (%s)",
+					d.Match, d.Outcome, d.LawsuitID, d.TrialID, small.ID, tc.smallerTrial, d.Reason)
+			}
+			if len(d.Candidates) != 1 || d.Candidates[0].LawsuitID != small.ID {
+				t.Errorf("candidates %v, want only %s", d.Candidates, small.ID)
+			}
+			if got := lookupLawsuit(t, td.trials[tc.smallerTrial], small.ID); !sameIntSet(got.Claims, q.Claims) {
+				t.Errorf("claims after the merge %v, want %v", got.Claims, q.Claims)
+			}
+			if got := lookupLawsuit(t, td.trials[tc.biggerTrial], big.ID); !sameIntSet(got.Claims, bigger.Claims) {
+				t.Errorf("contained lawsuit %s changed to %v", big.ID, got.Claims)
+			}
+		})
+	}
+
+	// without a lawsuit to expand, the filing contained in two lawsuits is refused
+	// on the earliest one
+	t.Run("only contained", func(t *testing.T) {
+		td := newTestDistrict(t, 2)
+		first := seedLawsuit(t, td.trials[2], trial.StatusActive, bigger)
+		seedLawsuit(t, td.trials[1], trial.StatusActive, NewLawsuit{"Ivy", "Bank", 8, []int{7, 8, 10}})
+		d, err := td.engine().Distribute(q)
+		if err != nil {
+			t.Fatalf("Distribute: %v (decision %+v)", err, d)
+		}
+		if d.Match != "joinder_contained" || d.Outcome != "refused" || d.LawsuitID != first.ID || len(d.Candidates) != 2 {
+			t.Fatalf("decision %s/%s on %s, candidates %v, want joinder_contained/refused on %s", d.Match, d.Outcome, d.LawsuitID, d.Candidates, first.ID)
+		}
+	})
+}
+
+func sameIntSet(a, b []int) bool {
+	set := map[int]bool{}
+	for _, x := range a {
+		set[x] = true
+	}
+	for _, x := range b {
+		if !set[x] {
+			return false
+		}
+	}
+	return len(set) == len(b)
+}
+
+func hasStr(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Listener on the mem transport that never answers
+func silentAgent(t *testing.T, addr string) string {
+	t.Helper()
+	ln, err := transport.Mem{}.Listen(addr, func(transport.Replier, []byte) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	return "mem://" + addr
+}
+
+// A trial and a district that never answer leave the decision partial, not
+// late: the classification and the workloads end at their deadlines and the
+// decision lists what was not classified
+func TestDistributeSilentTrial(t *testing.T) {
+	td := newTestDistrict(t, 2)
+	silent, err := td.tl.Add(silentAgent(t, td.prefix+"-silent"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := silentAgent(t, td.prefix+"-B")
+	if err := td.dl.SetAll([]protocol.District{{ID: 1, Name: "A"}, {ID: 2, Name: "B", Address: other}}); err != nil {
+		t.Fatal(err)
+	}
+	seedLawsuit(t, td.trials[1], trial.StatusActive, NewLawsuit{"Zed", "Shop", 90, []int{90}})
+
+	e := td.engine()
+	e.timeout = 300 * time.Millisecond
+	start := time.Now()
+	d, err := e.Distribute(NewLawsuit{"Ann", "Bank", 1, []int{1}})
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatalf("Distribute: %v", err)
+	}
+	// one round of classification and one of workloads
+	if elapsed > 2*e.timeout+200*time.Millisecond {
+		t.Fatalf("decision after %v (timeout %v)", elapsed, e.timeout)
+	}
+	if d.Stage != protocol.StageFree || d.Outcome != "created" || d.TrialID != 2 {
+		t.Fatalf("decision %s/%s in the trial %d, want free/created in the trial 2", d.Stage, d.Outcome, d.TrialID)
+	}
+	want := []string{
+		fmt.Sprintf("trial %d (%s) was not classified", silent.ID, silent.Address),
+		fmt.Sprintf("district B (%s) was not classified", other),
+	}
+	if len(d.Warnings) != len(want) {
+		t.Fatalf("warnings %q, want %q", d.Warnings, want)
+	}
+	for i, w := range want {
+		if !strings.HasPrefix(d.Warnings[i], w) {
+			t.Fatalf("warnings %q, want %q", d.Warnings, want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package district
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"judiciary/internal/court"
+	"judiciary/internal/protocol"
+	"judiciary/internal/transport"
+)
+
+// Court on the mem transport with the lease table of the Court; the responses
+// to the requests in drop are lost (by request number, from 1)
+type testLeaseCourt struct {
+	mu       sync.Mutex
+	lt       *court.LeaseTable
+	requests []protocol.LeaseRequest
+	drop     map[int]bool
+}
+
+func startTestLeaseCourt(t *testing.T, addr string) *testLeaseCourt {
+	t.Helper()
+	c := &testLeaseCourt{lt: court.NewLeaseTable(), drop: map[int]bool{}}
+	ln, err := transport.Mem{}.Listen(addr, func(w transport.Replier, data []byte) {
+		var req protocol.LeaseRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			t.Errorf("lease request: %v", err)
+			return
+		}
+		c.mu.Lock()
+		c.requests = append(c.requests, req)
+		lost := c.drop[len(c.requests)]
+		c.mu.Unlock()
+
+		l, ok := c.lt.Acquire(req.Fingerprint, req.Holder, req.Token, time.Duration(req.TTLMs)*time.Millisecond)
+		if lost {
+			return
+		}
+		b, _ := json.Marshal(protocol.LeaseResponse{Envelope: req.Reply("court"), Success: ok, Lease: &l})
+		_ = w.Reply(b)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	return c
+}
+
+// A lost response to the acquire (or to the renewal) is retransmitted with the
+// same token: the district gets the lease the Court granted it
+func TestLeaseResponseLost(t *testing.T) {
+	c := startTestLeaseCourt(t, "court-lease")
+	c.drop[1] = true
+	c.drop[3] = true
+
+	fl, current, err := acquireFilingLease("mem://court-lease", "A", "fp", "trace")
+	if err != nil || current != nil {
+		t.Fatalf("acquire: current=%+v err=%v", current, err)
+	}
+	if len(c.requests) != 2 || c.requests[0].MsgID != c.requests[1].MsgID || c.requests[0].Token == "" ||
+		c.requests[0].Token != c.requests[1].Token || fl.lease.Token != c.requests[0].Token {
+		t.Fatalf("acquire retransmitted as %+v, lease %+v", c.requests, fl.lease)
+	}
+
+	if current, err := fl.renew(); err != nil || current != nil {
+		t.Fatalf("renew: current=%+v err=%v", current, err)
+	}
+	if len(c.requests) != 4 || c.requests[3].Token != fl.lease.Token {
+		t.Fatalf("renew retransmitted as %+v", c.requests[2:])
+	}
+
+	// another district still finds the lease held
+	if _, current, err := acquireFilingLease("mem://court-lease", "B", "fp", "trace"); err != nil || current == nil || current.Holder != "A" {
+		t.Fatalf("acquire by another district: current=%+v err=%v", current, err)
+	}
+}
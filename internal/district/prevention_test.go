@@ -0,0 +1,68 @@
+package district
+
+import (
+	"testing"
+	"time"
+
+	"judiciary/internal/protocol"
+)
+
+func TestPreventionLess(t *testing.T) {
+	t0 := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	t1 := t0.Add(time.Second)
+	for _, tc := range []struct {
+		name string
+		a, b protocol.TrialActionQueryResponse
+		less bool // a precedes b
+	}{
+		{"earlier filing", protocol.TrialActionQueryResponse{LawsuitID: "1.2.9", FiledAt: t0}, protocol.TrialActionQueryResponse{LawsuitID: "1.1.1", FiledAt: t1}, true},
+		{"later filing", protocol.TrialActionQueryResponse{LawsuitID: "1.1.1", FiledAt: t1}, protocol.TrialActionQueryResponse{LawsuitID: "1.2.9", FiledAt: t0}, false},
+		{"same instant in another zone", protocol.TrialActionQueryResponse{LawsuitID: "1.1.2", FiledAt: t0.In(time.FixedZone("BRT", -3*3600))}, protocol.TrialActionQueryResponse{LawsuitID: "1.1.10", FiledAt: t0}, true},
+		{"without date before dated", protocol.TrialActionQueryResponse{LawsuitID: "9.9.9"}, protocol.TrialActionQueryResponse{LawsuitID: "1.1.1", FiledAt: t0}, true},
+		{"dated after without date", protocol.TrialActionQueryResponse{LawsuitID: "1.1.1", FiledAt: t0}, protocol.TrialActionQueryResponse{LawsuitID: "9.9.9"}, false},
+		{"both without date: by ID", protocol.TrialActionQueryResponse{LawsuitID: "1.1.2"}, protocol.TrialActionQueryResponse{LawsuitID: "1.1.10"}, true},
+		{"tie: sequence compared as number", protocol.TrialActionQueryResponse{LawsuitID: "1.1.10", FiledAt: t0}, protocol.TrialActionQueryResponse{LawsuitID: "1.1.9", FiledAt: t0}, false},
+		{"tie: trial before sequence", protocol.TrialActionQueryResponse{LawsuitID: "1.2.1", FiledAt: t0}, protocol.TrialActionQueryResponse{LawsuitID: "1.10.1", FiledAt: t0}, true},
+		{"tie across districts", protocol.TrialActionQueryResponse{LawsuitID: "2.1.1", DistrictName: "A", FiledAt: t0}, protocol.TrialActionQueryResponse{LawsuitID: "10.1.1", DistrictName: "B", FiledAt: t0}, true},
+		{"tie across districts, reversed", protocol.TrialActionQueryResponse{LawsuitID: "10.1.1", DistrictName: "B", FiledAt: t0}, protocol.TrialActionQueryResponse{LawsuitID: "2.1.1", DistrictName: "A", FiledAt: t0}, false},
+		{"same lawsuit", protocol.TrialActionQueryResponse{LawsuitID: "1.1.1", FiledAt: t0}, protocol.TrialActionQueryResponse{LawsuitID: "1.1.1", FiledAt: t0}, false},
+		{"invalid ID: by text", protocol.TrialActionQueryResponse{LawsuitID: "x"}, protocol.TrialActionQueryResponse{LawsuitID: "y"}, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := preventionLess(&tc.a, &tc.b); got != tc.less {
+				t.Fatalf("preventionLess(%s, %s) = %v, want %v", tc.a.LawsuitID, tc.b.LawsuitID, got, tc.less)
+			}
+		})
+	}
+}
+
+// The candidates of several trials and districts, ranked by prevention
+func TestRankByPrevention(t *testing.T) {
+	t0 := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	matches := []protocol.TrialActionQueryResponse{
+		{LawsuitID: "2.1.5", DistrictName: "B", FiledAt: t0.Add(time.Hour)},
+		{LawsuitID: "1.2.3", DistrictName: "A", FiledAt: t0},
+		{LawsuitID: "3.1.1", DistrictName: "C"},
+		{LawsuitID: "1.1.7", DistrictName: "A", FiledAt: t0},
+		{LawsuitID: "2.1.2", DistrictName: "B"},
+		{LawsuitID: "1.1.8", DistrictName: "A", FiledAt: t0.Add(time.Hour)},
+	}
+	rankByPrevention(matches)
+	want := []string{"2.1.2", "3.1.1", "1.1.7", "1.2.3", "1.1.8", "2.1.5"}
+	for i, m := range matches {
+		if m.LawsuitID != want[i] {
+			t.Fatalf("ranked %v, want %v", idsOf(matches), want)
+		}
+	}
+	if r := preventionReason(matches); r != "prevention: lawsuit 2.1.2 filing date unknown (registered before the dates were kept), the earliest among 6 lawsuits found" {
+		t.Fatalf("reason %q", r)
+	}
+}
+
+func idsOf(matches []protocol.TrialActionQueryResponse) []string {
+	ids := make([]string, len(matches))
+	for i, m := range matches {
+		ids[i] = m.LawsuitID
+	}
+	return ids
+}
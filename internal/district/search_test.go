@@ -0,0 +1,56 @@
+package district
+
+import (
+	"testing"
+	"time"
+
+	"judiciary/internal/protocol"
+	"judiciary/internal/trial"
+)
+
+// Pages of 2 over 4 trials; the second page fills at the end of the trial 2:
+// the next page is the trial with the next result, if any, never an empty one
+func TestSearchLocalTrialsPages(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		seeds []int // lawsuits of the trials 1..4 found by the search
+		pages []int // results of each page
+	}{
+		{"last trial with results", []int{2, 2, 0, 0}, []int{2, 2}},
+		{"results after empty trials", []int{2, 2, 0, 1}, []int{2, 2, 1}},
+		{"cut in the middle", []int{1, 2, 0, 0}, []int{2, 1}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			td := newTestDistrict(t, 4)
+			total := 0
+			for i, n := range tc.seeds {
+				for k := 0; k < n; k++ {
+					seedLawsuit(t, td.trials[i+1], trial.StatusActive, NewLawsuit{"Ann", "Bank", 10*i + k + 1, []int{1}})
+				}
+				total += n
+			}
+
+			req := protocol.TrialSearchLawsuitsRequest{Field: "defendant", Value: "bank", Limit: 2}
+			var got []int
+			for page := 0; page < 5; page++ {
+				resp := searchLocalTrials(td.tl, req, time.Second)
+				if !resp.Success || resp.Total != total {
+					t.Fatalf("page %d: %+v", page+1, resp)
+				}
+				got = append(got, len(resp.Results))
+				if resp.NextCursor == "" {
+					break
+				}
+				req.Cursor = resp.NextCursor
+			}
+			if len(got) != len(tc.pages) {
+				t.Fatalf("pages %v, want %v", got, tc.pages)
+			}
+			for i := range got {
+				if got[i] != tc.pages[i] {
+					t.Fatalf("pages %v, want %v", got, tc.pages)
+				}
+			}
+		})
+	}
+}
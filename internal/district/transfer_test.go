@@ -0,0 +1,77 @@
+package district
+
+import (
+	"testing"
+	"time"
+
+	"judiciary/internal/protocol"
+	"judiciary/internal/trial"
+)
+
+// A pending lawsuit transferred (recusal) to another trial: new ID in the
+// target, transferred in the source and one compensation for the target
+func TestTransferLawsuit(t *testing.T) {
+	td := newTestDistrict(t, 3)
+	a := seedLawsuit(t, td.trials[1], trial.StatusSuspended, NewLawsuit{"Ann", "Bank", 1, []int{1}})
+
+	res, err := transferLawsuit(td.dl, td.tl, td.cl, a.ID, 3, protocol.TransferRecusal, time.Second)
+	if err != nil {
+		t.Fatalf("transferLawsuit: %v", err)
+	}
+	if res.FromTrial != 1 || res.ToTrial != 3 || res.FormerID != a.ID || res.Reason != protocol.TransferRecusal {
+		t.Fatalf("result %+v", res)
+	}
+	moved := lookupLawsuit(t, td.trials[3], res.LawsuitID)
+	if moved.Status != trial.StatusSuspended || moved.Plaintiff != "Ann" || !moved.FiledAt.Equal(a.FiledAt) {
+		t.Errorf("lawsuit in the target %+v", moved)
+	}
+	if old := lookupLawsuit(t, td.trials[1], a.ID); old.Status != trial.StatusTransferred || old.TransferredTo != res.LawsuitID {
+		t.Errorf("lawsuit in the source %s -> %s", old.Status, old.TransferredTo)
+	}
+	if got := td.cl.ByTrial(); len(got) != 1 || len(got[3]) != 1 || got[3][0] != res.LawsuitID {
+		t.Errorf("compensations %v", got)
+	}
+
+	// once transferred it is no longer in the source trial
+	if _, err := transferLawsuit(td.dl, td.tl, td.cl, a.ID, 2, protocol.TransferRecusal, time.Second); err == nil {
+		t.Fatal("lawsuit transferred twice")
+	}
+	// nor goes to its own trial or to a trial being drained
+	b := seedLawsuit(t, td.trials[1], trial.StatusActive, NewLawsuit{"Ben", "Bank", 2, []int{2}})
+	if _, err := transferLawsuit(td.dl, td.tl, td.cl, b.ID, 1, protocol.TransferRecusal, time.Second); err == nil {
+		t.Fatal("lawsuit transferred to its own trial")
+	}
+	if err := td.tl.SetDraining(2, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := transferLawsuit(td.dl, td.tl, td.cl, b.ID, 2, protocol.TransferRecusal, time.Second); err == nil {
+		t.Fatal("lawsuit transferred to a trial being drained")
+	}
+	if len(td.cl.GetAll()) != 1 {
+		t.Errorf("compensations %+v", td.cl.GetAll())
+	}
+}
+
+// The move repeated after a fault (same exported lawsuit) gives the same new ID
+func TestMoveLawsuitRepeated(t *testing.T) {
+	td := newTestDistrict(t, 2)
+	a := seedLawsuit(t, td.trials[1], trial.StatusActive, NewLawsuit{"Ann", "Bank", 1, []int{1}})
+	from, _ := td.tl.FindByID(1)
+	to, _ := td.tl.FindByID(2)
+
+	exported, err := exportLawsuitFromTrial(from.Address, a.ID, protocol.TransferDeclination, "trace", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := moveLawsuit(from, to, *exported, protocol.TransferDeclination, "trace", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := moveLawsuit(from, to, *exported, protocol.TransferDeclination, "trace", time.Second)
+	if err != nil || again != first {
+		t.Fatalf("repeated move: %s, %v; first %s", again, err, first)
+	}
+	if n := len(td.trials[2].GetByStatus(trial.StatusActive)); n != 1 {
+		t.Errorf("%d active lawsuits in the target", n)
+	}
+}
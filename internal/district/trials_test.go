@@ -0,0 +1,85 @@
+package district
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"judiciary/internal/protocol"
+	"judiciary/internal/storage"
+)
+
+func newTestTrialList(t *testing.T, dir string) *TrialList {
+	t.Helper()
+	tl := NewTrialList(storage.NewJSONFiles(map[string]string{
+		keyTrials:   filepath.Join(dir, "trials.json"),
+		keyTrialIDs: filepath.Join(dir, "trial_ids.json"),
+	}))
+	if err := tl.Load(); err != nil {
+		t.Fatal(err)
+	}
+	return tl
+}
+
+// The ID of a removed trial is not given again, also after a restart
+func TestTrialIDsNotReused(t *testing.T) {
+	dir := t.TempDir()
+	tl := newTestTrialList(t, dir)
+	for i := 1; i <= 3; i++ {
+		if tr, err := tl.Add("udp://t"); err != nil || tr.ID != i {
+			t.Fatalf("Add: %+v, %v", tr, err)
+		}
+	}
+	if _, err := tl.RemoveByID(3); err != nil {
+		t.Fatal(err)
+	}
+	if tr, _ := tl.Add("udp://t4"); tr.ID != 4 {
+		t.Fatalf("ID after removing the greatest: %d, want 4", tr.ID)
+	}
+
+	tl = newTestTrialList(t, dir)
+	if r, ok := tl.Retired(3); !ok || r.RemovedAt.IsZero() {
+		t.Fatalf("retired trial 3 after the restart: %+v, %v", r, ok)
+	}
+	if _, ok := tl.Retired(2); ok {
+		t.Fatal("trial 2 retired")
+	}
+	if tr, _ := tl.Add("udp://t5"); tr.ID != 5 {
+		t.Fatalf("ID after the restart: %d, want 5", tr.ID)
+	}
+}
+
+type testReplier struct{ b []byte }
+
+func (r *testReplier) Reply(b []byte) error { r.b = b; return nil }
+func (r *testReplier) Remote() string       { return "test" }
+
+// A trial that asks for a retired ID is refused and told so
+func TestTrialInfoRetired(t *testing.T) {
+	tl := newTestTrialList(t, t.TempDir())
+	tl.Add("udp://t1")
+	tl.Add("udp://t2")
+	if _, err := tl.RemoveByID(2); err != nil {
+		t.Fatal(err)
+	}
+	info := func(id int) protocol.DistrictInfoResponse {
+		req, _ := json.Marshal(protocol.DistrictInfoRequest{Envelope: protocol.NewEnvelope("trial", "trace"), Type: "trial_info", TrialID: id})
+		var w testReplier
+		handleTrialInfo(&w, req, "A", NewDistrictList(nil), tl)
+		var resp protocol.DistrictInfoResponse
+		if err := json.Unmarshal(w.b, &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	if resp := info(2); resp.Success || !resp.Retired {
+		t.Fatalf("trial_info of the retired ID: %+v", resp)
+	}
+	if resp := info(3); resp.Success || resp.Retired {
+		t.Fatalf("trial_info of an ID never given: %+v", resp)
+	}
+	if resp := info(1); !resp.Success || resp.TrialID != 1 || resp.DistrictName != "A" {
+		t.Fatalf("trial_info of the trial 1: %+v", resp)
+	}
+}
@@ -0,0 +1,96 @@
+package persist
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Append-only file of JSON records, one per line (write-ahead journal of a
+// state saved from time to time with SaveJSON). Each Append is written and
+// fsynced before returning.
+type Journal struct {
+	path string
+	f    *os.File
+}
+
+func OpenJournal(path string) (*Journal, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &Journal{path: path, f: f}, nil
+}
+
+func (j *Journal) Path() string { return j.path }
+
+// Write the record as one line and fsync it. If it fails, the journal is cut
+// back to its size before the record (a part of it would join the next one).
+func (j *Journal) Append(v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	size, err := j.f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	if _, err = j.f.Write(append(b, '\n')); err == nil {
+		err = j.f.Sync()
+	}
+	if err != nil {
+		if terr := j.f.Truncate(size); terr != nil {
+			return fmt.Errorf("%w (and the journal was not cut back: %v)", err, terr)
+		}
+	}
+	return err
+}
+
+// Calls fn for each record, in the order they were appended; returns the number
+// of records. A last line without its end (write interrupted by a fault) is
+// cut from the file; a record that fn rejects before it is an error.
+func (j *Journal) Replay(fn func(record []byte) error) (int, error) {
+	if _, err := j.f.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	r := bufio.NewReader(j.f)
+	var n int
+	var good int64
+	for {
+		line, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) > 0 {
+				// torn last record: never acknowledged, drop it
+				if err := j.f.Truncate(good); err != nil {
+					return n, err
+				}
+				return n, j.f.Sync()
+			}
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+		if rec := bytes.TrimSpace(line); len(rec) > 0 {
+			if err := fn(rec); err != nil {
+				return n, fmt.Errorf("%s: record %d: %w", j.path, n+1, err)
+			}
+			n++
+		}
+		good += int64(len(line))
+	}
+}
+
+// Empty the journal (after its records were saved in a snapshot)
+func (j *Journal) Reset() error {
+	if err := j.f.Truncate(0); err != nil {
+		return err
+	}
+	return j.f.Sync()
+}
+
+func (j *Journal) Close() error { return j.f.Close() }
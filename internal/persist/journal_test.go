@@ -0,0 +1,146 @@
+package persist
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type testRecord struct {
+	Seq int `json:"seq"`
+}
+
+func openTestJournal(t *testing.T, path string) *Journal {
+	t.Helper()
+	j, err := OpenJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { j.Close() })
+	return j
+}
+
+func replaySeqs(t *testing.T, j *Journal) []int {
+	t.Helper()
+	var seqs []int
+	n, err := j.Replay(func(record []byte) error {
+		var r testRecord
+		if err := json.Unmarshal(record, &r); err != nil {
+			return err
+		}
+		seqs = append(seqs, r.Seq)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(seqs) {
+		t.Fatalf("Replay returned %d, %d records read", n, len(seqs))
+	}
+	return seqs
+}
+
+func sameSeqs(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestJournalReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.journal")
+	j := openTestJournal(t, path)
+	for seq := 1; seq <= 3; seq++ {
+		if err := j.Append(testRecord{Seq: seq}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	j.Close()
+
+	if got := replaySeqs(t, openTestJournal(t, path)); !sameSeqs(got, []int{1, 2, 3}) {
+		t.Fatalf("replayed %v, want [1 2 3]", got)
+	}
+}
+
+// A record cut in the middle (fault during the write) is dropped on the next
+// open and the records appended after it are read normally
+func TestJournalTornTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.journal")
+	j := openTestJournal(t, path)
+	for seq := 1; seq <= 2; seq++ {
+		if err := j.Append(testRecord{Seq: seq}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	j.Close()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	good := info.Size()
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"seq":3,"da`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	j = openTestJournal(t, path)
+	if got := replaySeqs(t, j); !sameSeqs(got, []int{1, 2}) {
+		t.Fatalf("replayed %v, want [1 2]", got)
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() != good {
+		t.Fatalf("journal not cut back to %d bytes: %v %v", good, info.Size(), err)
+	}
+
+	if err := j.Append(testRecord{Seq: 3}); err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+	if got := replaySeqs(t, openTestJournal(t, path)); !sameSeqs(got, []int{1, 2, 3}) {
+		t.Fatalf("replayed %v after the new append, want [1 2 3]", got)
+	}
+}
+
+func TestJournalRejectedRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.journal")
+	if err := os.WriteFile(path, []byte("{\"seq\":1}\nnot json\n{\"seq\":3}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	j := openTestJournal(t, path)
+	n, err := j.Replay(func(record []byte) error {
+		var r testRecord
+		return json.Unmarshal(record, &r)
+	})
+	var syntax *json.SyntaxError
+	if n != 1 || !errors.As(err, &syntax) {
+		t.Fatalf("Replay = %d, %v; want 1 and the decoding error of record 2", n, err)
+	}
+}
+
+func TestJournalReset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.journal")
+	j := openTestJournal(t, path)
+	if err := j.Append(testRecord{Seq: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Append(testRecord{Seq: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if got := replaySeqs(t, j); !sameSeqs(got, []int{2}) {
+		t.Fatalf("replayed %v, want [2]", got)
+	}
+}
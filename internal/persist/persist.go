@@ -0,0 +1,85 @@
+// Package persist keeps the agents' state in local files (JSON lists/states,
+// their append-only journals and one-line text files with names and addresses).
+package persist
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Decode the JSON file into v. A missing file is not an error:
+// found is false and v is unchanged.
+func LoadJSON(path string, v any) (found bool, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer f.Close()
+
+	return true, json.NewDecoder(f).Decode(v)
+}
+
+// Write v as indented JSON in a temporary file (fsynced) renamed over path, so
+// a fault in the middle of the write never leaves a truncated file
+func SaveJSON(path string, v any) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	SyncDir(path)
+	return nil
+}
+
+// fsync of the directory of path, so a rename in it survives a fault (not
+// supported everywhere: errors are ignored)
+func SyncDir(path string) {
+	d, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	d.Close()
+}
+
+// Content (trimmed) of a one-line text file; "" if the file does not exist
+func LoadLine(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// Save one line (empty values are not saved)
+func SaveLine(path, line string) error {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil
+	}
+	return os.WriteFile(path, []byte(line+"\n"), 0644)
+}
@@ -0,0 +1,57 @@
+package protocol
+
+import "time"
+
+// ---------- COURT <-> DISTRICT ----------
+
+type District struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	Trials  int    `json:"trials"`
+}
+
+type Request struct {
+	Envelope
+
+	Type        string `json:"type"`             // "list", "create", "remove", "update_trials"
+	Name        string `json:"name,omitempty"`   // used in create/remove/update_trials
+	Trials      int    `json:"trials,omitempty"` // create / update_trials
+	TrialsDelta int    `json:"trials_delta,omitempty"`
+}
+
+type Response struct {
+	Envelope
+
+	Success   bool       `json:"success"`
+	Message   string     `json:"message"`
+	District  *District  `json:"district,omitempty"`
+	Districts []District `json:"districts,omitempty"`
+}
+
+// Lease of a lawsuit fingerprint held in the Court while the lawsuit is filed
+type Lease struct {
+	Fingerprint string    `json:"fingerprint"`
+	Holder      string    `json:"holder"`
+	Token       string    `json:"token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+type LeaseRequest struct {
+	Envelope
+
+	Type        string `json:"type"` // "lease_acquire" (also renews with the token), "lease_release"
+	Fingerprint string `json:"fingerprint"`
+	Holder      string `json:"holder,omitempty"`
+	Token       string `json:"token,omitempty"` // created by the district (a lost response is retransmitted)
+	TTLMs       int64  `json:"ttl_ms,omitempty"`
+}
+
+// If the lease is held by another district, Success=false and Lease is the current one
+type LeaseResponse struct {
+	Envelope
+
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Lease   *Lease `json:"lease,omitempty"`
+}
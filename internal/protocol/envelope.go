@@ -0,0 +1,71 @@
+// Package protocol defines the messages exchanged by the court, the districts
+// and the trials. Every message is one JSON object (a datagram in UDP),
+// identified by its "type" field and carrying an Envelope.
+package protocol
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"judiciary/internal/transport"
+)
+
+// Version of the UDP protocol with envelope (0: agents without envelope)
+const ProtocolVersion = 1
+
+// Common header of every UDP message (requests and responses).
+// MsgID identifies the message, ReplyTo the request answered and TraceID
+// is shared by every message (and log line) of the same filing.
+type Envelope struct {
+	MsgID   string `json:"msg_id,omitempty"`
+	ReplyTo string `json:"reply_to,omitempty"`
+	Sender  string `json:"sender,omitempty"`
+	Version int    `json:"version,omitempty"`
+	TraceID string `json:"trace_id,omitempty"`
+}
+
+func NewMsgID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// Envelope of a new request sent by sender; without trace, a new trace is started
+func NewEnvelope(sender, trace string) Envelope {
+	if trace == "" {
+		trace = NewMsgID()
+	}
+	return Envelope{MsgID: NewMsgID(), Sender: sender, Version: ProtocolVersion, TraceID: trace}
+}
+
+// Envelope of the response (sent by sender) to the request env (same trace)
+func (env Envelope) Reply(sender string) Envelope {
+	return Envelope{MsgID: NewMsgID(), ReplyTo: env.MsgID, Sender: sender, Version: ProtocolVersion, TraceID: env.TraceID}
+}
+
+// Reports if the message is the response to the request msgID.
+// Messages of agents without envelope (version 0) are accepted.
+func (env Envelope) Answers(msgID string) bool {
+	return env.Version == 0 || env.ReplyTo == msgID
+}
+
+// Send the request data (whose envelope is msgID) to addr and wait for its response
+// until the deadline; late responses of previous requests are discarded
+func Exchange(t transport.Transport, addr string, data []byte, msgID string, deadline time.Time) ([]byte, error) {
+	return transport.Exchange(t, addr, data, deadline, func(b []byte) bool {
+		var env Envelope
+		if err := json.Unmarshal(b, &env); err == nil && !env.Answers(msgID) {
+			log.Printf("[%s] %s - discarded response reply_to=%s from %s (expected %s)",
+				strings.ToUpper(t.Name()), time.Now().Format(time.RFC3339), env.ReplyTo, env.Sender, msgID)
+			return false
+		}
+		return true
+	})
+}
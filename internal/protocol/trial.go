@@ -0,0 +1,502 @@
+package protocol
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Stages of the distribution procedure ("free" is always the last one)
+const (
+	StageResJudicata     = "res_judicata"
+	StageLisPendens      = "lis_pendens"
+	StageRepeatedRequest = "repeated_request"
+	StageJoinder         = "joinder"
+	StageConnection      = "connection"
+	StageFree            = "free"
+)
+
+
+// ---------- Handshake TRIAL -> DISTRICT ----------
+
+type DistrictInfoRequest struct {
+	Envelope
+
+	Type    string `json:"type"`     // "trial_info"
+	TrialID int    `json:"trial_id"` // trial id (1, 2, 3, etc.)
+}
+
+type DistrictInfoResponse struct {
+	Envelope
+
+	Success      bool   `json:"success"`
+	Message      string `json:"message"`
+	DistrictID   int    `json:"district_id,omitempty"`
+	DistrictName string `json:"district_name,omitempty"`
+	TrialID      int    `json:"trial_id,omitempty"`
+	TrialAddr    string `json:"trial_addr,omitempty"`
+
+	// The trial ID was removed from the district and is not given again
+	Retired bool `json:"retired,omitempty"`
+}
+
+
+// ---------- Lawsuits verification / distribution (DISTRICT -> TRIAL) ----------
+
+// Description for the lawsuit that will be verified/created
+type ActionQuery struct {
+	Plaintiff string `json:"plaintiff"`
+	Defendant string `json:"defendant"`
+	CauseID   int    `json:"cause_id"`
+	Claims    []int  `json:"claims"`
+}
+
+// Request from a district to a trial (or to another district, as aggregator
+// of its trials) to look for a lawsuit inside its lists, for one stage
+type TrialActionQueryRequest struct {
+	Envelope
+
+	Type    string      `json:"type"`  // "lawsuit_query"
+	Stage   string      `json:"stage"` // "res_judicata", "lis_pendens", "repeated_request", "joinder", "connection"
+	Lawsuit ActionQuery `json:"lawsuit"`
+}
+
+// Response about a lawsuit found (or not) for one stage
+type TrialActionQueryResponse struct {
+	Envelope
+
+	Success bool   `json:"success"`
+	Stage   string `json:"stage"`
+	Match   string `json:"match"` // "" or "none", "res_judicata", "lis_pendens", "repeated_request", "joinder_contained", "joinder_continent", "connection"
+	Message string `json:"message"`
+
+	LawsuitID string    `json:"lawsuit_id,omitempty"`
+	FiledAt   time.Time `json:"filed_at,omitzero"` // filing date of the lawsuit found (prevention)
+
+	DistrictID   int    `json:"district_id,omitempty"`
+	DistrictName string `json:"district_name,omitempty"`
+	TrialID      int    `json:"trial_id,omitempty"`
+	TrialAddr    string `json:"trial_addr,omitempty"`
+
+	ExistentClaims    []int    `json:"existent_claims,omitempty"`
+	ConnectedLawsuits []string `json:"connected_lawsuits,omitempty"`
+
+	// What relates the lawsuit found with the new one (joinder and connection)
+	OverlapClaims []int `json:"overlap_claims,omitempty"`
+	SharedCause   bool  `json:"shared_cause,omitempty"`
+}
+
+// Request from a district to a trial (or to another district, as aggregator
+// of its trials) to evaluate several stages in one round
+type TrialClassifyRequest struct {
+	Envelope
+
+	Type    string      `json:"type"`   // "lawsuit_classify"
+	Stages  []string    `json:"stages"` // stages to evaluate (empty: all the stages known by the trial)
+	Lawsuit ActionQuery `json:"lawsuit"`
+}
+
+// Every lawsuit found for one stage (each match carries its district/trial)
+type StageMatches struct {
+	Stage   string                     `json:"stage"`
+	Matches []TrialActionQueryResponse `json:"matches,omitempty"`
+}
+
+type TrialClassifyResponse struct {
+	Envelope
+
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+
+	DistrictID   int    `json:"district_id,omitempty"`
+	DistrictName string `json:"district_name,omitempty"`
+	TrialID      int    `json:"trial_id,omitempty"`
+	TrialAddr    string `json:"trial_addr,omitempty"`
+
+	Stages []StageMatches `json:"stages"`
+
+	// Trials (of an aggregator district) that failed, timed out or refused:
+	// their matches are missing
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Matches of one stage in an (aggregated) response
+func (r *TrialClassifyResponse) MatchesOf(stage string) []TrialActionQueryResponse {
+	if r == nil {
+		return nil
+	}
+	for _, sm := range r.Stages {
+		if sm.Stage == stage {
+			return sm.Matches
+		}
+	}
+	return nil
+}
+
+// Request to create the lawsuit in the trial
+// Reason: "free", "repeated_request", "connection"
+// With "lawsuit_create_checked" the trial creates only if, under its lock, there is
+// still no identical lawsuit nor joinder (otherwise answers with the conflict).
+type TrialCreateActionRequest struct {
+	Envelope
+
+	Type    string      `json:"type"` // "lawsuit_create" or "lawsuit_create_checked"
+	Reason  string      `json:"reason"`
+	Lawsuit ActionQuery `json:"lawsuit"`
+	Related string      `json:"related,omitempty"` // ID for the related lawsuit (repeated request, connection, etc.)
+
+	RequestID string `json:"request_id,omitempty"` // retransmissions have the same ID
+
+	// For the lawsuit's history: who filed and in which district
+	Actor    string `json:"actor,omitempty"`
+	District string `json:"district,omitempty"`
+}
+
+type TrialCreateActionResponse struct {
+	Envelope
+
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+
+	LawsuitID    string `json:"lawsuit_id,omitempty"`
+	DistrictID   int    `json:"district_id,omitempty"`
+	DistrictName string `json:"district_name,omitempty"`
+	TrialID      int    `json:"trial_id,omitempty"`
+	TrialAddr    string `json:"trial_addr,omitempty"`
+
+	// lawsuit_create_checked: lawsuit that prevented the creation
+	Conflict          bool   `json:"conflict,omitempty"`
+	ConflictMatch     string `json:"conflict_match,omitempty"`
+	ConflictLawsuitID string `json:"conflict_lawsuit_id,omitempty"`
+}
+
+// Request to merge claims in a lawsuit already existent (containment: joinder)
+type TrialMergeClaimsRequest struct {
+	Envelope
+
+	Type      string `json:"type"` // "lawsuit_merge_claims"
+	LawsuitID string `json:"lawsuit_id"`
+	NewClaims []int  `json:"new_claims"`
+
+	RequestID string `json:"request_id,omitempty"` // retransmissions have the same ID
+
+	// For the lawsuit's history: who filed and in which district
+	Actor    string `json:"actor,omitempty"`
+	District string `json:"district,omitempty"`
+}
+
+type TrialMergeClaimsResponse struct {
+	Envelope
+
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+
+// ---------- History of a lawsuit ----------
+
+// Who made a change of a lawsuit and why
+type Audit struct {
+	Actor    string `json:"actor,omitempty"`    // operator (user of the district's or trial's menu)
+	District string `json:"district,omitempty"` // district where the filing (or the dismissal) was made
+	Reason   string `json:"reason,omitempty"`   // rule applied: "free", "repeated_request", "connection", "joinder"
+	Related  string `json:"related,omitempty"`  // lawsuit the rule related the filing to
+	Filing   string `json:"filing,omitempty"`   // trace ID of the filing that caused the change
+}
+
+// One change of a lawsuit, with the time it was committed in the trial. Event:
+// "created", "claims_merged" (Claims: the claims added), "connected" (Other:
+// the lawsuit connected), "status_changed" (From -> Status: "active",
+// "suspended", "archived_provisionally", "on_appeal", "dismissed_with_merit",
+// "dismissed_without_merit"), "transferred_out" / "transferred_in" (Other:
+// the ID of the lawsuit in the other trial), "connection_moved" (the
+// connected lawsuit From was transferred and is now Other) or, in histories of
+// previous versions, "dismissed" (List: "with_merit" / "without_merit").
+type HistoryEntry struct {
+	At     time.Time `json:"at"`
+	Event  string    `json:"event"`
+	Claims []int     `json:"claims,omitempty"`
+	Other  string    `json:"other,omitempty"`
+	List   string    `json:"list,omitempty"`
+	From   string    `json:"from,omitempty"`
+	Status string    `json:"status,omitempty"`
+	Audit
+}
+
+// One line for the menus, e.g. "2026-03-01 10:22:03 claims merged [3] (joinder
+// of 1.1.2) by maria, district Campinas, filing 9f2c..."
+func (h HistoryEntry) String() string {
+	var b strings.Builder
+	b.WriteString(h.At.Local().Format("2006-01-02 15:04:05") + " ")
+	switch h.Event {
+	case "claims_merged":
+		fmt.Fprintf(&b, "claims merged %v", h.Claims)
+	case "connected":
+		fmt.Fprintf(&b, "connected to %s", h.Other)
+	case "dismissed":
+		b.WriteString("dismissed " + strings.ReplaceAll(h.List, "_", " "))
+	case "status_changed":
+		fmt.Fprintf(&b, "%s -> %s", strings.ReplaceAll(h.From, "_", " "), strings.ReplaceAll(h.Status, "_", " "))
+	case "transferred_out":
+		fmt.Fprintf(&b, "transferred to %s", h.Other)
+	case "transferred_in":
+		fmt.Fprintf(&b, "transferred from %s", h.Other)
+	case "connection_moved":
+		fmt.Fprintf(&b, "connected lawsuit %s transferred, now %s", h.From, h.Other)
+	default:
+		b.WriteString(h.Event)
+	}
+	if h.Reason != "" {
+		b.WriteString(" (" + h.Reason)
+		if h.Related != "" && h.Event != "connected" {
+			b.WriteString(" of " + h.Related)
+		}
+		b.WriteString(")")
+	}
+	if h.Actor != "" {
+		b.WriteString(" by " + h.Actor)
+	}
+	if h.District != "" {
+		b.WriteString(", district " + h.District)
+	}
+	if h.Filing != "" {
+		b.WriteString(", filing " + h.Filing)
+	}
+	return b.String()
+}
+
+
+// ---------- Lawsuits search (DISTRICT -> TRIAL) ----------
+
+// Generic search request (field + value) sent by district to each trial.
+// With Limit > 0 the results come in pages: the next page is asked with the
+// NextCursor of the previous response (and the same field, value and sort).
+type TrialSearchLawsuitsRequest struct {
+	Envelope
+
+	Type  string `json:"type"`  // "search_lawsuit"
+	Field string `json:"field"` // "id", "plaintiff", "defendant", "cause", "claim"
+	Value string `json:"value"`
+	Query string `json:"query,omitempty"` // compound query (package query); when present, Field and Value are ignored
+
+	Sort   string `json:"sort,omitempty"`   // "id" (default), "list", "plaintiff"
+	Limit  int    `json:"limit,omitempty"`  // results per page (0: all)
+	Cursor string `json:"cursor,omitempty"` // opaque; "" for the first page
+}
+
+// Sort orders accepted in the search
+const (
+	SortByID        = "id"
+	SortByList      = "list"
+	SortByPlaintiff = "plaintiff"
+)
+
+// Individual result returned by the trial for each lawsuit found
+type TrialSearchResult struct {
+	List        string `json:"list"`         // "Active", "Suspended", "Archived provisionally", "On appeal", "Dismissed with merit", "Dismissed without merit", "Transferred"
+	ID          string `json:"id"`           // Lawsuit ID (ex: "1.1.3")
+	Plaintiff   string `json:"plaintiff"`    // Plaintiff's name
+	Defendant   string `json:"defendant"`    // Defendant's name
+	CauseAction int    `json:"cause_action"` // Cause of action code
+	Claims      []int  `json:"claims"`       // Claims' list
+
+	History []HistoryEntry `json:"history,omitempty"` // changes of the lawsuit, in order
+
+	// Where the lawsuit is (results merged from several trials / districts)
+	DistrictID   int    `json:"district_id,omitempty"`
+	DistrictName string `json:"district_name,omitempty"`
+	TrialID      int    `json:"trial_id,omitempty"`
+	TrialAddr    string `json:"trial_addr,omitempty"`
+}
+
+// Trial's response with the lawsuits that meet the criteria. A district answers
+// the same request for all its trials (results trial by trial, each one sorted).
+type TrialSearchLawsuitsResponse struct {
+	Envelope
+
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+
+	DistrictID   int    `json:"district_id,omitempty"`
+	DistrictName string `json:"district_name,omitempty"`
+	TrialID      int    `json:"trial_id,omitempty"`
+	TrialAddr    string `json:"trial_addr,omitempty"`
+
+	Results []TrialSearchResult `json:"results,omitempty"`
+
+	Total      int    `json:"total"`                 // lawsuits found (all the pages)
+	NextCursor string `json:"next_cursor,omitempty"` // "" in the last page
+}
+
+
+// ---------- Docket at a past moment (-> TRIAL) ----------
+
+// Lists of the trial as they were at At (rebuilt from the history of each lawsuit)
+type TrialDocketAsOfRequest struct {
+	Envelope
+
+	Type string    `json:"type"` // "docket_as_of"
+	At   time.Time `json:"at"`
+}
+
+type TrialDocketAsOfResponse struct {
+	Envelope
+
+	Success bool      `json:"success"`
+	Message string    `json:"message"`
+	At      time.Time `json:"at"`
+
+	DistrictID   int    `json:"district_id,omitempty"`
+	DistrictName string `json:"district_name,omitempty"`
+	TrialID      int    `json:"trial_id,omitempty"`
+	TrialAddr    string `json:"trial_addr,omitempty"`
+
+	Actives               []TrialSearchResult `json:"actives"`
+	DismissedWithMerit    []TrialSearchResult `json:"dismissed_with_merit"`
+	DismissedWithoutMerit []TrialSearchResult `json:"dismissed_without_merit"`
+	Others                []TrialSearchResult `json:"others,omitempty"` // suspended, archived provisionally or on appeal (see List)
+
+	// Lawsuits left out because their filing or dismissal date is unknown
+	// (registered before the dates were kept)
+	Undated int `json:"undated,omitempty"`
+}
+
+
+// ---------- Transfer of a lawsuit to another trial (DISTRICT -> TRIAL) ----------
+
+// Redistribution of one lawsuit (recusal of the judge, declination of
+// competence), run by the district in three messages: "lawsuit_export" to the
+// source trial (the lawsuit with its history, unchanged), "lawsuit_import" to
+// the target trial (the lawsuit gets a new ID there, linked to the old one) and
+// "lawsuit_transferred" to the source (the lawsuit stays there as transferred,
+// linked to the new ID). Both trials rewrite the connections of their lawsuits
+// with the old ID.
+
+// Reasons of a transfer. With "decommission" (the trial is drained before being
+// removed from the district) the dismissed lawsuits are transferred too.
+const (
+	TransferRecusal      = "recusal"
+	TransferDeclination  = "declination"
+	TransferDecommission = "decommission"
+)
+
+// Lawsuit as the trial keeps it (only pending lawsuits are transferred, but for
+// "decommission")
+type TransferLawsuit struct {
+	ID          string         `json:"id"`
+	Plaintiff   string         `json:"plaintiff"`
+	Defendant   string         `json:"defendant"`
+	CauseAction int            `json:"cause_action"`
+	Claims      []int          `json:"claims,omitempty"`
+	Connected   []string       `json:"connected,omitempty"`
+	FiledAt     time.Time      `json:"filed_at,omitzero"`
+	Status      string         `json:"status"`
+	StatusAt    time.Time      `json:"status_at,omitzero"`
+	History     []HistoryEntry `json:"history,omitempty"`
+}
+
+type TrialExportRequest struct {
+	Envelope
+
+	Type      string `json:"type"` // "lawsuit_export"
+	LawsuitID string `json:"lawsuit_id"`
+	Reason    string `json:"reason,omitempty"` // reason of the transfer
+}
+
+type TrialExportResponse struct {
+	Envelope
+
+	Success bool             `json:"success"`
+	Message string           `json:"message"`
+	Lawsuit *TransferLawsuit `json:"lawsuit,omitempty"`
+}
+
+// The target trial answers a repeated import of the same lawsuit (same ID in the
+// source) with the lawsuit already imported
+type TrialImportRequest struct {
+	Envelope
+
+	Type    string          `json:"type"` // "lawsuit_import"
+	Lawsuit TransferLawsuit `json:"lawsuit"`
+
+	RequestID string `json:"request_id,omitempty"` // retransmissions have the same ID
+
+	// For the lawsuit's history: why, who and in which district
+	Reason   string `json:"reason,omitempty"`
+	Actor    string `json:"actor,omitempty"`
+	District string `json:"district,omitempty"`
+}
+
+type TrialImportResponse struct {
+	Envelope
+
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+
+	LawsuitID    string `json:"lawsuit_id,omitempty"` // new ID, in the target trial
+	DistrictID   int    `json:"district_id,omitempty"`
+	DistrictName string `json:"district_name,omitempty"`
+	TrialID      int    `json:"trial_id,omitempty"`
+	TrialAddr    string `json:"trial_addr,omitempty"`
+}
+
+type TrialTransferredRequest struct {
+	Envelope
+
+	Type      string `json:"type"` // "lawsuit_transferred"
+	LawsuitID string `json:"lawsuit_id"`
+	NewID     string `json:"new_id"` // ID in the target trial
+
+	RequestID string `json:"request_id,omitempty"` // retransmissions have the same ID
+
+	// For the lawsuit's history: why, who and in which district
+	Reason   string `json:"reason,omitempty"`
+	Actor    string `json:"actor,omitempty"`
+	District string `json:"district,omitempty"`
+}
+
+type TrialTransferredResponse struct {
+	Envelope
+
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+
+// ---------- Workload verification (DISTRICT -> TRIAL) ----------
+
+type WorkloadInfoRequest struct {
+	Envelope
+
+	Type string `json:"type"` // "workload_info"
+
+	// Lawsuits transferred to the trial (compensations of the district): the
+	// response counts the ones still pending that are not active
+	Received []string `json:"received,omitempty"`
+}
+
+type WorkloadInfoResponse struct {
+	Envelope
+
+	Success        bool   `json:"success"`
+	Message        string `json:"message"`
+	DistrictID     int    `json:"district_id,omitempty"`
+	DistrictName   string `json:"district_name,omitempty"`
+	TrialID        int    `json:"trial_id,omitempty"`
+	TrialAddr      string `json:"trial_addr,omitempty"`
+	ActiveWorkload int    `json:"active_workload"` // number of active lawsuits
+
+	// Lawsuits of Received suspended, archived provisionally or on appeal (still
+	// pending, but not in ActiveWorkload)
+	Received int `json:"received,omitempty"`
+}
+
+
+// Response of the trial to a message of unknown type
+type GenericResponse struct {
+	Envelope
+
+	Success bool        `json:"success"`
+	Message string      `json:"message"`
+	Dados   interface{} `json:"data,omitempty"`
+}
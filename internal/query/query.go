@@ -0,0 +1,426 @@
+// Package query parses the compound searches of lawsuits, e.g.
+//
+//	defendant contains Banco AND cause=12 AND list=Active
+//	claim in (10,20) OR NOT plaintiff = "Maria da Silva"
+//
+// into a predicate tree evaluated on each lawsuit (Record).
+//
+// Grammar (keywords and field names are case-insensitive):
+//
+//	expr  := and { OR and }
+//	and   := unary { AND unary }
+//	unary := NOT unary | "(" expr ")" | cond
+//	cond  := field op value | field IN "(" value { "," value } ")"
+//	field := id | plaintiff | defendant | cause | claim | list
+//	op    := = | != | contains | < | <= | > | >=
+//
+// Values with spaces go between quotes. Text comparisons ignore case; cause and
+// claim are numbers (< and > only for them); claim matches if any claim of the
+// lawsuit satisfies the condition.
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Fields of a lawsuit seen by the queries
+type Record struct {
+	ID        string
+	Plaintiff string
+	Defendant string
+	Cause     int
+	Claims    []int
+	List      string // "Active", "Suspended", "Archived provisionally", "On appeal", "Dismissed with merit", "Dismissed without merit"
+}
+
+// Predicate tree of a query
+type Node interface {
+	Match(r Record) bool
+	String() string
+}
+
+type andNode struct{ left, right Node }
+type orNode struct{ left, right Node }
+type notNode struct{ expr Node }
+
+func (n andNode) Match(r Record) bool { return n.left.Match(r) && n.right.Match(r) }
+func (n orNode) Match(r Record) bool  { return n.left.Match(r) || n.right.Match(r) }
+func (n notNode) Match(r Record) bool { return !n.expr.Match(r) }
+
+func (n andNode) String() string { return "(" + n.left.String() + " AND " + n.right.String() + ")" }
+func (n orNode) String() string  { return "(" + n.left.String() + " OR " + n.right.String() + ")" }
+func (n notNode) String() string { return "NOT " + n.expr.String() }
+
+// ---------- Conditions ----------
+
+var numericFields = map[string]bool{"cause": true, "claim": true}
+var textFields = map[string]bool{"id": true, "plaintiff": true, "defendant": true, "list": true}
+
+// Names of the lists (list=active, list="with merit", list=without_merit ...)
+var listNames = map[string]string{
+	"active":                  "active",
+	"dismissed with merit":    "dismissed with merit",
+	"with merit":              "dismissed with merit",
+	"dismissed without merit": "dismissed without merit",
+	"without merit":           "dismissed without merit",
+	"suspended":               "suspended",
+	"archived":                "archived provisionally",
+	"archived provisionally":  "archived provisionally",
+	"provisionally archived":  "archived provisionally",
+	"on appeal":               "on appeal",
+	"appeal":                  "on appeal",
+}
+
+func normalizeList(s string) string {
+	s = strings.ToLower(strings.Join(strings.Fields(strings.ReplaceAll(s, "_", " ")), " "))
+	if n, ok := listNames[s]; ok {
+		return n
+	}
+	return s
+}
+
+type cond struct {
+	field  string
+	op     string // "=", "!=", "contains", "<", "<=", ">", ">=", "in"
+	values []string
+	nums   []int
+}
+
+func (c cond) String() string {
+	if c.op == "in" {
+		return fmt.Sprintf("%s IN (%s)", c.field, strings.Join(quoteAll(c.values), ","))
+	}
+	return fmt.Sprintf("%s %s %s", c.field, c.op, quote(c.values[0]))
+}
+
+func quote(v string) string {
+	if v == "" || strings.ContainsAny(v, " \t(),=!<>\"") {
+		return strconv.Quote(v)
+	}
+	return v
+}
+
+func quoteAll(vs []string) []string {
+	out := make([]string, len(vs))
+	for i, v := range vs {
+		out[i] = quote(v)
+	}
+	return out
+}
+
+func (c cond) text(r Record) string {
+	switch c.field {
+	case "id":
+		return strings.ToLower(r.ID)
+	case "plaintiff":
+		return strings.ToLower(r.Plaintiff)
+	case "defendant":
+		return strings.ToLower(r.Defendant)
+	case "list":
+		return normalizeList(r.List)
+	}
+	return ""
+}
+
+func (c cond) matchText(s string) bool {
+	for _, v := range c.values {
+		want := strings.ToLower(v)
+		if c.field == "list" {
+			want = normalizeList(v)
+		}
+		switch c.op {
+		case "=", "in":
+			if s == want {
+				return true
+			}
+		case "!=":
+			return s != want
+		case "contains":
+			if strings.Contains(s, want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c cond) matchNum(n int) bool {
+	for _, v := range c.nums {
+		switch c.op {
+		case "=", "in":
+			if n == v {
+				return true
+			}
+		case "!=":
+			return n != v
+		case "<":
+			return n < v
+		case "<=":
+			return n <= v
+		case ">":
+			return n > v
+		case ">=":
+			return n >= v
+		}
+	}
+	return false
+}
+
+func (c cond) Match(r Record) bool {
+	switch c.field {
+	case "cause":
+		return c.matchNum(r.Cause)
+	case "claim":
+		if c.op == "!=" {
+			// no claim equal to the value
+			for _, p := range r.Claims {
+				if p == c.nums[0] {
+					return false
+				}
+			}
+			return true
+		}
+		for _, p := range r.Claims {
+			if c.matchNum(p) {
+				return true
+			}
+		}
+		return false
+	}
+	return c.matchText(c.text(r))
+}
+
+// ---------- Parser ----------
+
+type parser struct {
+	toks []token
+	pos  int
+}
+
+// Parse the query; the error tells the position of the problem
+func Parse(s string) (Node, error) {
+	toks, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(toks) == 0 {
+		return nil, fmt.Errorf("empty query")
+	}
+	p := &parser{toks: toks}
+	n, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.toks) {
+		return nil, p.errorf("unexpected %q (AND / OR missing? values with spaces go between quotes)", p.toks[p.pos].text)
+	}
+	return n, nil
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos >= len(p.toks) {
+		return token{}, false
+	}
+	return p.toks[p.pos], true
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	at := "end of the query"
+	if t, ok := p.peek(); ok {
+		at = fmt.Sprintf("position %d", t.pos+1)
+	}
+	return fmt.Errorf("%s (at %s)", fmt.Sprintf(format, args...), at)
+}
+
+// Consume the keyword kw (case-insensitive, not quoted)
+func (p *parser) keyword(kw string) bool {
+	t, ok := p.peek()
+	if ok && !t.quoted && strings.EqualFold(t.text, kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expr() (Node, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("OR") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) and() (Node, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("AND") {
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) unary() (Node, error) {
+	if p.keyword("NOT") {
+		n, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{n}, nil
+	}
+	if p.keyword("(") {
+		n, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.keyword(")") {
+			return nil, p.errorf("missing \")\"")
+		}
+		return n, nil
+	}
+	return p.cond()
+}
+
+func (p *parser) value() (string, error) {
+	t, ok := p.peek()
+	if !ok || (!t.quoted && strings.ContainsAny(t.text, "(),") && len(t.text) == 1) {
+		return "", p.errorf("value expected")
+	}
+	p.pos++
+	return t.text, nil
+}
+
+func (p *parser) cond() (Node, error) {
+	t, ok := p.peek()
+	if !ok || t.quoted {
+		return nil, p.errorf("field expected (id, plaintiff, defendant, cause, claim or list)")
+	}
+	field := strings.ToLower(t.text)
+	if !numericFields[field] && !textFields[field] {
+		return nil, p.errorf("unknown field %q", t.text)
+	}
+	p.pos++
+
+	c := cond{field: field}
+	op, ok := p.peek()
+	if !ok {
+		return nil, p.errorf("operator expected after %s", field)
+	}
+	c.op = strings.ToLower(op.text)
+	p.pos++
+
+	switch c.op {
+	case "=", "!=":
+	case "contains":
+		if numericFields[field] {
+			return nil, p.errorf("contains is not valid for %s (a number)", field)
+		}
+	case "<", "<=", ">", ">=":
+		if !numericFields[field] {
+			return nil, p.errorf("%s is valid only for cause and claim", c.op)
+		}
+	case "in":
+		if !p.keyword("(") {
+			return nil, p.errorf("\"(\" expected after IN")
+		}
+		for {
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			c.values = append(c.values, v)
+			if p.keyword(")") {
+				break
+			}
+			if !p.keyword(",") {
+				return nil, p.errorf("\",\" or \")\" expected in the IN list")
+			}
+		}
+	default:
+		p.pos--
+		return nil, p.errorf("operator expected after %s (=, !=, contains, <, <=, >, >= or IN)", field)
+	}
+	if c.op != "in" {
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		c.values = []string{v}
+	}
+
+	if numericFields[field] {
+		for _, v := range c.values {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s needs a number, not %q", field, v)
+			}
+			c.nums = append(c.nums, n)
+		}
+	}
+	return c, nil
+}
+
+// ---------- Tokens ----------
+
+type token struct {
+	text   string
+	quoted bool
+	pos    int
+}
+
+func tokenize(s string) ([]token, error) {
+	var toks []token
+	rs := []rune(s)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			i++
+		case r == '(' || r == ')' || r == ',' || r == '=':
+			toks = append(toks, token{text: string(r), pos: i})
+			i++
+		case r == '!' || r == '<' || r == '>':
+			if i+1 < len(rs) && rs[i+1] == '=' {
+				toks = append(toks, token{text: string(rs[i : i+2]), pos: i})
+				i += 2
+			} else if r == '!' {
+				return nil, fmt.Errorf("\"!\" without \"=\" (at position %d)", i+1)
+			} else {
+				toks = append(toks, token{text: string(r), pos: i})
+				i++
+			}
+		case r == '"' || r == '\'':
+			j := i + 1
+			for j < len(rs) && rs[j] != r {
+				j++
+			}
+			if j >= len(rs) {
+				return nil, fmt.Errorf("unclosed quote (at position %d)", i+1)
+			}
+			toks = append(toks, token{text: string(rs[i+1 : j]), quoted: true, pos: i})
+			i = j + 1
+		default:
+			j := i
+			for j < len(rs) && !strings.ContainsRune(" \t\n\r(),=!<>\"'", rs[j]) {
+				j++
+			}
+			toks = append(toks, token{text: string(rs[i:j]), pos: i})
+			i = j
+		}
+	}
+	return toks, nil
+}
@@ -0,0 +1,171 @@
+package query
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePrecedence(t *testing.T) {
+	for _, tc := range []struct {
+		query string
+		tree  string
+	}{
+		{"cause=12", "cause = 12"},
+		{"cause=1 OR cause=2 AND cause=3", "(cause = 1 OR (cause = 2 AND cause = 3))"},
+		{"cause=1 AND cause=2 OR cause=3", "((cause = 1 AND cause = 2) OR cause = 3)"},
+		{"(cause=1 OR cause=2) AND cause=3", "((cause = 1 OR cause = 2) AND cause = 3)"},
+		{"NOT cause=1 AND cause=2", "(NOT cause = 1 AND cause = 2)"},
+		{"NOT (cause=1 AND cause=2)", "NOT (cause = 1 AND cause = 2)"},
+		{"NOT NOT cause=1", "NOT NOT cause = 1"},
+		{"cause=1 OR cause=2 OR cause=3", "((cause = 1 OR cause = 2) OR cause = 3)"},
+		{"claim in (10, 20,30)", "claim IN (10,20,30)"},
+		{"cause >= 5 and claim < 100", "(cause >= 5 AND claim < 100)"},
+		{"Defendant CONTAINS bank oR Plaintiff != x", "(defendant contains bank OR plaintiff != x)"},
+	} {
+		n, err := Parse(tc.query)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tc.query, err)
+			continue
+		}
+		if got := n.String(); got != tc.tree {
+			t.Errorf("Parse(%q) = %s, want %s", tc.query, got, tc.tree)
+		}
+	}
+}
+
+func TestParseQuoting(t *testing.T) {
+	for _, tc := range []struct {
+		query string
+		tree  string
+	}{
+		{`plaintiff = "Maria da Silva"`, `plaintiff = "Maria da Silva"`},
+		{`plaintiff = 'Maria da Silva'`, `plaintiff = "Maria da Silva"`},
+		{`defendant contains "AND"`, `defendant contains AND`},
+		{`plaintiff = "a (b), c"`, `plaintiff = "a (b), c"`},
+		{`plaintiff = ""`, `plaintiff = ""`},
+		{`plaintiff in ("Maria da Silva", Jose)`, `plaintiff IN ("Maria da Silva",Jose)`},
+		{`id="1.2.3"`, `id = 1.2.3`},
+	} {
+		n, err := Parse(tc.query)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tc.query, err)
+			continue
+		}
+		if got := n.String(); got != tc.tree {
+			t.Errorf("Parse(%q) = %s, want %s", tc.query, got, tc.tree)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, tc := range []struct {
+		query string
+		err   string
+	}{
+		{"", "empty query"},
+		{"   ", "empty query"},
+		{"court=1", `unknown field "court"`},
+		{`"cause"=1`, "field expected"},
+		{"cause", "operator expected after cause"},
+		{"cause ~ 1", "operator expected after cause"},
+		{"cause =", "value expected"},
+		{"cause = abc", `cause needs a number, not "abc"`},
+		{"cause contains 1", "contains is not valid for cause"},
+		{"plaintiff < x", "< is valid only for cause and claim"},
+		{"claim in 1,2", `"(" expected after IN`},
+		{"claim in (1 2)", `"," or ")" expected in the IN list`},
+		{"claim in (1,", "value expected"},
+		{"claim in ()", "value expected"},
+		{"(cause=1", `missing ")"`},
+		{"cause=1 cause=2", `unexpected "cause"`},
+		{"cause=1 AND", "field expected"},
+		{"NOT", "field expected"},
+		{`plaintiff = "Maria`, "unclosed quote (at position 13)"},
+		{"plaintiff ! x", `"!" without "="`},
+		{"plaintiff = Maria da Silva", `unexpected "da" (AND / OR missing?`},
+	} {
+		_, err := Parse(tc.query)
+		if err == nil {
+			t.Errorf("Parse(%q) accepted, want error %q", tc.query, tc.err)
+			continue
+		}
+		if !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("Parse(%q) error %q, want %q", tc.query, err, tc.err)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	r := Record{
+		ID:        "1.2.3",
+		Plaintiff: "Maria da Silva",
+		Defendant: "Banco do Brasil",
+		Cause:     12,
+		Claims:    []int{10, 20},
+		List:      "Dismissed with merit",
+	}
+	for _, tc := range []struct {
+		query string
+		match bool
+	}{
+		{"id = 1.2.3", true},
+		{"id = 1.2.30", false},
+		{`plaintiff = "maria da silva"`, true},
+		{"plaintiff = maria", false},
+		{"plaintiff contains SILVA", true},
+		{"defendant contains banco AND cause=12", true},
+		{"defendant contains banco AND cause=13", false},
+		{"defendant contains caixa OR cause=12", true},
+		{"NOT cause=12", false},
+		{"cause != 12", false},
+		{"cause > 11 AND cause < 13", true},
+		{"cause >= 12 AND cause <= 12", true},
+		{"cause in (1, 12)", true},
+		{"cause in (1, 2)", false},
+		{"claim = 20", true},
+		{"claim = 30", false},
+		{"claim != 10", false}, // no claim equal to 10
+		{"claim != 30", true},
+		{"claim > 15", true},
+		{"claim > 20", false},
+		{"claim in (5, 10)", true},
+		{"list = with_merit", true},
+		{`list = "Dismissed with merit"`, true},
+		{"list = active", false},
+		{"list != active", true},
+		{`list in (active, "with merit")`, true},
+		{"plaintiff in (jose, maria)", false},
+		{"NOT (cause=1 OR claim=30) AND list=\"with merit\"", true},
+		{"cause=1 OR cause=12 AND claim=30", false},
+		{"(cause=1 OR cause=12) AND claim=20", true},
+	} {
+		n, err := Parse(tc.query)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tc.query, err)
+			continue
+		}
+		if got := n.Match(r); got != tc.match {
+			t.Errorf("%q on %+v = %v, want %v", tc.query, r, got, tc.match)
+		}
+	}
+}
+
+// The String of a tree parses into the same tree
+func TestStringRoundTrip(t *testing.T) {
+	for _, q := range []string{
+		`plaintiff = "Maria da Silva" OR NOT (cause in (1,2) AND claim >= 3)`,
+		`defendant contains "a,b" AND list = "on appeal"`,
+	} {
+		n, err := Parse(q)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", q, err)
+		}
+		again, err := Parse(n.String())
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", n.String(), err)
+		}
+		if again.String() != n.String() {
+			t.Errorf("round trip of %q: %s != %s", q, again, n)
+		}
+	}
+}
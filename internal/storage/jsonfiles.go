@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"judiciary/internal/persist"
+)
+
+// One JSON file per key (persist.SaveJSON: temporary file fsynced and renamed).
+// A transaction is atomic for each file, not between files.
+type JSONFiles struct {
+	mu    sync.Mutex
+	files map[string]string
+}
+
+func NewJSONFiles(files map[string]string) *JSONFiles {
+	return &JSONFiles{files: files}
+}
+
+func (j *JSONFiles) Name() string { return NameJSON }
+
+func (j *JSONFiles) path(key string) (string, error) {
+	p, ok := j.files[key]
+	if !ok {
+		return "", fmt.Errorf("json storage: no file for key %q", key)
+	}
+	return p, nil
+}
+
+func (j *JSONFiles) Get(key string, v any) (bool, error) {
+	p, err := j.path(key)
+	if err != nil {
+		return false, err
+	}
+	return persist.LoadJSON(p, v)
+}
+
+func (j *JSONFiles) Scan(prefix string, fn func(key string, value []byte) error) error {
+	keys := make([]string, 0, len(j.files))
+	for k := range j.files {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		b, err := os.ReadFile(j.files[k])
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(k, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type jsonTx struct {
+	j       *JSONFiles
+	writes  map[string]any
+	deletes map[string]bool
+}
+
+func (tx *jsonTx) Get(key string, v any) (bool, error) {
+	if tx.deletes[key] {
+		return false, nil
+	}
+	if w, ok := tx.writes[key]; ok {
+		b, err := json.Marshal(w)
+		if err != nil {
+			return false, err
+		}
+		return true, json.Unmarshal(b, v)
+	}
+	return tx.j.Get(key, v)
+}
+
+func (tx *jsonTx) Put(key string, v any) error {
+	if _, err := tx.j.path(key); err != nil {
+		return err
+	}
+	tx.writes[key] = v
+	delete(tx.deletes, key)
+	return nil
+}
+
+func (tx *jsonTx) Delete(key string) error {
+	if _, err := tx.j.path(key); err != nil {
+		return err
+	}
+	tx.deletes[key] = true
+	delete(tx.writes, key)
+	return nil
+}
+
+func (j *JSONFiles) Update(fn func(tx Tx) error) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	tx := &jsonTx{j: j, writes: map[string]any{}, deletes: map[string]bool{}}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	for key, v := range tx.writes {
+		if err := persist.SaveJSON(j.files[key], v); err != nil {
+			return err
+		}
+	}
+	for key := range tx.deletes {
+		if err := os.Remove(j.files[key]); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (j *JSONFiles) Close() error { return nil }
@@ -0,0 +1,417 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"judiciary/internal/persist"
+)
+
+// Single-file transactional key-value store.
+//
+// The file is a magic followed by frames, one per transaction:
+//
+//	length (4 bytes) | CRC-32 of the payload (4) | payload
+//	payload: count of writes (uvarint), then for each write
+//	         'P' | key length (uvarint) | key | value length (uvarint) | value (JSON)
+//	      or 'D' | key length (uvarint) | key
+//
+// A frame is written and fsynced whole before Update returns; at Open a last
+// frame incomplete or with a wrong CRC (fault while writing) is cut, so a
+// transaction is in the file with all its writes or not at all. Only the keys
+// and the positions of their values are kept in memory: Get reads one value
+// from the file. When most of the file is old values, Update rewrites it with
+// only the current ones (compaction).
+type KV struct {
+	mu    sync.RWMutex
+	path  string
+	f     *os.File
+	size  int64
+	live  int64 // bytes of the current values
+	index map[string]kvLoc
+}
+
+type kvLoc struct {
+	off int64
+	n   int
+}
+
+var kvMagic = []byte("JKV\x01")
+
+const (
+	kvFrameHeader  = 8
+	kvCompactAbove = 4 << 20 // file size for compaction (and more than half old values)
+	kvFrameMax     = 4 << 20 // largest frame written by the compaction
+)
+
+func OpenKV(path string) (*KV, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	kv := &KV{path: path, f: f, index: map[string]kvLoc{}}
+	if err := kv.load(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return kv, nil
+}
+
+func (kv *KV) Name() string { return NameKV }
+
+// Read the frames and build the index
+func (kv *KV) load() error {
+	st, err := kv.f.Stat()
+	if err != nil {
+		return err
+	}
+	if st.Size() == 0 {
+		if _, err := kv.f.WriteAt(kvMagic, 0); err != nil {
+			return err
+		}
+		kv.size = int64(len(kvMagic))
+		return kv.f.Sync()
+	}
+
+	r := bufio.NewReader(io.NewSectionReader(kv.f, 0, st.Size()))
+	magic := make([]byte, len(kvMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || !bytes.Equal(magic, kvMagic) {
+		return fmt.Errorf("%s is not a kv storage file", kv.path)
+	}
+	off := int64(len(kvMagic))
+	header := make([]byte, kvFrameHeader)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			break
+		}
+		n := binary.BigEndian.Uint32(header[0:4])
+		if int64(n) > st.Size()-off-kvFrameHeader {
+			break
+		}
+		payload := make([]byte, n)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			break
+		}
+		if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+			break
+		}
+		if err := kv.apply(off+kvFrameHeader, payload); err != nil {
+			return fmt.Errorf("%s: frame at %d: %w", kv.path, off, err)
+		}
+		off += kvFrameHeader + int64(n)
+	}
+
+	if off < st.Size() {
+		// torn last transaction: never acknowledged, drop it
+		log.Printf("[STORAGE] %s - %s: incomplete transaction at %d cut (%d bytes)",
+			time.Now().Format(time.RFC3339), kv.path, off, st.Size()-off)
+		if err := kv.f.Truncate(off); err != nil {
+			return err
+		}
+		if err := kv.f.Sync(); err != nil {
+			return err
+		}
+	}
+	kv.size = off
+	return nil
+}
+
+// Update the index with the writes of a frame whose payload starts at base
+func (kv *KV) apply(base int64, payload []byte) error {
+	count, p, err := uvarint(payload, 0)
+	if err != nil {
+		return err
+	}
+	for ; count > 0; count-- {
+		if p >= len(payload) {
+			return errors.New("truncated payload")
+		}
+		op := payload[p]
+		p++
+		kl, q, err := uvarint(payload, p)
+		if err != nil || q+int(kl) > len(payload) {
+			return errors.New("bad key")
+		}
+		key := string(payload[q : q+int(kl)])
+		p = q + int(kl)
+
+		if old, ok := kv.index[key]; ok {
+			kv.live -= int64(old.n)
+			delete(kv.index, key)
+		}
+		switch op {
+		case 'P':
+			vl, q, err := uvarint(payload, p)
+			if err != nil || q+int(vl) > len(payload) {
+				return errors.New("bad value")
+			}
+			kv.index[key] = kvLoc{off: base + int64(q), n: int(vl)}
+			kv.live += int64(vl)
+			p = q + int(vl)
+		case 'D':
+		default:
+			return fmt.Errorf("unknown write %q", op)
+		}
+	}
+	return nil
+}
+
+func uvarint(b []byte, p int) (uint64, int, error) {
+	if p >= len(b) {
+		return 0, p, errors.New("truncated payload")
+	}
+	v, n := binary.Uvarint(b[p:])
+	if n <= 0 {
+		return 0, p, errors.New("bad varint")
+	}
+	return v, p + n, nil
+}
+
+// Value of key in the file (kv.mu must be locked)
+func (kv *KV) read(key string) ([]byte, bool, error) {
+	loc, ok := kv.index[key]
+	if !ok {
+		return nil, false, nil
+	}
+	b := make([]byte, loc.n)
+	if _, err := kv.f.ReadAt(b, loc.off); err != nil {
+		return nil, false, err
+	}
+	return b, true, nil
+}
+
+func (kv *KV) Get(key string, v any) (bool, error) {
+	kv.mu.RLock()
+	b, found, err := kv.read(key)
+	kv.mu.RUnlock()
+	if err != nil || !found {
+		return false, err
+	}
+	return true, json.Unmarshal(b, v)
+}
+
+func (kv *KV) Scan(prefix string, fn func(key string, value []byte) error) error {
+	kv.mu.RLock()
+	keys := make([]string, 0)
+	for k := range kv.index {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	kv.mu.RUnlock()
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		kv.mu.RLock()
+		b, found, err := kv.read(k)
+		kv.mu.RUnlock()
+		if err != nil {
+			return err
+		}
+		if !found {
+			// deleted while scanning
+			continue
+		}
+		if err := fn(k, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type kvWrite struct {
+	key   string
+	value []byte // nil: delete
+}
+
+type kvTx struct {
+	kv     *KV
+	writes []kvWrite
+	latest map[string]int // key -> position in writes
+}
+
+func (tx *kvTx) set(key string, value []byte) {
+	if i, ok := tx.latest[key]; ok {
+		tx.writes[i].value = value
+		return
+	}
+	tx.latest[key] = len(tx.writes)
+	tx.writes = append(tx.writes, kvWrite{key: key, value: value})
+}
+
+func (tx *kvTx) Get(key string, v any) (bool, error) {
+	if i, ok := tx.latest[key]; ok {
+		if tx.writes[i].value == nil {
+			return false, nil
+		}
+		return true, json.Unmarshal(tx.writes[i].value, v)
+	}
+	b, found, err := tx.kv.read(key)
+	if err != nil || !found {
+		return false, err
+	}
+	return true, json.Unmarshal(b, v)
+}
+
+func (tx *kvTx) Put(key string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	tx.set(key, b)
+	return nil
+}
+
+func (tx *kvTx) Delete(key string) error {
+	tx.set(key, nil)
+	return nil
+}
+
+func encodeFrame(writes []kvWrite) []byte {
+	var payload []byte
+	payload = binary.AppendUvarint(payload, uint64(len(writes)))
+	for _, w := range writes {
+		if w.value == nil {
+			payload = append(payload, 'D')
+			payload = binary.AppendUvarint(payload, uint64(len(w.key)))
+			payload = append(payload, w.key...)
+			continue
+		}
+		payload = append(payload, 'P')
+		payload = binary.AppendUvarint(payload, uint64(len(w.key)))
+		payload = append(payload, w.key...)
+		payload = binary.AppendUvarint(payload, uint64(len(w.value)))
+		payload = append(payload, w.value...)
+	}
+	frame := make([]byte, kvFrameHeader, kvFrameHeader+len(payload))
+	binary.BigEndian.PutUint32(frame[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(frame[4:8], crc32.ChecksumIEEE(payload))
+	return append(frame, payload...)
+}
+
+func (kv *KV) Update(fn func(tx Tx) error) error {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	tx := &kvTx{kv: kv, latest: map[string]int{}}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if len(tx.writes) == 0 {
+		return nil
+	}
+
+	frame := encodeFrame(tx.writes)
+	if _, err := kv.f.WriteAt(frame, kv.size); err != nil {
+		_ = kv.f.Truncate(kv.size)
+		return err
+	}
+	if err := kv.f.Sync(); err != nil {
+		_ = kv.f.Truncate(kv.size)
+		return err
+	}
+	if err := kv.apply(kv.size+kvFrameHeader, frame[kvFrameHeader:]); err != nil {
+		// not acknowledged: the frame is cut and the index (maybe with part of
+		// its writes) is built again from the file
+		_ = kv.f.Truncate(kv.size)
+		_ = kv.f.Sync()
+		if rerr := kv.reload(); rerr != nil {
+			return fmt.Errorf("%v (index not rebuilt: %v)", err, rerr)
+		}
+		return err
+	}
+	kv.size += int64(len(frame))
+
+	if kv.size > kvCompactAbove && kv.live < kv.size/2 {
+		if err := kv.compact(); err != nil {
+			// the transaction is saved; the file stays as it is
+			log.Printf("[STORAGE] %s - error while compacting %s: %v", time.Now().Format(time.RFC3339), kv.path, err)
+		}
+	}
+	return nil
+}
+
+// Rewrite the file with only the current values (kv.mu must be locked)
+func (kv *KV) compact() error {
+	keys := make([]string, 0, len(kv.index))
+	for k := range kv.index {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	tmp := kv.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	w.Write(kvMagic)
+	var batch []kvWrite
+	var batchSize int
+	flush := func() {
+		if len(batch) > 0 {
+			w.Write(encodeFrame(batch))
+			batch, batchSize = nil, 0
+		}
+	}
+	for _, k := range keys {
+		b, _, err := kv.read(k)
+		if err != nil {
+			f.Close()
+			os.Remove(tmp)
+			return err
+		}
+		batch = append(batch, kvWrite{key: k, value: b})
+		batchSize += len(k) + len(b)
+		if batchSize >= kvFrameMax {
+			flush()
+		}
+	}
+	flush()
+	if err := w.Flush(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, kv.path); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	persist.SyncDir(kv.path)
+
+	old := kv.f
+	kv.f = f
+	old.Close()
+	return kv.reload()
+}
+
+// Build the index again from the file (kv.mu must be locked)
+func (kv *KV) reload() error {
+	kv.index = map[string]kvLoc{}
+	kv.live = 0
+	return kv.load()
+}
+
+func (kv *KV) Close() error {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	return kv.f.Close()
+}
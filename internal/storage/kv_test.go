@@ -0,0 +1,226 @@
+package storage
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func openTestKV(t *testing.T, path string) *KV {
+	t.Helper()
+	kv, err := OpenKV(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { kv.Close() })
+	return kv
+}
+
+func mustPut(t *testing.T, kv *KV, key string, v any) {
+	t.Helper()
+	if err := kv.Update(func(tx Tx) error { return tx.Put(key, v) }); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func getString(t *testing.T, kv *KV, key string) (string, bool) {
+	t.Helper()
+	var v string
+	found, err := kv.Get(key, &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v, found
+}
+
+func fileSize(t *testing.T, path string) int64 {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info.Size()
+}
+
+// The last frame damaged by a fault while writing is cut at Open: the
+// transactions before it are kept and the next one takes its place
+func TestKVDamagedTail(t *testing.T) {
+	frame := encodeFrame([]kvWrite{{key: "c", value: []byte(`"three"`)}, {key: "a", value: nil}})
+
+	for _, tc := range []struct {
+		name string
+		tail func() []byte
+	}{
+		{"header only", func() []byte { return frame[:kvFrameHeader-3] }},
+		{"half frame", func() []byte { return frame[:len(frame)/2] }},
+		{"length beyond the file", func() []byte {
+			b := append([]byte(nil), frame...)
+			binary.BigEndian.PutUint32(b[0:4], uint32(len(frame)*2))
+			return b
+		}},
+		{"CRC mismatch", func() []byte {
+			b := append([]byte(nil), frame...)
+			b[len(b)-2] ^= 0xff
+			return b
+		}},
+		{"wrong CRC field", func() []byte {
+			b := append([]byte(nil), frame...)
+			b[5] ^= 0x01
+			return b
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "state.kv")
+			kv := openTestKV(t, path)
+			mustPut(t, kv, "a", "one")
+			mustPut(t, kv, "b", "two")
+			kv.Close()
+			good := fileSize(t, path)
+
+			f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := f.Write(tc.tail()); err != nil {
+				t.Fatal(err)
+			}
+			f.Close()
+
+			kv = openTestKV(t, path)
+			if v, found := getString(t, kv, "a"); !found || v != "one" {
+				t.Fatalf("a = %q %v, want one (delete of the damaged frame applied)", v, found)
+			}
+			if _, found := getString(t, kv, "c"); found {
+				t.Fatal("write of the damaged frame applied")
+			}
+			if size := fileSize(t, path); size != good {
+				t.Fatalf("file of %d bytes, want %d (damaged frame cut)", size, good)
+			}
+
+			mustPut(t, kv, "c", "again")
+			kv.Close()
+			kv = openTestKV(t, path)
+			for key, want := range map[string]string{"a": "one", "b": "two", "c": "again"} {
+				if v, found := getString(t, kv, key); !found || v != want {
+					t.Fatalf("%s = %q %v after the reopen, want %q", key, v, found, want)
+				}
+			}
+		})
+	}
+}
+
+// A frame with a wrong CRC before valid frames ends the file: what follows it
+// cannot be trusted either
+func TestKVCorruptFrameInTheMiddle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.kv")
+	kv := openTestKV(t, path)
+	mustPut(t, kv, "a", "one")
+	first := fileSize(t, path)
+	mustPut(t, kv, "b", "two")
+	mustPut(t, kv, "c", "three")
+	kv.Close()
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b[first+kvFrameHeader+1] ^= 0xff // payload of the frame of b
+	if err := os.WriteFile(path, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	kv = openTestKV(t, path)
+	if _, found := getString(t, kv, "a"); !found {
+		t.Fatal("frame before the corrupt one lost")
+	}
+	for _, key := range []string{"b", "c"} {
+		if _, found := getString(t, kv, key); found {
+			t.Fatalf("%s read from or after the corrupt frame", key)
+		}
+	}
+	if size := fileSize(t, path); size != first {
+		t.Fatalf("file of %d bytes, want %d", size, first)
+	}
+}
+
+func TestKVNotKVFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.kv")
+	if err := os.WriteFile(path, []byte(`{"districts":[]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenKV(path); err == nil || !strings.Contains(err.Error(), "not a kv storage file") {
+		t.Fatalf("OpenKV error = %v, want not a kv storage file", err)
+	}
+}
+
+// Above kvCompactAbove with most of the file old values, the file is
+// rewritten with the current ones, which are read the same before and after
+// a reopen
+func TestKVCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.kv")
+	kv := openTestKV(t, path)
+	value := func(key string, round int) string {
+		return key + strings.Repeat("x", 64<<10) + string(rune('a'+round%26))
+	}
+	keys := []string{"k1", "k2", "k3", "gone"}
+	rounds := 0
+	for ; int64(rounds*len(keys)*(64<<10)) < 2*kvCompactAbove; rounds++ {
+		err := kv.Update(func(tx Tx) error {
+			for _, k := range keys {
+				if err := tx.Put(k, value(k, rounds)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := kv.Update(func(tx Tx) error { return tx.Delete("gone") }); err != nil {
+		t.Fatal(err)
+	}
+
+	size := fileSize(t, path)
+	if size > kvCompactAbove || size != kv.size {
+		t.Fatalf("file of %d bytes (kv.size %d) after %d rounds, want it compacted below %d", size, kv.size, rounds, kvCompactAbove)
+	}
+
+	check := func(kv *KV, when string) {
+		t.Helper()
+		for _, k := range keys[:3] {
+			if v, found := getString(t, kv, k); !found || v != value(k, rounds-1) {
+				t.Fatalf("%s %s: value of %d bytes (found %v), want the last one", when, k, len(v), found)
+			}
+		}
+		if _, found := getString(t, kv, "gone"); found {
+			t.Fatalf("%s: deleted key back", when)
+		}
+		var scanned []string
+		if err := kv.Scan("k", func(key string, _ []byte) error {
+			scanned = append(scanned, key)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(scanned, ",") != "k1,k2,k3" {
+			t.Fatalf("%s: scan %v", when, scanned)
+		}
+	}
+	check(kv, "after the compaction")
+
+	// writes after the compaction go to the new file
+	mustPut(t, kv, "new", "four")
+	kv.Close()
+
+	kv = openTestKV(t, path)
+	check(kv, "after the reopen")
+	if v, found := getString(t, kv, "new"); !found || v != "four" {
+		t.Fatalf("write after the compaction: %q %v", v, found)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("temporary file of the compaction left: %v", err)
+	}
+}
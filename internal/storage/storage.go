@@ -0,0 +1,84 @@
+// Package storage keeps the state of the agents (court's and district's lists,
+// trial's lawsuits) in a backend chosen by the -storage flag:
+//
+//   - json: one JSON file per key (districts.json, trials.json, lawsuits.json...),
+//     rewritten whole on each update, as the agents always did;
+//   - kv: one file with every key, append-only, where each update is a
+//     transaction (all its writes or none) and a read loads only its record.
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"judiciary/internal/persist"
+)
+
+const (
+	NameJSON = "json"
+	NameKV   = "kv"
+)
+
+// Records (JSON values) by key
+type Backend interface {
+	Name() string
+
+	// Decode the record of key into v; found is false (and v unchanged) if there is none
+	Get(key string, v any) (found bool, err error)
+
+	// Calls fn with each record whose key has the prefix, in key order
+	Scan(prefix string, fn func(key string, value []byte) error) error
+
+	// Runs fn in one transaction: its writes are saved when fn returns nil
+	Update(fn func(tx Tx) error) error
+
+	Close() error
+}
+
+// Writes of one transaction (Get sees the writes already done in it)
+type Tx interface {
+	Get(key string, v any) (found bool, err error)
+	Put(key string, v any) error
+	Delete(key string) error
+}
+
+// Backend by name (the -storage flag): files gives the JSON file of each key
+// (json) and kvPath the file of the kv backend
+func Open(name, kvPath string, files map[string]string) (Backend, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case NameJSON, "":
+		return NewJSONFiles(files), nil
+	case NameKV:
+		return OpenKV(kvPath)
+	}
+	return nil, fmt.Errorf("unknown storage %q (use json or kv)", name)
+}
+
+// Copy into b the JSON files of the keys that b does not have yet (first start
+// of an agent with -storage kv after running with json)
+func Import(b Backend, files map[string]string) error {
+	if b.Name() == NameJSON {
+		return nil
+	}
+	return b.Update(func(tx Tx) error {
+		for key, path := range files {
+			var raw json.RawMessage
+			if found, err := tx.Get(key, &raw); err != nil || found {
+				continue
+			}
+			if _, err := os.Stat(path); err != nil {
+				continue
+			}
+			var v json.RawMessage
+			if _, err := persist.LoadJSON(path, &v); err != nil {
+				return fmt.Errorf("import of %s: %w", path, err)
+			}
+			if err := tx.Put(key, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,120 @@
+package transport
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// UDP messages larger than a safe datagram (below the usual MTU) are sent in
+// fragments; the receiver reassembles them before the handler / accept sees the
+// message. A message with a lost fragment is dropped (the request times out).
+//
+// Fragment: magic (4 bytes) | message ID (8) | index (2) | count (2) | payload
+// JSON never starts with the magic's first byte (0), so whole messages and
+// fragments can arrive on the same socket.
+const (
+	safeDatagram  = 1400
+	fragHeaderLen = 16
+	fragPayload   = safeDatagram - fragHeaderLen
+	fragmentTTL   = 10 * time.Second
+)
+
+var fragMagic = []byte{0, 'F', 'R', 1}
+
+// Datagrams to send for message b (b itself if it fits in one datagram)
+func fragment(b []byte) ([][]byte, error) {
+	if len(b) <= safeDatagram {
+		return [][]byte{b}, nil
+	}
+	if len(b) > MaxMessage {
+		return nil, ErrTooLarge
+	}
+	count := (len(b) + fragPayload - 1) / fragPayload
+
+	var id [8]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		binary.BigEndian.PutUint64(id[:], uint64(time.Now().UnixNano()))
+	}
+	out := make([][]byte, 0, count)
+	for i := 0; i < count; i++ {
+		part := b[i*fragPayload : min((i+1)*fragPayload, len(b))]
+		d := make([]byte, fragHeaderLen+len(part))
+		copy(d, fragMagic)
+		copy(d[4:12], id[:])
+		binary.BigEndian.PutUint16(d[12:14], uint16(i))
+		binary.BigEndian.PutUint16(d[14:16], uint16(count))
+		copy(d[fragHeaderLen:], part)
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+type partialMessage struct {
+	parts    [][]byte
+	received int
+	size     int
+	started  time.Time
+}
+
+// Fragments received and not yet complete, by sender and message ID
+type reassembler struct {
+	mu      sync.Mutex
+	pending map[string]*partialMessage
+}
+
+func newReassembler() *reassembler {
+	return &reassembler{pending: map[string]*partialMessage{}}
+}
+
+// Add a datagram received from sender; returns the whole message when it is
+// complete (a datagram that is not a fragment is already complete)
+func (r *reassembler) add(sender string, d []byte) ([]byte, bool, error) {
+	if len(d) < fragHeaderLen || !bytes.Equal(d[:4], fragMagic) {
+		return d, true, nil
+	}
+	idx := int(binary.BigEndian.Uint16(d[12:14]))
+	count := int(binary.BigEndian.Uint16(d[14:16]))
+	if count == 0 || idx >= count || count*fragPayload > MaxMessage+fragPayload {
+		return nil, false, fmt.Errorf("invalid fragment %d/%d from %s", idx, count, sender)
+	}
+	key := sender + "/" + string(d[4:12])
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for k, p := range r.pending {
+		if now.Sub(p.started) > fragmentTTL {
+			delete(r.pending, k)
+		}
+	}
+
+	p, ok := r.pending[key]
+	if !ok {
+		p = &partialMessage{parts: make([][]byte, count), started: now}
+		r.pending[key] = p
+	}
+	if len(p.parts) != count {
+		delete(r.pending, key)
+		return nil, false, fmt.Errorf("inconsistent fragment count from %s", sender)
+	}
+	if p.parts[idx] == nil {
+		p.parts[idx] = append([]byte(nil), d[fragHeaderLen:]...)
+		p.received++
+		p.size += len(d) - fragHeaderLen
+	}
+	if p.received < count {
+		return nil, false, nil
+	}
+
+	delete(r.pending, key)
+	msg := make([]byte, 0, p.size)
+	for _, part := range p.parts {
+		msg = append(msg, part...)
+	}
+	return msg, true, nil
+}
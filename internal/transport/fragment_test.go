@@ -0,0 +1,254 @@
+package transport
+
+import (
+	"bytes"
+	"math/rand"
+	"net"
+	"testing"
+	"time"
+)
+
+// Message of n bytes that is not a fragment (JSON-like)
+func testMessage(n int) []byte {
+	b := make([]byte, n)
+	r := rand.New(rand.NewSource(int64(n)))
+	for i := range b {
+		b[i] = 'a' + byte(r.Intn(26))
+	}
+	b[0] = '{'
+	return b
+}
+
+func mustFragment(t *testing.T, msg []byte) [][]byte {
+	t.Helper()
+	datagrams, err := fragment(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range datagrams {
+		if len(d) > safeDatagram {
+			t.Fatalf("datagram of %d bytes (limit %d)", len(d), safeDatagram)
+		}
+	}
+	return datagrams
+}
+
+func TestFragmentSmallMessage(t *testing.T) {
+	msg := testMessage(safeDatagram)
+	datagrams := mustFragment(t, msg)
+	if len(datagrams) != 1 || !bytes.Equal(datagrams[0], msg) {
+		t.Fatalf("message of %d bytes sent as %d datagram(s)", len(msg), len(datagrams))
+	}
+	got, complete, err := newReassembler().add("a", datagrams[0])
+	if err != nil || !complete || !bytes.Equal(got, msg) {
+		t.Fatalf("whole datagram not passed as is: complete=%v err=%v", complete, err)
+	}
+}
+
+func TestFragmentTooLarge(t *testing.T) {
+	if _, err := fragment(make([]byte, MaxMessage+1)); err != ErrTooLarge {
+		t.Fatalf("fragment error = %v, want ErrTooLarge", err)
+	}
+}
+
+func TestReassembleOrders(t *testing.T) {
+	msg := testMessage(5*fragPayload + 123)
+	datagrams := mustFragment(t, msg)
+	if len(datagrams) != 6 {
+		t.Fatalf("%d fragments, want 6", len(datagrams))
+	}
+
+	for _, tc := range []struct {
+		name  string
+		order []int
+	}{
+		{"in order", []int{0, 1, 2, 3, 4, 5}},
+		{"reversed", []int{5, 4, 3, 2, 1, 0}},
+		{"shuffled", []int{3, 0, 5, 1, 4, 2}},
+		{"duplicates", []int{1, 1, 0, 3, 3, 2, 0, 4, 5}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newReassembler()
+			var got []byte
+			completes := 0
+			for _, i := range tc.order {
+				m, complete, err := r.add("127.0.0.1:5000", datagrams[i])
+				if err != nil {
+					t.Fatal(err)
+				}
+				if complete {
+					got = m
+					completes++
+				}
+			}
+			if completes != 1 || !bytes.Equal(got, msg) {
+				t.Fatalf("%d complete message(s), equal=%v", completes, bytes.Equal(got, msg))
+			}
+			if len(r.pending) != 0 {
+				t.Fatalf("%d partial message(s) left", len(r.pending))
+			}
+		})
+	}
+}
+
+// A duplicate that arrives after the message was delivered starts a new
+// partial message, never a second delivery
+func TestReassembleLateDuplicate(t *testing.T) {
+	datagrams := mustFragment(t, testMessage(2*fragPayload+1))
+	r := newReassembler()
+	for _, d := range datagrams {
+		if _, _, err := r.add("a", d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, complete, err := r.add("a", datagrams[1]); complete || err != nil {
+		t.Fatalf("late duplicate: complete=%v err=%v", complete, err)
+	}
+}
+
+func TestReassembleMissingFragment(t *testing.T) {
+	datagrams := mustFragment(t, testMessage(3*fragPayload))
+	r := newReassembler()
+	for i, d := range datagrams {
+		if i == 1 {
+			continue
+		}
+		if _, complete, err := r.add("a", d); complete || err != nil {
+			t.Fatalf("fragment %d: complete=%v err=%v", i, complete, err)
+		}
+	}
+	if len(r.pending) != 1 {
+		t.Fatalf("%d partial messages, want 1", len(r.pending))
+	}
+}
+
+// Fragments of the same message ID from two senders are two messages
+func TestReassembleBySender(t *testing.T) {
+	msg := testMessage(2 * fragPayload)
+	datagrams := mustFragment(t, msg)
+	r := newReassembler()
+	if _, complete, _ := r.add("a", datagrams[0]); complete {
+		t.Fatal("complete after one fragment")
+	}
+	if _, complete, _ := r.add("b", datagrams[1]); complete {
+		t.Fatal("fragments of two senders joined")
+	}
+	got, complete, err := r.add("a", datagrams[1])
+	if err != nil || !complete || !bytes.Equal(got, msg) {
+		t.Fatalf("message of a: complete=%v err=%v", complete, err)
+	}
+}
+
+// A partial message older than fragmentTTL is dropped: its late fragments do
+// not complete it
+func TestReassembleTimeout(t *testing.T) {
+	datagrams := mustFragment(t, testMessage(2*fragPayload))
+	other := mustFragment(t, testMessage(3*fragPayload))
+	r := newReassembler()
+	if _, _, err := r.add("a", datagrams[0]); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range r.pending {
+		p.started = time.Now().Add(-fragmentTTL - time.Second)
+	}
+
+	// any datagram received purges the expired messages
+	if _, _, err := r.add("a", other[0]); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.pending) != 1 {
+		t.Fatalf("%d partial messages, want 1 (the expired one dropped)", len(r.pending))
+	}
+	if _, complete, err := r.add("a", datagrams[1]); complete || err != nil {
+		t.Fatalf("expired message completed: complete=%v err=%v", complete, err)
+	}
+}
+
+func TestReassembleInvalid(t *testing.T) {
+	datagrams := mustFragment(t, testMessage(2*fragPayload))
+
+	bad := append([]byte(nil), datagrams[0]...)
+	bad[12], bad[13] = 0, 2 // index 2 of 2
+	if _, _, err := newReassembler().add("a", bad); err == nil {
+		t.Fatal("fragment with index beyond the count accepted")
+	}
+
+	zero := append([]byte(nil), datagrams[0]...)
+	zero[14], zero[15] = 0, 0
+	if _, _, err := newReassembler().add("a", zero); err == nil {
+		t.Fatal("fragment with count 0 accepted")
+	}
+
+	// same message ID with another count
+	r := newReassembler()
+	if _, _, err := r.add("a", datagrams[0]); err != nil {
+		t.Fatal(err)
+	}
+	other := append([]byte(nil), datagrams[1]...)
+	other[15] = 3
+	if _, _, err := r.add("a", other); err == nil {
+		t.Fatal("inconsistent fragment count accepted")
+	}
+	if len(r.pending) != 0 {
+		t.Fatal("inconsistent message kept")
+	}
+}
+
+// A message and its response of several fragments through loopback sockets
+func TestUDPLargeExchange(t *testing.T) {
+	ln, err := UDP{}.Listen("127.0.0.1:0", func(w Replier, data []byte) {
+		w.Reply(append([]byte("{\"echo\":"), data...))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	req := testMessage(100 * 1024)
+	resp, err := UDP{}.Exchange(ln.Addr(), req, time.Now().Add(5*time.Second), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := append([]byte("{\"echo\":"), req...); !bytes.Equal(resp, want) {
+		t.Fatalf("response of %d bytes, want %d", len(resp), len(want))
+	}
+}
+
+// Fragments sent out of order and repeated by the network reach the handler
+// as one message
+func TestUDPListenerReassembles(t *testing.T) {
+	got := make(chan []byte, 4)
+	ln, err := UDP{}.Listen("127.0.0.1:0", func(w Replier, data []byte) { got <- data })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	conn, err := net.Dial("udp", ln.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	msg := testMessage(4*fragPayload + 7)
+	datagrams := mustFragment(t, msg)
+	for _, i := range []int{4, 2, 2, 0, 3, 1, 4} {
+		if _, err := conn.Write(datagrams[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	select {
+	case m := <-got:
+		if !bytes.Equal(m, msg) {
+			t.Fatalf("message of %d bytes, want %d", len(m), len(msg))
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("message not delivered")
+	}
+	select {
+	case <-got:
+		t.Fatal("message delivered twice")
+	case <-time.After(200 * time.Millisecond):
+	}
+}
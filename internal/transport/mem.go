@@ -0,0 +1,89 @@
+package transport
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// In-process transport: the listeners are registered by address and the
+// messages go through channels. Every agent of the topology must run in the
+// same process (simulations and tests of a whole court).
+type Mem struct{}
+
+var memNet = struct {
+	mu       sync.RWMutex
+	handlers map[string]Handler
+}{handlers: map[string]Handler{}}
+
+func (Mem) Name() string { return NameMem }
+
+type memListener struct {
+	addr string
+}
+
+type memReplier struct {
+	replies chan []byte
+	remote  string
+}
+
+func (r memReplier) Reply(b []byte) error {
+	if len(b) > MaxMessage {
+		return ErrTooLarge
+	}
+	select {
+	case r.replies <- append([]byte(nil), b...):
+		return nil
+	default:
+		return fmt.Errorf("mem: reply to %s not delivered (requester gone or queue full)", r.remote)
+	}
+}
+
+func (r memReplier) Remote() string { return r.remote }
+
+func (Mem) Listen(addr string, h Handler) (Listener, error) {
+	memNet.mu.Lock()
+	defer memNet.mu.Unlock()
+	if _, ok := memNet.handlers[addr]; ok {
+		return nil, fmt.Errorf("mem: address %s already in use", addr)
+	}
+	memNet.handlers[addr] = h
+	return &memListener{addr: addr}, nil
+}
+
+func (l *memListener) Addr() string { return l.addr }
+
+func (l *memListener) Close() error {
+	memNet.mu.Lock()
+	delete(memNet.handlers, l.addr)
+	memNet.mu.Unlock()
+	return nil
+}
+
+func (Mem) Exchange(addr string, req []byte, deadline time.Time, accept func([]byte) bool) ([]byte, error) {
+	if len(req) > MaxMessage {
+		return nil, ErrTooLarge
+	}
+	memNet.mu.RLock()
+	h, ok := memNet.handlers[addr]
+	memNet.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("mem: nobody listening at %s", addr)
+	}
+
+	w := memReplier{replies: make(chan []byte, 16), remote: "mem:client"}
+	go h(w, append([]byte(nil), req...))
+
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	for {
+		select {
+		case b := <-w.replies:
+			if accept == nil || accept(b) {
+				return b, nil
+			}
+		case <-timer.C:
+			return nil, fmt.Errorf("mem: timeout waiting for the response of %s", addr)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package transport
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// TCP or Unix-domain stream: each message is a frame with a 4-byte big-endian
+// length followed by the JSON. The client opens one connection per exchange;
+// the server reads the frames of a connection in sequence.
+type Stream struct {
+	Network string // "tcp" or "unix" (address is the socket's path)
+}
+
+func (s Stream) Name() string { return s.Network }
+
+func writeFrame(w io.Writer, b []byte) error {
+	if len(b) > MaxMessage {
+		return ErrTooLarge
+	}
+	frame := make([]byte, 4+len(b))
+	binary.BigEndian.PutUint32(frame, uint32(len(b)))
+	copy(frame[4:], b)
+	_, err := w.Write(frame)
+	return err
+}
+
+func readFrame(r io.Reader) ([]byte, error) {
+	var hdr [4]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(hdr[:])
+	if n > MaxMessage {
+		return nil, ErrTooLarge
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+type streamListener struct {
+	ln net.Listener
+}
+
+type streamReplier struct {
+	mu     *sync.Mutex // handlers may answer from several goroutines
+	conn   net.Conn
+	remote string
+}
+
+func (r streamReplier) Reply(b []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return writeFrame(r.conn, b)
+}
+
+func (r streamReplier) Remote() string { return r.remote }
+
+func (s Stream) Listen(addr string, h Handler) (Listener, error) {
+	if s.Network == NameUnix {
+		removeStaleSocket(addr)
+	}
+	ln, err := net.Listen(s.Network, addr)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				if errors.Is(err, net.ErrClosed) {
+					return
+				}
+				log.Printf("[%s] %s - error while accepting at %s: %v", s.Network, time.Now().Format(time.RFC3339), addr, err)
+				continue
+			}
+			go s.serveConn(conn, h)
+		}
+	}()
+	return &streamListener{ln: ln}, nil
+}
+
+func (s Stream) serveConn(conn net.Conn, h Handler) {
+	defer conn.Close()
+
+	remote := conn.RemoteAddr().String()
+	if remote == "" || remote == "@" {
+		// unnamed Unix socket of the client
+		remote = s.Network + ":" + conn.LocalAddr().String()
+	}
+	w := streamReplier{mu: &sync.Mutex{}, conn: conn, remote: remote}
+	for {
+		data, err := readFrame(conn)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				log.Printf("[%s] %s - error while reading from %s: %v", s.Network, time.Now().Format(time.RFC3339), remote, err)
+			}
+			return
+		}
+		h(w, data)
+	}
+}
+
+// A socket file left by an agent that did not finish cleanly blocks the Listen;
+// it is removed only if nobody answers on it
+func removeStaleSocket(path string) {
+	if _, err := os.Stat(path); err != nil {
+		return
+	}
+	if c, err := net.DialTimeout(NameUnix, path, 200*time.Millisecond); err == nil {
+		c.Close()
+		return
+	}
+	_ = os.Remove(path)
+}
+
+func (l *streamListener) Addr() string { return l.ln.Addr().String() }
+
+func (l *streamListener) Close() error { return l.ln.Close() }
+
+func (s Stream) Exchange(addr string, req []byte, deadline time.Time, accept func([]byte) bool) ([]byte, error) {
+	d := net.Dialer{Deadline: deadline}
+	conn, err := d.Dial(s.Network, addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	_ = conn.SetDeadline(deadline)
+	if err := writeFrame(conn, req); err != nil {
+		return nil, err
+	}
+	for {
+		b, err := readFrame(conn)
+		if err != nil {
+			return nil, err
+		}
+		if accept == nil || accept(b) {
+			return b, nil
+		}
+	}
+}
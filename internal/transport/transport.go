@@ -0,0 +1,98 @@
+// Package transport carries the agents' messages with request/response
+// semantics over UDP, TCP, Unix-domain sockets or in-process channels.
+// The messages themselves (JSON with envelope) are defined in package protocol.
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Transport names accepted by the agents' -transport flag
+const (
+	NameUDP  = "udp"
+	NameTCP  = "tcp"
+	NameUnix = "unix"
+	NameMem  = "mem"
+)
+
+// Largest message accepted (UDP is also limited by the datagram size)
+const MaxMessage = 16 << 20
+
+var ErrTooLarge = errors.New("message too large")
+
+// Sends the response(s) of one received message back to its sender
+type Replier interface {
+	Reply(b []byte) error
+	Remote() string // peer address for the logs
+}
+
+// Called for each message received by a listener. Handlers run in the listener's
+// goroutine (one at a time per connection): long work must be started with go.
+type Handler func(w Replier, data []byte)
+
+type Listener interface {
+	Addr() string
+	Close() error
+}
+
+type Transport interface {
+	Name() string
+
+	// Serve the messages arriving at addr until the listener is closed
+	Listen(addr string, h Handler) (Listener, error)
+
+	// Send req to addr and return the first response for which accept is true
+	// (nil accept: the first response); gives up at the deadline
+	Exchange(addr string, req []byte, deadline time.Time, accept func([]byte) bool) ([]byte, error)
+}
+
+// Transport with the given name
+func ByName(name string) (Transport, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", NameUDP:
+		return UDP{}, nil
+	case NameTCP:
+		return Stream{Network: NameTCP}, nil
+	case NameUnix:
+		return Stream{Network: NameUnix}, nil
+	case NameMem:
+		return Mem{}, nil
+	}
+	return nil, fmt.Errorf("unknown transport %q (udp, tcp, unix or mem)", name)
+}
+
+// Transport and bare address for addr. An address with a scheme
+// ("tcp://127.0.0.1:9000", "unix:///tmp/court.sock") chooses its own transport;
+// otherwise def is used.
+func Resolve(def Transport, addr string) (Transport, string, error) {
+	scheme, rest, ok := strings.Cut(addr, "://")
+	if !ok {
+		return def, addr, nil
+	}
+	t, err := ByName(scheme)
+	if err != nil {
+		return nil, "", err
+	}
+	return t, rest, nil
+}
+
+// Listen at addr with def (or the transport of the address' scheme)
+func Listen(def Transport, addr string, h Handler) (Listener, error) {
+	t, bare, err := Resolve(def, addr)
+	if err != nil {
+		return nil, err
+	}
+	return t.Listen(bare, h)
+}
+
+// Exchange with addr using def (or the transport of the address' scheme)
+func Exchange(def Transport, addr string, req []byte, deadline time.Time, accept func([]byte) bool) ([]byte, error) {
+	t, bare, err := Resolve(def, addr)
+	if err != nil {
+		return nil, err
+	}
+	return t.Exchange(bare, req, deadline, accept)
+}
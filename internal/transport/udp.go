@@ -0,0 +1,123 @@
+package transport
+
+import (
+	"errors"
+	"log"
+	"net"
+	"time"
+)
+
+// One JSON datagram per message (or its fragments, see fragment.go);
+// a new socket for each exchange
+type UDP struct{}
+
+// Largest UDP datagram
+const maxDatagram = 65535
+
+// Socket buffers large enough for the fragments of a big response
+const udpSocketBuffer = 4 << 20
+
+func (UDP) Name() string { return NameUDP }
+
+type udpListener struct {
+	conn net.PacketConn
+}
+
+type udpReplier struct {
+	conn net.PacketConn
+	addr net.Addr
+}
+
+func (r udpReplier) Reply(b []byte) error {
+	datagrams, err := fragment(b)
+	if err != nil {
+		return err
+	}
+	for _, d := range datagrams {
+		if _, err := r.conn.WriteTo(d, r.addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r udpReplier) Remote() string { return r.addr.String() }
+
+func (UDP) Listen(addr string, h Handler) (Listener, error) {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if uc, ok := conn.(*net.UDPConn); ok {
+		_ = uc.SetReadBuffer(udpSocketBuffer)
+		_ = uc.SetWriteBuffer(udpSocketBuffer)
+	}
+	go func() {
+		ra := newReassembler()
+		buf := make([]byte, maxDatagram)
+		for {
+			n, from, err := conn.ReadFrom(buf)
+			if err != nil {
+				if errors.Is(err, net.ErrClosed) {
+					return
+				}
+				log.Printf("[UDP] %s - error while reading at %s: %v", time.Now().Format(time.RFC3339), addr, err)
+				continue
+			}
+			data, complete, err := ra.add(from.String(), append([]byte(nil), buf[:n]...))
+			if err != nil {
+				log.Printf("[UDP] %s - %v", time.Now().Format(time.RFC3339), err)
+				continue
+			}
+			if !complete {
+				continue
+			}
+			h(udpReplier{conn: conn, addr: from}, data)
+		}
+	}()
+	return &udpListener{conn: conn}, nil
+}
+
+func (l *udpListener) Addr() string { return l.conn.LocalAddr().String() }
+
+func (l *udpListener) Close() error { return l.conn.Close() }
+
+func (UDP) Exchange(addr string, req []byte, deadline time.Time, accept func([]byte) bool) ([]byte, error) {
+	datagrams, err := fragment(req)
+	if err != nil {
+		return nil, err
+	}
+	raddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.DialUDP("udp", nil, raddr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	_ = conn.SetReadBuffer(udpSocketBuffer)
+	_ = conn.SetWriteBuffer(udpSocketBuffer)
+
+	for _, d := range datagrams {
+		if _, err := conn.Write(d); err != nil {
+			return nil, err
+		}
+	}
+	_ = conn.SetReadDeadline(deadline)
+	ra := newReassembler()
+	buf := make([]byte, maxDatagram)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		msg, complete, err := ra.add(addr, append([]byte(nil), buf[:n]...))
+		if err != nil || !complete {
+			continue
+		}
+		if accept == nil || accept(msg) {
+			return msg, nil
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package trial
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"judiciary/internal/protocol"
+)
+
+// ---------- History of the lawsuits and docket at a past moment ----------
+
+// One change of a lawsuit, with the time it was committed, who made it and why
+// (Event is the type of the journal event). Lawsuits registered before the
+// history was kept have none (only FiledAt, if any).
+type HistoryEntry = protocol.HistoryEntry
+
+// Lists of the trial as they were at At
+type DocketAsOf struct {
+	At time.Time
+
+	// Lawsuits by status (see status.go)
+	ByStatus map[string][]Lawsuit
+
+	// Lawsuits whose filing date or status changes are unknown (registered
+	// before the dates were kept): they are not in the lists
+	Undated int
+}
+
+// Reconstructs the lists as of at from the history of each lawsuit: a lawsuit
+// filed after at is left out, its status is the one it had at at, and the
+// claims merged and connections made after at are undone. Each list comes in
+// the order the lawsuits entered it.
+func (ts *TrialStore) DocketAt(at time.Time) DocketAsOf {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+
+	res := DocketAsOf{At: at, ByStatus: map[string][]Lawsuit{}}
+	lists := map[string][]pastLawsuit{}
+	for _, a := range ts.state.Lawsuits {
+		past, known := lawsuitAt(a, at)
+		if !known {
+			res.Undated++
+			continue
+		}
+		if past != nil {
+			lists[past.lawsuit.Status] = append(lists[past.lawsuit.Status], *past)
+		}
+	}
+	for st, past := range lists {
+		res.ByStatus[st] = inEntryOrder(past)
+	}
+	return res
+}
+
+type pastLawsuit struct {
+	lawsuit Lawsuit
+	since   time.Time // entered the list
+}
+
+// The lawsuit as it was at at; nil if it was not filed yet (or, transferred
+// from another trial, not here yet). known is false when the dates needed are
+// not in its history.
+func lawsuitAt(a Lawsuit, at time.Time) (*pastLawsuit, bool) {
+	filed := a.FiledAt
+	for _, h := range a.History {
+		if h.Event == evCreated {
+			filed = h.At
+			break
+		}
+	}
+	for _, h := range a.History {
+		if h.Event == evImported {
+			filed = h.At
+		}
+	}
+	if filed.IsZero() {
+		return nil, false
+	}
+	if filed.After(at) {
+		return nil, true
+	}
+
+	// status at at: changes of the history up to at
+	past := &pastLawsuit{lawsuit: a, since: filed}
+	status, changes := StatusActive, 0
+	for _, h := range a.History {
+		var to string
+		switch h.Event {
+		case evDismissed:
+			to = StatusDisWithMerit
+			if h.List == dismissedWithoutMerit {
+				to = StatusDisWithoutMerit
+			}
+		case evStatus:
+			to = h.Status
+		case evTransferred:
+			to = StatusTransferred
+		default:
+			continue
+		}
+		changes++
+		if !h.At.After(at) {
+			status = to
+			if h.At.After(past.since) {
+				past.since = h.At
+			}
+		}
+	}
+	if changes == 0 && a.Status != StatusActive {
+		// status changed before the history was kept
+		return nil, false
+	}
+	past.lawsuit.Status, past.lawsuit.StatusAt = status, past.since
+
+	// undo the changes after at
+	p := &past.lawsuit
+	p.Claims = append([]int(nil), a.Claims...)
+	p.Connected = append([]string(nil), a.Connected...)
+	p.History = nil
+	for _, h := range a.History {
+		if !h.At.After(at) {
+			p.History = append(p.History, h)
+		}
+	}
+	// from the last change back: a connection made after at may have been
+	// moved to the new ID of a transferred lawsuit later
+	for i := len(a.History) - 1; i >= 0; i-- {
+		h := a.History[i]
+		if !h.At.After(at) {
+			continue
+		}
+		switch h.Event {
+		case evClaimsMerged:
+			p.Claims = without(p.Claims, h.Claims)
+		case evConnected:
+			p.Connected = without(p.Connected, []string{h.Other})
+		case histConnectionMoved:
+			p.Connected = without(p.Connected, []string{h.Other})
+			if !hasStr(p.Connected, h.From) {
+				p.Connected = append(p.Connected, h.From)
+			}
+		}
+	}
+	return past, true
+}
+
+func without[T comparable](slice []T, vals []T) []T {
+	res := slice[:0]
+	for _, x := range slice {
+		drop := false
+		for _, v := range vals {
+			if x == v {
+				drop = true
+				break
+			}
+		}
+		if !drop {
+			res = append(res, x)
+		}
+	}
+	return res
+}
+
+func inEntryOrder(past []pastLawsuit) []Lawsuit {
+	sort.SliceStable(past, func(i, j int) bool { return past[i].since.Before(past[j].since) })
+	res := make([]Lawsuit, len(past))
+	for i, p := range past {
+		res[i] = p.lawsuit
+	}
+	return res
+}
+
+// Moment typed in the menu: RFC 3339, "YYYY-MM-DD HH:MM[:SS]" (local time) or
+// "YYYY-MM-DD" (the end of that day)
+func parseMoment(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date/time %q (use YYYY-MM-DD, YYYY-MM-DD HH:MM or RFC 3339)", s)
+}
@@ -0,0 +1,279 @@
+package trial
+
+import (
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+)
+
+var historyStart = time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+// n hours after historyStart
+func hour(n int) time.Time { return historyStart.Add(time.Duration(n) * time.Hour) }
+
+// Lawsuits of a trial with a fixed history:
+//
+//	h0 1.2.4 registered before the history was kept (FiledAt only)
+//	h1 1.2.1 created with claim 1
+//	h2 1.2.2 created
+//	h3 claims 2 and 3 merged into 1.2.1
+//	h4 1.2.1 and 1.2.2 connected
+//	h5 1.2.1 suspended
+//	h6 1.2.2 dismissed without merit (record of the previous versions)
+//	h7 1.2.5 transferred in (created at h0 in another trial)
+//
+// 1.2.3 has no dates and 1.2.6 a status changed before the history was kept:
+// both are undated (1.2.6 once filed).
+func historyTrial(t *testing.T) *TrialStore {
+	ts, _ := newTestTrial(t)
+	ts.state.Lawsuits = []Lawsuit{
+		{ID: "1.2.4", Claims: []int{9}, FiledAt: hour(0), Status: StatusActive},
+		{
+			ID: "1.2.1", Claims: []int{1, 2, 3}, Connected: []string{"1.2.2"},
+			Status: StatusSuspended, StatusAt: hour(5),
+			History: []HistoryEntry{
+				{At: hour(1), Event: evCreated, Claims: []int{1}},
+				{At: hour(3), Event: evClaimsMerged, Claims: []int{2, 3}},
+				{At: hour(4), Event: evConnected, Other: "1.2.2"},
+				{At: hour(5), Event: evStatus, Status: StatusSuspended},
+			},
+		},
+		{
+			ID: "1.2.2", Claims: []int{4}, Connected: []string{"1.2.1"},
+			Status: StatusDisWithoutMerit, StatusAt: hour(6),
+			History: []HistoryEntry{
+				{At: hour(2), Event: evCreated, Claims: []int{4}},
+				{At: hour(4), Event: evConnected, Other: "1.2.1"},
+				{At: hour(6), Event: evDismissed, List: dismissedWithoutMerit},
+			},
+		},
+		{ID: "1.2.3", Claims: []int{5}, Status: StatusActive},
+		{
+			ID: "1.2.5", Claims: []int{6}, FiledAt: hour(0), Status: StatusActive,
+			TransferredFrom: "1.1.8",
+			History: []HistoryEntry{
+				{At: hour(0), Event: evCreated, Claims: []int{6}},
+				{At: hour(7), Event: evImported, From: "1.1.8"},
+			},
+		},
+		{ID: "1.2.6", Claims: []int{7}, FiledAt: hour(0), Status: StatusDisWithMerit},
+	}
+	return ts
+}
+
+func TestDocketAt(t *testing.T) {
+	ts := historyTrial(t)
+
+	for _, tc := range []struct {
+		name  string
+		at    time.Time
+		lists map[string][]string
+		// 1.2.1 and 1.2.2 at that moment (nil: not checked)
+		claims1, connected1, connected2 []string
+	}{
+		{
+			name:  "before everything",
+			at:    hour(0).Add(-time.Minute),
+			lists: map[string][]string{},
+		},
+		{
+			name:       "at the creation",
+			at:         hour(1),
+			lists:      map[string][]string{StatusActive: {"1.2.4", "1.2.1"}},
+			claims1:    []string{"1"},
+			connected1: []string{},
+		},
+		{
+			name:       "before the merge",
+			at:         hour(3).Add(-time.Second),
+			lists:      map[string][]string{StatusActive: {"1.2.4", "1.2.1", "1.2.2"}},
+			claims1:    []string{"1"},
+			connected1: []string{},
+			connected2: []string{},
+		},
+		{
+			name:       "between the merge and the connection",
+			at:         hour(3).Add(30 * time.Minute),
+			lists:      map[string][]string{StatusActive: {"1.2.4", "1.2.1", "1.2.2"}},
+			claims1:    []string{"1", "2", "3"},
+			connected1: []string{},
+			connected2: []string{},
+		},
+		{
+			name:       "after the connection",
+			at:         hour(4),
+			lists:      map[string][]string{StatusActive: {"1.2.4", "1.2.1", "1.2.2"}},
+			claims1:    []string{"1", "2", "3"},
+			connected1: []string{"1.2.2"},
+			connected2: []string{"1.2.1"},
+		},
+		{
+			name: "suspended",
+			at:   hour(5),
+			lists: map[string][]string{
+				StatusActive:    {"1.2.4", "1.2.2"},
+				StatusSuspended: {"1.2.1"},
+			},
+			claims1: []string{"1", "2", "3"},
+		},
+		{
+			name: "before the transfer in",
+			at:   hour(7).Add(-time.Second),
+			lists: map[string][]string{
+				StatusActive:          {"1.2.4"},
+				StatusSuspended:       {"1.2.1"},
+				StatusDisWithoutMerit: {"1.2.2"},
+			},
+		},
+		{
+			name: "after everything",
+			at:   hour(8),
+			lists: map[string][]string{
+				StatusActive:          {"1.2.4", "1.2.5"},
+				StatusSuspended:       {"1.2.1"},
+				StatusDisWithoutMerit: {"1.2.2"},
+			},
+			claims1:    []string{"1", "2", "3"},
+			connected1: []string{"1.2.2"},
+			connected2: []string{"1.2.1"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d := ts.DocketAt(tc.at)
+			undated := 2 // 1.2.3 and 1.2.6
+			if tc.at.Before(hour(0)) {
+				undated = 1 // 1.2.6 not filed yet
+			}
+			if d.Undated != undated {
+				t.Errorf("Undated = %d, want %d", d.Undated, undated)
+			}
+			got := map[string][]string{}
+			past := map[string]Lawsuit{}
+			for st, lawsuits := range d.ByStatus {
+				got[st] = lawsuitIDs(lawsuits)
+				for _, a := range lawsuits {
+					past[a.ID] = a
+				}
+			}
+			if len(got) != len(tc.lists) {
+				t.Errorf("lists %v, want %v", got, tc.lists)
+			}
+			for st, ids := range tc.lists {
+				if !sameStrs(got[st], ids) {
+					t.Errorf("%s = %v, want %v", st, got[st], ids)
+				}
+			}
+
+			if tc.claims1 != nil {
+				if claims := intStrs(past["1.2.1"].Claims); !sameStrs(claims, tc.claims1) {
+					t.Errorf("claims of 1.2.1 = %v, want %v", claims, tc.claims1)
+				}
+			}
+			if tc.connected1 != nil && !sameStrs(past["1.2.1"].Connected, tc.connected1) {
+				t.Errorf("1.2.1 connected to %v, want %v", past["1.2.1"].Connected, tc.connected1)
+			}
+			if tc.connected2 != nil && !sameStrs(past["1.2.2"].Connected, tc.connected2) {
+				t.Errorf("1.2.2 connected to %v, want %v", past["1.2.2"].Connected, tc.connected2)
+			}
+			for id, a := range past {
+				for _, h := range a.History {
+					if h.At.After(tc.at) {
+						t.Errorf("%s keeps the change %s of after the moment", id, h.Event)
+					}
+				}
+			}
+		})
+	}
+
+	// the lawsuits of the trial are not changed by the reconstruction
+	a := ts.state.Lawsuits[1]
+	if !sameIntSet(a.Claims, []int{1, 2, 3}) || !sameStrs(a.Connected, []string{"1.2.2"}) || len(a.History) != 4 {
+		t.Fatalf("1.2.1 changed by DocketAt: %+v", a)
+	}
+}
+
+func TestLawsuitAt(t *testing.T) {
+	ts := historyTrial(t)
+	byID := map[string]Lawsuit{}
+	for _, a := range ts.state.Lawsuits {
+		byID[a.ID] = a
+	}
+
+	for _, tc := range []struct {
+		id     string
+		at     time.Time
+		here   bool
+		known  bool
+		status string
+		since  time.Time
+	}{
+		{"1.2.3", hour(8), false, false, "", time.Time{}},
+		{"1.2.6", hour(8), false, false, "", time.Time{}},
+		{"1.2.4", hour(0), true, true, StatusActive, hour(0)},
+		{"1.2.1", hour(1).Add(-time.Second), false, true, "", time.Time{}},
+		{"1.2.1", hour(5).Add(-time.Second), true, true, StatusActive, hour(1)},
+		{"1.2.1", hour(5), true, true, StatusSuspended, hour(5)},
+		{"1.2.2", hour(6), true, true, StatusDisWithoutMerit, hour(6)},
+		// the transfer in is when the lawsuit came here, not its creation in the other trial
+		{"1.2.5", hour(1), false, true, "", time.Time{}},
+		{"1.2.5", hour(7), true, true, StatusActive, hour(7)},
+	} {
+		past, known := lawsuitAt(byID[tc.id], tc.at)
+		if known != tc.known || (past != nil) != tc.here {
+			t.Errorf("%s at h%v: here=%v known=%v, want %v %v", tc.id, tc.at.Sub(historyStart).Hours(), past != nil, known, tc.here, tc.known)
+			continue
+		}
+		if past == nil {
+			continue
+		}
+		if past.lawsuit.Status != tc.status || !past.since.Equal(tc.since) || !past.lawsuit.StatusAt.Equal(tc.since) {
+			t.Errorf("%s at h%v: %s since %v, want %s since %v", tc.id, tc.at.Sub(historyStart).Hours(),
+				past.lawsuit.Status, past.since, tc.status, tc.since)
+		}
+	}
+}
+
+// A connection moved to the new ID of a transferred lawsuit is undone back to
+// the old ID, and the changes after at are undone from the last one back
+func TestLawsuitAtConnectionMoved(t *testing.T) {
+	a := Lawsuit{
+		ID: "1.2.1", Claims: []int{1}, Connected: []string{"1.3.1", "1.2.8"}, Status: StatusActive,
+		History: []HistoryEntry{
+			{At: hour(0), Event: evCreated},
+			{At: hour(2), Event: evConnected, Other: "1.2.7"},
+			{At: hour(3), Event: histConnectionMoved, From: "1.2.7", Other: "1.3.1"},
+			{At: hour(4), Event: evConnected, Other: "1.2.8"},
+		},
+	}
+	for _, tc := range []struct {
+		at        time.Time
+		connected []string
+	}{
+		{hour(1), []string{}},
+		{hour(2), []string{"1.2.7"}},
+		{hour(3), []string{"1.3.1"}},
+		{hour(4), []string{"1.3.1", "1.2.8"}},
+	} {
+		past, known := lawsuitAt(a, tc.at)
+		if !known || past == nil {
+			t.Fatalf("h%v: here=%v known=%v", tc.at.Sub(historyStart).Hours(), past != nil, known)
+		}
+		if !sameStrs(past.lawsuit.Connected, tc.connected) {
+			t.Errorf("h%v: connected to %v, want %v", tc.at.Sub(historyStart).Hours(), past.lawsuit.Connected, tc.connected)
+		}
+	}
+	if !sameStrs(a.Connected, []string{"1.3.1", "1.2.8"}) {
+		t.Fatalf("lawsuit changed by lawsuitAt: %v", a.Connected)
+	}
+}
+
+func intStrs(ints []int) []string {
+	sorted := append([]int(nil), ints...)
+	sort.Ints(sorted)
+	res := make([]string, len(sorted))
+	for i, n := range sorted {
+		res[i] = strconv.Itoa(n)
+	}
+	return res
+}
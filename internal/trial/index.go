@@ -0,0 +1,140 @@
+package trial
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ---------- In-memory indexes of the TrialStore ----------
+
+// Set of lawsuit IDs
+type idSet map[string]struct{}
+
+// Indexes rebuilt on Load and kept by every method that changes the lists
+// (ts.mu protects them as it protects the lists)
+type trialIndex struct {
+	byID     map[string]int   // position in state.Lawsuits
+	byStatus map[string]idSet // the lists
+	byParty  map[string]idSet // folded plaintiff or defendant -> lawsuits
+	byCause  map[int]idSet
+	byClaim  map[int]idSet
+	byFormer map[string]int // TransferredFrom (ID in the previous trial) -> position in state.Lawsuits
+}
+
+func newTrialIndex() *trialIndex {
+	return &trialIndex{
+		byID:     map[string]int{},
+		byStatus: map[string]idSet{},
+		byParty:  map[string]idSet{},
+		byCause:  map[int]idSet{},
+		byClaim:  map[int]idSet{},
+		byFormer: map[string]int{},
+	}
+}
+
+// Key of a party's name: equal for names equal by strings.EqualFold
+func foldKey(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		min := r
+		for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+			if f < min {
+				min = f
+			}
+		}
+		b.WriteRune(min)
+	}
+	return b.String()
+}
+
+func addTo[K comparable](m map[K]idSet, k K, id string) {
+	s, ok := m[k]
+	if !ok {
+		s = idSet{}
+		m[k] = s
+	}
+	s[id] = struct{}{}
+}
+
+func (ix *trialIndex) add(a Lawsuit, idx int) {
+	ix.byID[a.ID] = idx
+	addTo(ix.byStatus, a.Status, a.ID)
+	addTo(ix.byParty, foldKey(a.Plaintiff), a.ID)
+	addTo(ix.byParty, foldKey(a.Defendant), a.ID)
+	addTo(ix.byCause, a.CauseAction, a.ID)
+	for _, c := range a.Claims {
+		addTo(ix.byClaim, c, a.ID)
+	}
+	if _, ok := ix.byFormer[a.TransferredFrom]; a.TransferredFrom != "" && !ok {
+		ix.byFormer[a.TransferredFrom] = idx
+	}
+}
+
+// Rebuild every index from the lawsuits (ts.mu must be locked)
+func (ts *TrialStore) reindexLocked() {
+	ix := newTrialIndex()
+	for i, a := range ts.state.Lawsuits {
+		ix.add(a, i)
+	}
+	ts.index = ix
+}
+
+// Lawsuit by ID (ts.mu must be locked)
+func (ts *TrialStore) lookupLocked(id string) (*Lawsuit, bool) {
+	idx, ok := ts.index.byID[id]
+	if !ok {
+		return nil, false
+	}
+	return &ts.state.Lawsuits[idx], true
+}
+
+// Lawsuits of the IDs with one of the statuses, in filing order (ts.mu must be locked)
+func (ts *TrialStore) collectLocked(ids idSet, statuses ...string) []Lawsuit {
+	refs := make([]int, 0, len(ids))
+	for id := range ids {
+		if idx, ok := ts.index.byID[id]; ok && hasStr(statuses, ts.state.Lawsuits[idx].Status) {
+			refs = append(refs, idx)
+		}
+	}
+	sort.Ints(refs)
+
+	res := make([]Lawsuit, len(refs))
+	for i, idx := range refs {
+		res[i] = ts.state.Lawsuits[idx]
+	}
+	return res
+}
+
+// The lawsuits with one of the statuses (the lists), in filing order (ts.mu must be locked)
+func (ts *TrialStore) viewLocked(statuses ...string) []Lawsuit {
+	if len(statuses) == 1 {
+		return ts.collectLocked(ts.index.byStatus[statuses[0]], statuses...)
+	}
+	ids := idSet{}
+	for _, st := range statuses {
+		for id := range ts.index.byStatus[st] {
+			ids[id] = struct{}{}
+		}
+	}
+	return ts.collectLocked(ids, statuses...)
+}
+
+// Smallest of the candidate sets (lawsuits with the same plaintiff, defendant and cause
+// are in all of them)
+func smallest(sets ...idSet) idSet {
+	var min idSet
+	for i, s := range sets {
+		if i == 0 || len(s) < len(min) {
+			min = s
+		}
+	}
+	return min
+}
+
+// Candidates with the same parties and cause of the query (superset; the callers
+// check the fields)
+func (ts *TrialStore) samePartiesLocked(plaintiff, defendant string, cause int) idSet {
+	return smallest(ts.index.byParty[foldKey(plaintiff)], ts.index.byParty[foldKey(defendant)], ts.index.byCause[cause])
+}
@@ -0,0 +1,166 @@
+package trial
+
+import (
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"judiciary/internal/protocol"
+)
+
+// Benchmarks of the matchers in a trial with 100k lawsuits: "indexed" is the
+// store's path, "scan" the loop over the whole lists (as before the indexes).
+//
+//	go test -run xxx -bench . ./internal/trial
+
+const benchLawsuits = 100000
+
+var (
+	benchOnce  sync.Once
+	benchStore *TrialStore
+)
+
+func benchTrial(b *testing.B) *TrialStore {
+	benchOnce.Do(func() {
+		ts := NewTrialStore(filepath.Join(b.TempDir(), "trial.json"), nil)
+		r := rand.New(rand.NewSource(1))
+		ts.mu.Lock()
+		for i := 0; i < benchLawsuits; i++ {
+			claims := []int{1 + r.Intn(2000)}
+			for n := r.Intn(3); n > 0; n-- {
+				claims = append(claims, 1+r.Intn(2000))
+			}
+			ts.createLocked(fmt.Sprintf("Plaintiff %d", r.Intn(20000)), fmt.Sprintf("Defendant %d", r.Intn(500)), 1+r.Intn(500), claims, nil, protocol.Audit{})
+		}
+		// a quarter dismissed, half of them with merit (Load rebuilds the indexes the same way)
+		for i := range ts.state.Lawsuits {
+			switch i % 8 {
+			case 0:
+				ts.state.Lawsuits[i].Status = StatusDisWithMerit
+			case 4:
+				ts.state.Lawsuits[i].Status = StatusDisWithoutMerit
+			}
+		}
+		ts.reindexLocked()
+		ts.mu.Unlock()
+		benchStore = ts
+	})
+	return benchStore
+}
+
+// Query with the parties, cause and claims of an existent lawsuit
+func benchQuery(ts *TrialStore, i int) protocol.ActionQuery {
+	a := ts.state.Lawsuits[i%len(ts.state.Lawsuits)]
+	return protocol.ActionQuery{Plaintiff: a.Plaintiff, Defendant: a.Defendant, CauseID: a.CauseAction, Claims: a.Claims}
+}
+
+func scanList(lawsuits []Lawsuit, status string, match func(a Lawsuit) bool) []Lawsuit {
+	var found []Lawsuit
+	for _, a := range lawsuits {
+		if a.Status == status && match(a) {
+			found = append(found, a)
+		}
+	}
+	return found
+}
+
+func sameParties(a Lawsuit, q protocol.ActionQuery) bool {
+	return strings.EqualFold(a.Plaintiff, q.Plaintiff) && strings.EqualFold(a.Defendant, q.Defendant) && a.CauseAction == q.CauseID
+}
+
+func BenchmarkFindIdentical(b *testing.B) {
+	ts := benchTrial(b)
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+
+	b.Run("indexed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			q := benchQuery(ts, i)
+			ts.findIdenticalDwM("dis_with", q)
+			ts.findIdenticalDwM("actives", q)
+		}
+	})
+	b.Run("scan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			q := benchQuery(ts, i)
+			match := func(a Lawsuit) bool { return sameParties(a, q) && sameIntSet(a.Claims, q.Claims) }
+			scanList(ts.state.Lawsuits, StatusDisWithMerit, match)
+			scanList(ts.state.Lawsuits, StatusActive, match)
+		}
+	})
+}
+
+func BenchmarkFindJoinder(b *testing.B) {
+	ts := benchTrial(b)
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+
+	b.Run("indexed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ts.findJoinder(benchQuery(ts, i))
+		}
+	})
+	b.Run("scan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			q := benchQuery(ts, i)
+			scanList(ts.state.Lawsuits, StatusActive, func(a Lawsuit) bool {
+				return sameParties(a, q) && !sameIntSet(a.Claims, q.Claims) && (isSubset(q.Claims, a.Claims) || isSubset(a.Claims, q.Claims))
+			})
+		}
+	})
+}
+
+func BenchmarkFindConnection(b *testing.B) {
+	ts := benchTrial(b)
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+
+	b.Run("indexed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ts.findConnection(benchQuery(ts, i))
+		}
+	})
+	b.Run("scan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			q := benchQuery(ts, i)
+			scanList(ts.state.Lawsuits, StatusActive, func(a Lawsuit) bool {
+				return !sameParties(a, q) && (a.CauseAction == q.CauseID || hasOverlap(a.Claims, q.Claims))
+			})
+		}
+	})
+}
+
+func BenchmarkSearchLawsuits(b *testing.B) {
+	ts := benchTrial(b)
+	for _, s := range []struct{ field, value string }{
+		{"id", "0.0.50000"},
+		{"cause", "250"},
+		{"claim", "1000"},
+		{"defendant", "defendant 49"},
+	} {
+		b.Run(s.field, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := ts.SearchLawsuits(s.field, s.value); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkDismiss(b *testing.B) {
+	ts := NewTrialStore(filepath.Join(b.TempDir(), "trial.json"), nil)
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	for i := 0; i < benchLawsuits; i++ {
+		ts.createLocked("Plaintiff", "Defendant", 1+i%500, []int{1 + i%2000}, nil, protocol.Audit{})
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N && i < len(ts.state.Lawsuits); i++ {
+		ts.applyLocked(journalEvent{Type: evStatus, ID: ts.state.Lawsuits[i].ID, Status: StatusDisWithMerit})
+	}
+}
@@ -0,0 +1,377 @@
+package trial
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"judiciary/internal/persist"
+	"judiciary/internal/protocol"
+	"judiciary/internal/storage"
+)
+
+// ---------- Journal of the TrialStore ----------
+
+// Every change of the lists is an event, applied in memory and saved when the
+// operation commits, by the layout of the storage:
+//   - jsonLayout (-storage json): the commit is one fsynced line appended to the
+//     journal (lawsuits.journal, next to lawsuits.json). lawsuits.json is the
+//     snapshot: it is rewritten every journalSnapshotEvery commits (and when the
+//     trial quits), with the sequence of the last commit it contains, and the
+//     journal is emptied. Load replays onto the snapshot the commits after that
+//     sequence.
+//   - recordsLayout (-storage kv, see records.go): the commit is one transaction
+//     that rewrites the records of the lawsuits changed.
+
+const journalSnapshotEvery = 1000
+
+// Key of the trial's state in the storage (json: the lawsuits.json file)
+const keyLawsuits = "lawsuits"
+
+// Types of the events
+const (
+	evCreated      = "created"
+	evClaimsMerged = "claims_merged"
+	evConnected    = "connected"
+	evDismissed    = "dismissed" // previous versions (now status_changed)
+	evStatus       = "status_changed"
+	evTransferred  = "transferred_out"
+	evImported     = "transferred_in"
+	evRequest      = "request_answered"
+)
+
+// Lists of the "dismissed" event
+const (
+	dismissedWithMerit    = "with_merit"
+	dismissedWithoutMerit = "without_merit"
+)
+
+type journalEvent struct {
+	Type string    `json:"type"`
+	At   time.Time `json:"at,omitzero"` // kept in the lawsuit's history (see history.go)
+
+	// created, transferred_in
+	Lawsuit *Lawsuit `json:"lawsuit,omitempty"`
+	NextSeq int      `json:"next_seq,omitempty"`
+
+	// claims_merged, connected, dismissed, status_changed, transferred_out (Other: the new ID)
+	ID     string `json:"id,omitempty"`
+	Claims []int  `json:"claims,omitempty"`
+	Other  string `json:"other,omitempty"`
+	List   string `json:"list,omitempty"`
+	Status string `json:"status,omitempty"`
+
+	// transferred_in, transferred_out: lawsuits connected to the old ID
+	Relinked []string `json:"relinked,omitempty"`
+
+	// request_answered
+	Request *RequestRecord `json:"request,omitempty"`
+
+	// who made the change and why (kept in the lawsuit's history)
+	protocol.Audit
+}
+
+// One line of the journal: the events of one operation
+type journalCommit struct {
+	Seq    int64          `json:"seq"`
+	At     time.Time      `json:"at"`
+	Events []journalEvent `json:"events"`
+}
+
+// Where the TrialStore keeps its state (methods called with ts.mu locked)
+type trialLayout interface {
+	load(ts *TrialStore) error
+	// Save the events of one commit (already applied to ts.state)
+	commit(ts *TrialStore, c journalCommit) error
+	// Save the whole state (trial quitting, new IDs / address)
+	snapshot(ts *TrialStore) error
+}
+
+// Apply the event and keep it for the next commit (ts.mu must be locked)
+func (ts *TrialStore) recordLocked(ev journalEvent) {
+	if ts.failed != nil {
+		// no change is accepted (see commitLocked)
+		return
+	}
+	if ev.At.IsZero() {
+		ev.At = time.Now().UTC()
+	}
+	ts.applyLocked(ev)
+	ts.pending = append(ts.pending, ev)
+}
+
+// Save the pending events as one commit (ts.mu must be locked). If the commit
+// fails, its events are undone: the state is read again from the storage, where
+// the commit is not. If that fails too, memory and storage may differ and the
+// store refuses every change until the trial is restarted.
+func (ts *TrialStore) commitLocked() error {
+	if ts.failed != nil {
+		ts.pending = nil
+		return ts.failed
+	}
+	if len(ts.pending) == 0 {
+		return nil
+	}
+	c := journalCommit{Seq: ts.state.JournalSeq + 1, At: time.Now().UTC(), Events: ts.pending}
+	ts.pending = nil
+	ts.state.JournalSeq = c.Seq
+	if err := ts.layout.commit(ts, c); err != nil {
+		ts.undoLocked(fmt.Errorf("commit %d: %w", c.Seq, err))
+		return err
+	}
+	return nil
+}
+
+// Drop the changes not committed, reading the state again from the storage;
+// if that fails, no change is accepted any more (ts.mu must be locked)
+func (ts *TrialStore) undoLocked(cause error) {
+	if err := ts.reloadLocked(); err != nil {
+		ts.failed = fmt.Errorf("the trial refuses changes after a failed commit (restart it): %v", cause)
+		log.Printf("[TRIAL] %v; the state was not read again: %v", cause, err)
+		return
+	}
+	log.Printf("[TRIAL] %v: changes undone", cause)
+}
+
+// State read again from the storage, dropping the changes not committed
+// (ts.mu must be locked)
+func (ts *TrialStore) reloadLocked() error {
+	ts.pending = nil
+	ts.setStateLocked(TrialState{
+		DistrictID:   ts.state.DistrictID,
+		DistrictName: ts.state.DistrictName,
+		TrialID:      ts.state.TrialID,
+		TrialAddr:    ts.state.TrialAddr,
+	})
+	return ts.layout.load(ts)
+}
+
+// Save the whole state (ts.mu must be locked)
+func (ts *TrialStore) snapshotLocked() error {
+	return ts.layout.snapshot(ts)
+}
+
+// ---------- JSON file + journal ----------
+
+type jsonLayout struct {
+	store     storage.Backend
+	journal   *persist.Journal
+	journaled int // commits in the journal
+}
+
+// lawsuits.json -> lawsuits.journal
+func journalPath(filePath string) string {
+	return strings.TrimSuffix(filePath, filepath.Ext(filePath)) + ".journal"
+}
+
+func (l *jsonLayout) openJournal(ts *TrialStore) error {
+	if l.journal != nil {
+		return nil
+	}
+	j, err := persist.OpenJournal(journalPath(ts.filePath))
+	if err != nil {
+		return err
+	}
+	l.journal = j
+	return nil
+}
+
+func (l *jsonLayout) load(ts *TrialStore) error {
+	var st TrialState
+	found, err := l.store.Get(keyLawsuits, &st)
+	if err != nil {
+		return err
+	}
+	if found {
+		ts.setStateLocked(st)
+	} else {
+		ts.reindexLocked()
+	}
+
+	// Changes after the snapshot (a lawsuits.json without journal is loaded as is)
+	if err := l.openJournal(ts); err != nil {
+		return err
+	}
+	replayed := 0
+	n, err := l.journal.Replay(func(record []byte) error {
+		var c journalCommit
+		if err := json.Unmarshal(record, &c); err != nil {
+			return err
+		}
+		if c.Seq <= ts.state.JournalSeq {
+			// already in the snapshot (fault between the snapshot and the reset)
+			return nil
+		}
+		for _, ev := range c.Events {
+			if ev.At.IsZero() {
+				// journals of previous versions: the time of the commit
+				ev.At = c.At
+			}
+			ts.applyLocked(ev)
+		}
+		ts.state.JournalSeq = c.Seq
+		replayed++
+		return nil
+	})
+	l.journaled = n
+	if replayed > 0 {
+		log.Printf("[TRIAL] %d commit(s) of %s replayed onto the snapshot", replayed, l.journal.Path())
+	}
+	return err
+}
+
+// Append the commit to the journal; every journalSnapshotEvery commits, a snapshot
+func (l *jsonLayout) commit(ts *TrialStore, c journalCommit) error {
+	if err := l.openJournal(ts); err != nil {
+		return err
+	}
+	if err := l.journal.Append(c); err != nil {
+		return err
+	}
+	l.journaled++
+
+	if l.journaled >= journalSnapshotEvery {
+		// the commit is already safe in the journal: a failed snapshot is only logged
+		if err := l.snapshot(ts); err != nil {
+			log.Printf("[TRIAL] error while saving the snapshot %s: %v", ts.filePath, err)
+		}
+	}
+	return nil
+}
+
+// Save the whole state in lawsuits.json and empty the journal
+func (l *jsonLayout) snapshot(ts *TrialStore) error {
+	err := l.store.Update(func(tx storage.Tx) error {
+		return tx.Put(keyLawsuits, ts.state)
+	})
+	if err != nil {
+		return err
+	}
+	// pending events are in the state just saved
+	ts.pending = nil
+	l.journaled = 0
+	if l.journal != nil {
+		return l.journal.Reset()
+	}
+	return nil
+}
+
+// The change of one event (same code for the operation and the replay)
+func (ts *TrialStore) applyLocked(ev journalEvent) {
+	switch ev.Type {
+	case evCreated:
+		if ev.Lawsuit == nil {
+			return
+		}
+		a := *ev.Lawsuit
+		a.Status, a.StatusAt = StatusActive, ev.At
+		a.History = append(a.History[:len(a.History):len(a.History)], HistoryEntry{At: ev.At, Event: evCreated, Audit: ev.Audit})
+		ts.state.Lawsuits = append(ts.state.Lawsuits, a)
+		ts.index.add(a, len(ts.state.Lawsuits)-1)
+		if ev.NextSeq > ts.state.NextSeq {
+			ts.state.NextSeq = ev.NextSeq
+		}
+
+	case evClaimsMerged:
+		a, ok := ts.lookupLocked(ev.ID)
+		if !ok || a.Status != StatusActive {
+			return
+		}
+		var merged []int
+		for _, p := range ev.Claims {
+			if !hasInt(a.Claims, p) {
+				a.Claims = append(a.Claims, p)
+				merged = append(merged, p)
+			}
+			addTo(ts.index.byClaim, p, ev.ID)
+		}
+		if len(merged) > 0 {
+			a.History = append(a.History, HistoryEntry{At: ev.At, Event: evClaimsMerged, Claims: merged, Audit: ev.Audit})
+		}
+
+	case evConnected:
+		a, ok := ts.lookupLocked(ev.ID)
+		if !ok || a.Status != StatusActive {
+			return
+		}
+		ts.linkLocked(a, ev.Other, ev)
+		// if the other is not here yet, connect only one end
+		if b, ok := ts.lookupLocked(ev.Other); ok && b.Status == StatusActive {
+			ts.linkLocked(b, ev.ID, ev)
+		}
+
+	case evDismissed:
+		// journals of previous versions: active -> dismissed
+		ev.Type, ev.Status = evStatus, StatusDisWithMerit
+		if ev.List == dismissedWithoutMerit {
+			ev.Status = StatusDisWithoutMerit
+		}
+		ts.applyLocked(ev)
+
+	case evStatus:
+		a, ok := ts.lookupLocked(ev.ID)
+		if !ok || !canTransition(a.Status, ev.Status) {
+			return
+		}
+		from := a.Status
+		ts.setStatusLocked(a, ev.Status, ev.At)
+		a.History = append(a.History, HistoryEntry{At: ev.At, Event: evStatus, From: from, Status: ev.Status, Audit: ev.Audit})
+
+	case evImported:
+		if ev.Lawsuit == nil {
+			return
+		}
+		a := *ev.Lawsuit
+		a.History = append(a.History[:len(a.History):len(a.History)], HistoryEntry{At: ev.At, Event: evImported, Other: a.TransferredFrom, Audit: ev.Audit})
+		ts.state.Lawsuits = append(ts.state.Lawsuits, a)
+		ts.index.add(a, len(ts.state.Lawsuits)-1)
+		if ev.NextSeq > ts.state.NextSeq {
+			ts.state.NextSeq = ev.NextSeq
+		}
+		ts.relinkLocked(ev.Relinked, a.TransferredFrom, a.ID, ev)
+
+	case evTransferred:
+		a, ok := ts.lookupLocked(ev.ID)
+		if !ok || a.Status == StatusTransferred {
+			return
+		}
+		ts.setStatusLocked(a, StatusTransferred, ev.At)
+		a.TransferredTo = ev.Other
+		a.History = append(a.History, HistoryEntry{At: ev.At, Event: evTransferred, Other: ev.Other, Audit: ev.Audit})
+		ts.relinkLocked(ev.Relinked, ev.ID, ev.Other, ev)
+
+	case evRequest:
+		if ev.Request != nil {
+			ts.rememberLocked(*ev.Request)
+		}
+	}
+}
+
+// Connection of a with other, kept in its history if new
+func (ts *TrialStore) linkLocked(a *Lawsuit, other string, ev journalEvent) {
+	if !hasStr(a.Connected, other) {
+		a.Connected = append(a.Connected, other)
+		a.History = append(a.History, HistoryEntry{At: ev.At, Event: evConnected, Other: other, Audit: ev.Audit})
+	}
+}
+
+func hasInt(slice []int, val int) bool {
+	for _, x := range slice {
+		if x == val {
+			return true
+		}
+	}
+	return false
+}
+
+func hasStr(slice []string, val string) bool {
+	for _, x := range slice {
+		if x == val {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,240 @@
+package trial
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"judiciary/internal/protocol"
+)
+
+func newTestTrial(t *testing.T) (*TrialStore, string) {
+	t.Helper()
+	return newTestTrialID(t, 2)
+}
+
+// Trial id of the district 1: its lawsuits are 1.<id>.N
+func newTestTrialID(t *testing.T, id int) (*TrialStore, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "lawsuits.json")
+	ts := NewTrialStore(path, nil)
+	if err := ts.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.UpdateInfo(1, "A", id, fmt.Sprintf("127.0.0.1:92%02d", id)); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { closeTestTrial(ts) })
+	return ts, path
+}
+
+func closeTestTrial(ts *TrialStore) {
+	if l, ok := ts.layout.(*jsonLayout); ok && l.journal != nil {
+		l.journal.Close()
+		l.journal = nil
+	}
+}
+
+func reopenTestTrial(t *testing.T, path string) *TrialStore {
+	t.Helper()
+	ts := NewTrialStore(path, nil)
+	if err := ts.Load(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { closeTestTrial(ts) })
+	return ts
+}
+
+func mustCreate(t *testing.T, ts *TrialStore, plaintiff string, claims ...int) Lawsuit {
+	t.Helper()
+	a, err := ts.CreateLawsuit(plaintiff, "Bank", 10, claims, nil, protocol.Audit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func lawsuitIDs(lawsuits []Lawsuit) []string {
+	ids := make([]string, len(lawsuits))
+	for i, a := range lawsuits {
+		ids[i] = a.ID
+	}
+	return ids
+}
+
+func sameStrs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// The commits after the snapshot are replayed on Load
+func TestJournalReplayOnLoad(t *testing.T) {
+	ts, path := newTestTrial(t)
+	mustCreate(t, ts, "Alice", 1)
+	b := mustCreate(t, ts, "Bob", 2)
+	mustCreate(t, ts, "Carl", 3)
+	if err := ts.AddClaims(b.ID, []int{4}, protocol.Audit{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ts.DismissWithMerit(b.ID, protocol.Audit{}); err != nil {
+		t.Fatal(err)
+	}
+	closeTestTrial(ts)
+
+	re := reopenTestTrial(t, path)
+	if got := lawsuitIDs(re.GetActives()); !sameStrs(got, []string{"1.2.1", "1.2.3"}) {
+		t.Fatalf("actives after the replay: %v", got)
+	}
+	dis := re.GetDisWithMerit()
+	if len(dis) != 1 || dis[0].ID != b.ID || !sameIntSet(dis[0].Claims, []int{2, 4}) {
+		t.Fatalf("dismissed with merit after the replay: %+v", dis)
+	}
+	if re.state.JournalSeq != 5 || re.state.NextSeq != 4 {
+		t.Fatalf("JournalSeq=%d NextSeq=%d, want 5 and 4", re.state.JournalSeq, re.state.NextSeq)
+	}
+}
+
+// A commit cut in the middle of its line was never acknowledged: it is lost,
+// the ones before it are kept and the next commit takes its place
+func TestJournalTornCommit(t *testing.T) {
+	ts, path := newTestTrial(t)
+	mustCreate(t, ts, "Alice", 1)
+	mustCreate(t, ts, "Bob", 2)
+	closeTestTrial(ts)
+
+	jpath := journalPath(path)
+	b, err := os.ReadFile(jpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// half of the last line
+	last := len(b) - 1
+	for last > 0 && b[last-1] != '\n' {
+		last--
+	}
+	if err := os.WriteFile(jpath, b[:last+(len(b)-last)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	re := reopenTestTrial(t, path)
+	if got := lawsuitIDs(re.GetActives()); !sameStrs(got, []string{"1.2.1"}) {
+		t.Fatalf("actives after the torn commit: %v", got)
+	}
+	if a := mustCreate(t, re, "Carl", 3); a.ID != "1.2.2" {
+		t.Fatalf("next lawsuit %s, want 1.2.2", a.ID)
+	}
+	closeTestTrial(re)
+	if got := lawsuitIDs(reopenTestTrial(t, path).GetActives()); !sameStrs(got, []string{"1.2.1", "1.2.2"}) {
+		t.Fatalf("actives after the reopen: %v", got)
+	}
+}
+
+// Every journalSnapshotEvery commits the state goes to lawsuits.json and the
+// journal is emptied
+func TestJournalSnapshotEvery(t *testing.T) {
+	ts, path := newTestTrial(t)
+	for i := 0; i < journalSnapshotEvery; i++ {
+		mustCreate(t, ts, "Alice", i+1)
+	}
+	jpath := journalPath(path)
+	if info, err := os.Stat(jpath); err != nil || info.Size() != 0 {
+		t.Fatalf("journal not emptied after %d commits", journalSnapshotEvery)
+	}
+	mustCreate(t, ts, "Bob", 1)
+	if info, err := os.Stat(jpath); err != nil || info.Size() == 0 {
+		t.Fatal("commit after the snapshot not in the journal")
+	}
+	closeTestTrial(ts)
+
+	re := reopenTestTrial(t, path)
+	if n := len(re.GetActives()); n != journalSnapshotEvery+1 {
+		t.Fatalf("%d actives after the reopen, want %d", n, journalSnapshotEvery+1)
+	}
+	if re.state.JournalSeq != journalSnapshotEvery+1 {
+		t.Fatalf("JournalSeq=%d, want %d", re.state.JournalSeq, journalSnapshotEvery+1)
+	}
+}
+
+// Layout whose commits fail while fail is set
+type failingLayout struct {
+	trialLayout
+	fail     bool
+	failLoad bool
+}
+
+var errTestCommit = errors.New("disk full")
+
+func (l *failingLayout) commit(ts *TrialStore, c journalCommit) error {
+	if l.fail {
+		return errTestCommit
+	}
+	return l.trialLayout.commit(ts, c)
+}
+
+func (l *failingLayout) load(ts *TrialStore) error {
+	if l.failLoad {
+		return errors.New("storage unreadable")
+	}
+	return l.trialLayout.load(ts)
+}
+
+// A failed commit leaves the memory as the storage is
+func TestCommitFailureUndone(t *testing.T) {
+	ts, path := newTestTrial(t)
+	a := mustCreate(t, ts, "Alice", 1)
+	fl := &failingLayout{trialLayout: ts.layout, fail: true}
+	ts.layout = fl
+
+	if _, err := ts.CreateLawsuit("Bob", "Bank", 10, []int{2}, nil, protocol.Audit{}); !errors.Is(err, errTestCommit) {
+		t.Fatalf("CreateLawsuit error = %v, want the commit error", err)
+	}
+	if err := ts.AddClaims(a.ID, []int{5}, protocol.Audit{}); !errors.Is(err, errTestCommit) {
+		t.Fatalf("AddClaims error = %v, want the commit error", err)
+	}
+	if got := lawsuitIDs(ts.GetActives()); !sameStrs(got, []string{a.ID}) {
+		t.Fatalf("actives after the failed commits: %v", got)
+	}
+	if got, _ := ts.lookupLocked(a.ID); !sameIntSet(got.Claims, []int{1}) {
+		t.Fatalf("claims after the failed merge: %v", got.Claims)
+	}
+	if found := ts.findIdenticalDwM("actives", protocol.ActionQuery{Plaintiff: "Bob", Defendant: "Bank", CauseID: 10, Claims: []int{2}}); len(found) > 0 {
+		t.Fatal("lawsuit of the failed commit still in the indexes")
+	}
+
+	fl.fail = false
+	if b := mustCreate(t, ts, "Bob", 2); b.ID != "1.2.2" {
+		t.Fatalf("lawsuit after the failure %s, want 1.2.2", b.ID)
+	}
+	closeTestTrial(ts)
+	if got := lawsuitIDs(reopenTestTrial(t, path).GetActives()); !sameStrs(got, []string{"1.2.1", "1.2.2"}) {
+		t.Fatalf("actives after the reopen: %v", got)
+	}
+}
+
+// If the state cannot be read again, the store refuses changes
+func TestCommitFailureStops(t *testing.T) {
+	ts, _ := newTestTrial(t)
+	a := mustCreate(t, ts, "Alice", 1)
+	fl := &failingLayout{trialLayout: ts.layout, fail: true, failLoad: true}
+	ts.layout = fl
+
+	if _, err := ts.CreateLawsuit("Bob", "Bank", 10, []int{2}, nil, protocol.Audit{}); !errors.Is(err, errTestCommit) {
+		t.Fatalf("CreateLawsuit error = %v, want the commit error", err)
+	}
+	fl.fail, fl.failLoad = false, false
+	if _, err := ts.CreateLawsuit("Carl", "Bank", 10, []int{3}, nil, protocol.Audit{}); err == nil {
+		t.Fatal("change accepted after a commit that was not undone")
+	}
+	if _, err := ts.DismissWithMerit(a.ID, protocol.Audit{}); err == nil {
+		t.Fatal("status change accepted after a commit that was not undone")
+	}
+}
@@ -0,0 +1,286 @@
+package trial
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"sort"
+
+	"judiciary/internal/storage"
+)
+
+// ---------- One record per lawsuit (kv storage) ----------
+
+// Keys: "trial" (IDs, address, sequences), "lawsuit/<ID>" (the lawsuit and its
+// position in the filing order) and "request/<ID>" (answered requests). A
+// commit rewrites, in one transaction, only the records its events changed.
+const (
+	keyTrialMeta     = "trial"
+	keyLawsuitPrefix = "lawsuit/"
+	keyRequestPrefix = "request/"
+)
+
+type trialMeta struct {
+	DistrictID   int    `json:"district_id"`
+	DistrictName string `json:"district_name"`
+	TrialID      int    `json:"trial_id"`
+	TrialAddr    string `json:"trial_addr"`
+	NextSeq      int    `json:"next_seq"`
+	JournalSeq   int64  `json:"journal_seq,omitempty"`
+
+	// Last position given to a lawsuit
+	ListSeq int64 `json:"list_seq"`
+}
+
+type lawsuitRecord struct {
+	Order   int64   `json:"order"`
+	Lawsuit Lawsuit `json:"lawsuit"`
+
+	// Records of previous versions: list of the lawsuit ("actives", "dis_with"
+	// or "dis_without"), instead of its status
+	List string `json:"list,omitempty"`
+}
+
+var recordListStatus = map[string]string{
+	"actives":     StatusActive,
+	"dis_with":    StatusDisWithMerit,
+	"dis_without": StatusDisWithoutMerit,
+}
+
+type recordsLayout struct {
+	store    storage.Backend
+	listSeq  int64
+	requests map[string]bool // request records in the storage
+}
+
+func (l *recordsLayout) meta(ts *TrialStore) trialMeta {
+	return trialMeta{
+		DistrictID:   ts.state.DistrictID,
+		DistrictName: ts.state.DistrictName,
+		TrialID:      ts.state.TrialID,
+		TrialAddr:    ts.state.TrialAddr,
+		NextSeq:      ts.state.NextSeq,
+		JournalSeq:   ts.state.JournalSeq,
+		ListSeq:      l.listSeq,
+	}
+}
+
+func (l *recordsLayout) load(ts *TrialStore) error {
+	var meta trialMeta
+	found, err := l.store.Get(keyTrialMeta, &meta)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return l.importJSON(ts)
+	}
+
+	var records []lawsuitRecord
+	legacy := false
+	err = l.store.Scan(keyLawsuitPrefix, func(key string, value []byte) error {
+		var r lawsuitRecord
+		if err := json.Unmarshal(value, &r); err != nil {
+			return err
+		}
+		if r.Lawsuit.Status == "" {
+			r.Lawsuit.Status = recordListStatus[r.List]
+			legacy = true
+		}
+		records = append(records, r)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if legacy {
+			// positions of previous versions are per list
+			return compareLawsuitIDs(records[i].Lawsuit.ID, records[j].Lawsuit.ID) < 0
+		}
+		return records[i].Order < records[j].Order
+	})
+	lawsuits := make([]Lawsuit, len(records))
+	for i, r := range records {
+		lawsuits[i] = r.Lawsuit
+	}
+
+	var requests []RequestRecord
+	l.requests = map[string]bool{}
+	err = l.store.Scan(keyRequestPrefix, func(key string, value []byte) error {
+		var r RequestRecord
+		if err := json.Unmarshal(value, &r); err != nil {
+			return err
+		}
+		requests = append(requests, r)
+		l.requests[r.ID] = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Slice(requests, func(i, j int) bool { return requests[i].At.Before(requests[j].At) })
+
+	l.listSeq = meta.ListSeq
+	ts.setStateLocked(TrialState{
+		DistrictID:   meta.DistrictID,
+		DistrictName: meta.DistrictName,
+		TrialID:      meta.TrialID,
+		TrialAddr:    meta.TrialAddr,
+		NextSeq:      meta.NextSeq,
+		Lawsuits:     lawsuits,
+		Requests:     requests,
+		JournalSeq:   meta.JournalSeq,
+	})
+	return nil
+}
+
+// First start with -storage kv: the state of lawsuits.json (and its journal), if
+// any, is written as records
+func (l *recordsLayout) importJSON(ts *TrialStore) error {
+	_, errSnap := os.Stat(ts.filePath)
+	_, errJournal := os.Stat(journalPath(ts.filePath))
+	if errSnap != nil && errJournal != nil {
+		ts.reindexLocked()
+		return l.writeAll(ts)
+	}
+
+	files := &jsonLayout{store: storage.NewJSONFiles(map[string]string{keyLawsuits: ts.filePath})}
+	err := files.load(ts)
+	if files.journal != nil {
+		files.journal.Close()
+	}
+	if err != nil {
+		return err
+	}
+	if n := len(ts.state.Lawsuits); n > 0 {
+		log.Printf("[TRIAL] %d lawsuit(s) of %s imported into the kv storage", n, ts.filePath)
+	}
+	return l.writeAll(ts)
+}
+
+// Write the lawsuit as it is now in the store
+func (l *recordsLayout) putLawsuit(ts *TrialStore, tx storage.Tx, id string, entered bool) error {
+	a, ok := ts.lookupLocked(id)
+	if !ok {
+		return nil
+	}
+	r := lawsuitRecord{Lawsuit: *a}
+	if entered {
+		l.listSeq++
+		r.Order = l.listSeq
+	} else {
+		var old lawsuitRecord
+		if _, err := tx.Get(keyLawsuitPrefix+id, &old); err != nil {
+			return err
+		}
+		r.Order = old.Order
+	}
+	return tx.Put(keyLawsuitPrefix+id, r)
+}
+
+func (l *recordsLayout) commit(ts *TrialStore, c journalCommit) error {
+	seq, requests := l.listSeq, l.requests
+	err := l.store.Update(func(tx storage.Tx) error {
+		for _, ev := range c.Events {
+			var err error
+			switch ev.Type {
+			case evCreated:
+				if ev.Lawsuit != nil {
+					err = l.putLawsuit(ts, tx, ev.Lawsuit.ID, true)
+				}
+			case evDismissed, evStatus, evClaimsMerged:
+				err = l.putLawsuit(ts, tx, ev.ID, false)
+			case evImported:
+				if ev.Lawsuit != nil {
+					err = l.putLawsuit(ts, tx, ev.Lawsuit.ID, true)
+				}
+				for _, id := range ev.Relinked {
+					if err == nil {
+						err = l.putLawsuit(ts, tx, id, false)
+					}
+				}
+			case evTransferred:
+				err = l.putLawsuit(ts, tx, ev.ID, false)
+				for _, id := range ev.Relinked {
+					if err == nil {
+						err = l.putLawsuit(ts, tx, id, false)
+					}
+				}
+			case evConnected:
+				if err = l.putLawsuit(ts, tx, ev.ID, false); err == nil {
+					err = l.putLawsuit(ts, tx, ev.Other, false)
+				}
+			case evRequest:
+				err = l.putRequests(ts, tx)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Put(keyTrialMeta, l.meta(ts))
+	})
+	if err != nil {
+		l.listSeq, l.requests = seq, requests
+	}
+	return err
+}
+
+// Write the answered requests not yet in the storage and delete the ones
+// dropped by rememberLocked
+func (l *recordsLayout) putRequests(ts *TrialStore, tx storage.Tx) error {
+	if l.requests == nil {
+		l.requests = map[string]bool{}
+	}
+	kept := map[string]bool{}
+	for _, r := range ts.state.Requests {
+		kept[r.ID] = true
+		if !l.requests[r.ID] {
+			if err := tx.Put(keyRequestPrefix+r.ID, r); err != nil {
+				return err
+			}
+		}
+	}
+	for id := range l.requests {
+		if !kept[id] {
+			if err := tx.Delete(keyRequestPrefix + id); err != nil {
+				return err
+			}
+		}
+	}
+	l.requests = kept
+	return nil
+}
+
+// Save the pending events (if any) and the trial's data; the lawsuits not
+// changed are already in their records
+func (l *recordsLayout) snapshot(ts *TrialStore) error {
+	if len(ts.pending) > 0 {
+		return ts.commitLocked()
+	}
+	return l.store.Update(func(tx storage.Tx) error {
+		return tx.Put(keyTrialMeta, l.meta(ts))
+	})
+}
+
+// Write every lawsuit, in filing order
+func (l *recordsLayout) writeAll(ts *TrialStore) error {
+	seq, requests := l.listSeq, l.requests
+	err := l.store.Update(func(tx storage.Tx) error {
+		l.listSeq = 0
+		for _, a := range ts.state.Lawsuits {
+			l.listSeq++
+			r := lawsuitRecord{Order: l.listSeq, Lawsuit: a}
+			if err := tx.Put(keyLawsuitPrefix+a.ID, r); err != nil {
+				return err
+			}
+		}
+		if err := l.putRequests(ts, tx); err != nil {
+			return err
+		}
+		return tx.Put(keyTrialMeta, l.meta(ts))
+	})
+	if err != nil {
+		l.listSeq, l.requests = seq, requests
+	}
+	return err
+}
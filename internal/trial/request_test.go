@@ -0,0 +1,109 @@
+package trial
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"judiciary/internal/protocol"
+)
+
+func createRequest(reqID string) protocol.TrialCreateActionRequest {
+	return protocol.TrialCreateActionRequest{
+		Envelope:  protocol.NewEnvelope("district:A", ""),
+		Type:      "lawsuit_create",
+		Reason:    "free",
+		Lawsuit:   protocol.ActionQuery{Plaintiff: "Alice", Defendant: "Bank", CauseID: 10, Claims: []int{1}},
+		RequestID: reqID,
+	}
+}
+
+func onceCreate(ts *TrialStore, req protocol.TrialCreateActionRequest) (protocol.TrialCreateActionResponse, error) {
+	var resp protocol.TrialCreateActionResponse
+	b, err := ts.once(req.RequestID, req.Type, func() any { return lawsuitCreate(ts, req) })
+	if err == nil {
+		err = json.Unmarshal(b, &resp)
+	}
+	return resp, err
+}
+
+// The lawsuit and the record of the response are one commit: a retransmission
+// never creates the lawsuit again, even after a restart
+func TestOnceCommitsChangeWithResponse(t *testing.T) {
+	ts, path := newTestTrial(t)
+	req := createRequest("req-1")
+
+	first, err := onceCreate(ts, req)
+	if err != nil || !first.Success {
+		t.Fatalf("first lawsuit_create: %+v %v", first, err)
+	}
+	again, err := onceCreate(ts, req)
+	if err != nil || again.LawsuitID != first.LawsuitID {
+		t.Fatalf("retransmission answered %+v %v, want lawsuit %s", again, err, first.LawsuitID)
+	}
+	if n := len(ts.GetActives()); n != 1 {
+		t.Fatalf("%d actives after the retransmission, want 1", n)
+	}
+
+	b, err := os.ReadFile(journalPath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	var c journalCommit
+	if len(lines) != 1 || json.Unmarshal([]byte(lines[0]), &c) != nil || len(c.Events) != 2 ||
+		c.Events[0].Type != evCreated || c.Events[1].Type != evRequest {
+		t.Fatalf("journal %q, want one commit with the creation and the response", b)
+	}
+
+	closeTestTrial(ts)
+	re := reopenTestTrial(t, path)
+	after, err := onceCreate(re, req)
+	if err != nil || after.LawsuitID != first.LawsuitID || len(re.GetActives()) != 1 {
+		t.Fatalf("retransmission after the restart: %+v %v (%d actives)", after, err, len(re.GetActives()))
+	}
+}
+
+// If the commit fails nothing is kept, not even the response: the
+// retransmission executes the request
+func TestOnceCommitFailure(t *testing.T) {
+	ts, _ := newTestTrial(t)
+	fl := &failingLayout{trialLayout: ts.layout, fail: true}
+	ts.layout = fl
+	req := createRequest("req-2")
+
+	if _, err := onceCreate(ts, req); !errors.Is(err, errTestCommit) {
+		t.Fatalf("lawsuit_create error = %v, want the commit error", err)
+	}
+	if n := len(ts.GetActives()); n != 0 || ts.findRequestLocked(req.RequestID) != nil {
+		t.Fatalf("%d actives and request record %v after the failed commit", n, ts.findRequestLocked(req.RequestID))
+	}
+
+	fl.fail = false
+	resp, err := onceCreate(ts, req)
+	if err != nil || !resp.Success || resp.LawsuitID != "1.2.1" {
+		t.Fatalf("retransmission after the failure: %+v %v", resp, err)
+	}
+}
+
+// Connection: the lawsuit, its connection and the response in one commit
+func TestOnceCreateConnected(t *testing.T) {
+	ts, _ := newTestTrial(t)
+	a := mustCreate(t, ts, "Bob", 2)
+	req := createRequest("req-3")
+	req.Reason, req.Related = "connection", a.ID
+
+	resp, err := onceCreate(ts, req)
+	if err != nil || !resp.Success {
+		t.Fatalf("lawsuit_create by connection: %+v %v", resp, err)
+	}
+	b, _ := ts.lookupLocked(resp.LawsuitID)
+	if !hasStr(b.Connected, a.ID) {
+		t.Fatalf("new lawsuit connected to %v, want %s", b.Connected, a.ID)
+	}
+	if ts.state.JournalSeq != 2 {
+		t.Fatalf("JournalSeq=%d, want 2 (one commit for the request)", ts.state.JournalSeq)
+	}
+}
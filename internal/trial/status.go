@@ -0,0 +1,122 @@
+package trial
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"judiciary/internal/protocol"
+)
+
+// ---------- Status of the lawsuits ----------
+
+// Every lawsuit has a status; the lists of the trial (actives, dismissed with
+// and without merit...) are the lawsuits of each status.
+const (
+	StatusActive          = "active"
+	StatusSuspended       = "suspended"              // sobrestamento
+	StatusArchived        = "archived_provisionally" // provisional archive
+	StatusOnAppeal        = "on_appeal"              // judgment sent up on appeal
+	StatusDisWithMerit    = "dismissed_with_merit"
+	StatusDisWithoutMerit = "dismissed_without_merit"
+	StatusTransferred     = "transferred" // moved to another trial (see transfer.go)
+)
+
+// Statuses in the order of the lists (menus, search sorted by list)
+var statusOrder = []string{StatusActive, StatusSuspended, StatusArchived, StatusOnAppeal, StatusDisWithMerit, StatusDisWithoutMerit, StatusTransferred}
+
+// Names of the lists (List of the search results)
+var statusLabels = map[string]string{
+	StatusActive:          "Active",
+	StatusSuspended:       "Suspended",
+	StatusArchived:        "Archived provisionally",
+	StatusOnAppeal:        "On appeal",
+	StatusDisWithMerit:    "Dismissed with merit",
+	StatusDisWithoutMerit: "Dismissed without merit",
+	StatusTransferred:     "Transferred",
+}
+
+// Transitions accepted (from -> to). A dismissed lawsuit goes on appeal, and
+// one dismissed with merit is reopened (active) after rescission; the appeal
+// ends reopening the lawsuit or with a dismissal. A pending lawsuit becomes
+// transferred only by a transfer, and a transferred one does not change.
+var statusTransitions = map[string][]string{
+	StatusActive:          {StatusSuspended, StatusArchived, StatusDisWithMerit, StatusDisWithoutMerit},
+	StatusSuspended:       {StatusActive, StatusDisWithMerit, StatusDisWithoutMerit},
+	StatusArchived:        {StatusActive},
+	StatusOnAppeal:        {StatusActive, StatusDisWithMerit, StatusDisWithoutMerit},
+	StatusDisWithMerit:    {StatusOnAppeal, StatusActive},
+	StatusDisWithoutMerit: {StatusOnAppeal},
+}
+
+// Lawsuits still pending (lis pendens): not dismissed, even if suspended,
+// archived provisionally or on appeal
+var pendingStatuses = []string{StatusActive, StatusSuspended, StatusArchived, StatusOnAppeal}
+
+func statusLabel(status string) string {
+	if l, ok := statusLabels[status]; ok {
+		return l
+	}
+	return status
+}
+
+func canTransition(from, to string) bool {
+	for _, s := range statusTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Change the status of the lawsuit, if the transition is accepted; by is kept
+// in its history
+func (ts *TrialStore) SetStatus(id, status string, by protocol.Audit) (Lawsuit, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	a, ok := ts.lookupLocked(id)
+	if !ok {
+		return Lawsuit{}, fmt.Errorf("lawsuit %q not found", id)
+	}
+	if !canTransition(a.Status, status) {
+		return Lawsuit{}, fmt.Errorf("lawsuit %s is %s: it cannot become %s", id,
+			strings.ToLower(statusLabel(a.Status)), strings.ToLower(statusLabel(status)))
+	}
+	ts.recordLocked(journalEvent{Type: evStatus, ID: id, Status: status, Audit: by})
+
+	if err := ts.commitLocked(); err != nil {
+		return Lawsuit{}, err
+	}
+	a, _ = ts.lookupLocked(id)
+	return *a, nil
+}
+
+// Statuses the lawsuit can go to (for the menu)
+func (ts *TrialStore) NextStatuses(id string) (Lawsuit, []string, error) {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+
+	a, ok := ts.lookupLocked(id)
+	if !ok {
+		return Lawsuit{}, nil, fmt.Errorf("lawsuit %q not found", id)
+	}
+	return *a, statusTransitions[a.Status], nil
+}
+
+// Lawsuits of the statuses, in filing order (copy for reading)
+func (ts *TrialStore) GetByStatus(statuses ...string) []Lawsuit {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	return ts.viewLocked(statuses...)
+}
+
+// Change of status (ts.mu must be locked; at: time of the transition)
+func (ts *TrialStore) setStatusLocked(a *Lawsuit, status string, at time.Time) {
+	if s, ok := ts.index.byStatus[a.Status]; ok {
+		delete(s, a.ID)
+	}
+	a.Status = status
+	a.StatusAt = at
+	addTo(ts.index.byStatus, status, a.ID)
+}
@@ -0,0 +1,179 @@
+package trial
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"judiciary/internal/protocol"
+)
+
+// Statuses set, in order, to take a new lawsuit to the status
+var statusPaths = map[string][]string{
+	StatusActive:          nil,
+	StatusSuspended:       {StatusSuspended},
+	StatusArchived:        {StatusArchived},
+	StatusOnAppeal:        {StatusDisWithMerit, StatusOnAppeal},
+	StatusDisWithMerit:    {StatusDisWithMerit},
+	StatusDisWithoutMerit: {StatusDisWithoutMerit},
+	StatusTransferred:     nil, // by MarkTransferred
+}
+
+func lawsuitWithStatus(t *testing.T, ts *TrialStore, status string, claims ...int) Lawsuit {
+	t.Helper()
+	a := mustCreate(t, ts, "Alice", claims...)
+	if status == StatusTransferred {
+		moved, err := ts.MarkTransferred(a.ID, "1.3.1", protocol.Audit{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return moved
+	}
+	for _, st := range statusPaths[status] {
+		var err error
+		if a, err = ts.SetStatus(a.ID, st, protocol.Audit{}); err != nil {
+			t.Fatalf("%s -> %s: %v", a.Status, st, err)
+		}
+	}
+	return a
+}
+
+func TestStatusTransitions(t *testing.T) {
+	for _, tc := range []struct {
+		from    string
+		allowed []string
+	}{
+		{StatusActive, []string{StatusSuspended, StatusArchived, StatusDisWithMerit, StatusDisWithoutMerit}},
+		{StatusSuspended, []string{StatusActive, StatusDisWithMerit, StatusDisWithoutMerit}},
+		{StatusArchived, []string{StatusActive}},
+		{StatusOnAppeal, []string{StatusActive, StatusDisWithMerit, StatusDisWithoutMerit}},
+		{StatusDisWithMerit, []string{StatusOnAppeal, StatusActive}},
+		{StatusDisWithoutMerit, []string{StatusOnAppeal}},
+		{StatusTransferred, nil},
+	} {
+		for _, to := range statusOrder {
+			allowed := hasStr(tc.allowed, to)
+			t.Run(tc.from+"->"+to, func(t *testing.T) {
+				ts, _ := newTestTrial(t)
+				a := lawsuitWithStatus(t, ts, tc.from, 1)
+				seq := ts.state.JournalSeq
+
+				got, err := ts.SetStatus(a.ID, to, protocol.Audit{})
+				if allowed {
+					if err != nil || got.Status != to {
+						t.Fatalf("transition refused: %+v %v", got, err)
+					}
+					return
+				}
+				if err == nil || !strings.Contains(err.Error(), "cannot become") {
+					t.Fatalf("transition accepted (err %v)", err)
+				}
+				after, _ := ts.lookupLocked(a.ID)
+				if after.Status != tc.from || ts.state.JournalSeq != seq {
+					t.Fatalf("refused transition left the lawsuit %s (journal %d -> %d)", after.Status, seq, ts.state.JournalSeq)
+				}
+			})
+		}
+	}
+}
+
+// An identical lawsuit is lis pendens while the other is pending, whatever
+// its status
+func TestPendingStatusesBlockLisPendens(t *testing.T) {
+	q := protocol.ActionQuery{Plaintiff: "Alice", Defendant: "Bank", CauseID: 10, Claims: []int{1}}
+	for _, tc := range []struct {
+		status   string
+		conflict string // match of CreateLawsuitChecked ("" accepted)
+	}{
+		{StatusActive, "lis_pendens"},
+		{StatusSuspended, "lis_pendens"},
+		{StatusArchived, "lis_pendens"},
+		{StatusOnAppeal, "lis_pendens"},
+		{StatusDisWithMerit, "res_judicata"},
+		{StatusDisWithoutMerit, ""},
+		{StatusTransferred, ""},
+	} {
+		t.Run(tc.status, func(t *testing.T) {
+			ts, _ := newTestTrial(t)
+			a := lawsuitWithStatus(t, ts, tc.status, 1)
+
+			pending := hasStr(pendingStatuses, tc.status)
+			ts.mu.RLock()
+			matches := lisPendensRule{}.Match(ts, q)
+			ts.mu.RUnlock()
+			if (len(matches) > 0) != pending {
+				t.Fatalf("lis pendens rule on a lawsuit %s: %+v", tc.status, matches)
+			}
+			if pending && matches[0].LawsuitID != a.ID {
+				t.Fatalf("lis pendens of %s, want %s", matches[0].LawsuitID, a.ID)
+			}
+
+			_, conflict, err := ts.CreateLawsuitChecked(q, protocol.Audit{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := ""
+			if conflict != nil {
+				got = conflict.Match
+			}
+			if got != tc.conflict {
+				t.Fatalf("identical lawsuit with the other %s: conflict %q, want %q", tc.status, got, tc.conflict)
+			}
+		})
+	}
+}
+
+// The "dismissed" events of the journals of previous versions are replayed
+// as status changes
+func TestLegacyDismissedReplay(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		lists  []string // List of each dismissed event
+		status string
+	}{
+		{"with merit", []string{dismissedWithMerit}, StatusDisWithMerit},
+		{"without merit", []string{dismissedWithoutMerit}, StatusDisWithoutMerit},
+		{"without list", []string{""}, StatusDisWithMerit},
+		{"dismissed twice", []string{dismissedWithMerit, dismissedWithoutMerit}, StatusDisWithMerit},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ts, path := newTestTrial(t)
+			a := mustCreate(t, ts, "Alice", 1)
+			seq := ts.state.JournalSeq
+			closeTestTrial(ts)
+
+			at := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+			f, err := os.OpenFile(journalPath(path), os.O_WRONLY|os.O_APPEND, 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, list := range tc.lists {
+				seq++
+				c := journalCommit{Seq: seq, At: at, Events: []journalEvent{{Type: evDismissed, At: at.Add(time.Duration(i) * time.Minute), ID: a.ID, List: list}}}
+				b, _ := json.Marshal(c)
+				if _, err := f.Write(append(b, '\n')); err != nil {
+					t.Fatal(err)
+				}
+			}
+			f.Close()
+
+			re := reopenTestTrial(t, path)
+			got, ok := re.lookupLocked(a.ID)
+			if !ok || got.Status != tc.status || !got.StatusAt.Equal(at) {
+				t.Fatalf("after the replay: %+v, want %s at %v", got, tc.status, at)
+			}
+			last := got.History[len(got.History)-1]
+			if last.Event != evStatus || last.From != StatusActive || last.Status != tc.status {
+				t.Fatalf("history entry %+v, want status_changed active -> %s", last, tc.status)
+			}
+			if ids := lawsuitIDs(re.GetByStatus(tc.status)); !sameStrs(ids, []string{a.ID}) {
+				t.Fatalf("list %s = %v", tc.status, ids)
+			}
+			if n := len(re.GetActives()); n != 0 {
+				t.Fatalf("%d actives after the replay", n)
+			}
+		})
+	}
+}
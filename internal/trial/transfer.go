@@ -0,0 +1,217 @@
+package trial
+
+import (
+	"fmt"
+	"strings"
+
+	"judiciary/internal/protocol"
+)
+
+// ---------- Transfer of a lawsuit to another trial ----------
+
+// The district moves a pending lawsuit (recusal of the judge, declination of
+// competence): the source trial exports it, the target trial imports it under
+// a new ID (TransferredFrom: the old one) and the source keeps it as
+// transferred (TransferredTo: the new one). The lawsuits connected to the old
+// ID, in each of the two trials, are connected to the new one. When the
+// district removes a trial (reason protocol.TransferDecommission), its
+// dismissed lawsuits are transferred too, keeping their status, so that they
+// still count for res judicata.
+
+// History entry of a lawsuit whose connected lawsuit was transferred
+const histConnectionMoved = "connection_moved"
+
+// Statuses of the lawsuits transferred for the reason
+func transferable(status, reason string) bool {
+	if reason == protocol.TransferDecommission && (status == StatusDisWithMerit || status == StatusDisWithoutMerit) {
+		return true
+	}
+	return hasStr(pendingStatuses, status)
+}
+
+func notTransferable(id, status string) error {
+	return fmt.Errorf("lawsuit %s is %s: only pending lawsuits are transferred (dismissed ones only when the trial is removed)",
+		id, strings.ToLower(statusLabel(status)))
+}
+
+// The lawsuit to be transferred, unchanged
+func (ts *TrialStore) ExportLawsuit(id, reason string) (Lawsuit, error) {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+
+	a, ok := ts.lookupLocked(id)
+	if !ok {
+		return Lawsuit{}, fmt.Errorf("lawsuit %q not found", id)
+	}
+	if !transferable(a.Status, reason) {
+		return Lawsuit{}, notTransferable(id, a.Status)
+	}
+	return *a, nil
+}
+
+// Registers the lawsuit exported by another trial under a new ID of this one,
+// with its history and status (by.Reason: the reason of the transfer). A
+// lawsuit already imported (same old ID) is returned as is.
+func (ts *TrialStore) ImportLawsuit(in Lawsuit, by protocol.Audit) (Lawsuit, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	a, err := ts.importLocked(in, by)
+	if err != nil {
+		return Lawsuit{}, err
+	}
+	if err := ts.commitLocked(); err != nil {
+		return Lawsuit{}, err
+	}
+	return a, nil
+}
+
+// See ImportLawsuit (ts.mu must be locked; does not commit)
+func (ts *TrialStore) importLocked(in Lawsuit, by protocol.Audit) (Lawsuit, error) {
+	if in.ID == "" || in.Plaintiff == "" || in.Defendant == "" || in.CauseAction == 0 {
+		return Lawsuit{}, fmt.Errorf("insufficient data for the lawsuit to be imported")
+	}
+	if !transferable(in.Status, by.Reason) {
+		return Lawsuit{}, notTransferable(in.ID, in.Status)
+	}
+	if a, ok := ts.importedLocked(in.ID); ok {
+		return *a, nil
+	}
+	if _, ok := ts.lookupLocked(in.ID); ok {
+		return Lawsuit{}, fmt.Errorf("lawsuit %s is already in this trial", in.ID)
+	}
+
+	a := in
+	a.ID = ts.nextID()
+	a.TransferredFrom, a.TransferredTo = in.ID, ""
+	a.Claims = append([]int(nil), in.Claims...)
+	a.History = append([]HistoryEntry(nil), in.History...)
+	// lawsuits connected to it that came here before have new IDs too
+	a.Connected = nil
+	for _, c := range in.Connected {
+		if b, ok := ts.importedLocked(c); ok {
+			c = b.ID
+		}
+		if !hasStr(a.Connected, c) {
+			a.Connected = append(a.Connected, c)
+		}
+	}
+
+	ts.recordLocked(journalEvent{Type: evImported, Lawsuit: &a, NextSeq: ts.state.NextSeq, Relinked: ts.connectedToLocked(in.ID), Audit: by})
+	b, ok := ts.lookupLocked(a.ID)
+	if !ok {
+		return Lawsuit{}, fmt.Errorf("lawsuit %s not imported", in.ID)
+	}
+	return *b, nil
+}
+
+// The lawsuit was imported by another trial as newID: it stays here as
+// transferred. Repeated for the same newID, returns the lawsuit as is.
+func (ts *TrialStore) MarkTransferred(id, newID string, by protocol.Audit) (Lawsuit, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	a, err := ts.markTransferredLocked(id, newID, by)
+	if err != nil {
+		return Lawsuit{}, err
+	}
+	if err := ts.commitLocked(); err != nil {
+		return Lawsuit{}, err
+	}
+	return a, nil
+}
+
+// See MarkTransferred (ts.mu must be locked; does not commit)
+func (ts *TrialStore) markTransferredLocked(id, newID string, by protocol.Audit) (Lawsuit, error) {
+	a, ok := ts.lookupLocked(id)
+	if !ok {
+		return Lawsuit{}, fmt.Errorf("lawsuit %q not found", id)
+	}
+	if newID == "" || newID == id {
+		return Lawsuit{}, fmt.Errorf("invalid ID %q for the transferred lawsuit %s", newID, id)
+	}
+	if a.Status == StatusTransferred && a.TransferredTo == newID {
+		return *a, nil
+	}
+	if !transferable(a.Status, by.Reason) {
+		return Lawsuit{}, notTransferable(id, a.Status)
+	}
+
+	ts.recordLocked(journalEvent{Type: evTransferred, ID: id, Other: newID, Relinked: ts.connectedToLocked(id), Audit: by})
+	if a.Status != StatusTransferred {
+		return Lawsuit{}, fmt.Errorf("lawsuit %s not transferred", id)
+	}
+	return *a, nil
+}
+
+// Lawsuit imported from another trial where its ID was oldID (ts.mu must be locked)
+func (ts *TrialStore) importedLocked(oldID string) (*Lawsuit, bool) {
+	idx, ok := ts.index.byFormer[oldID]
+	if !ok {
+		return nil, false
+	}
+	return &ts.state.Lawsuits[idx], true
+}
+
+// IDs of the lawsuits connected to id, in filing order (ts.mu must be locked)
+func (ts *TrialStore) connectedToLocked(id string) []string {
+	var ids []string
+	for _, a := range ts.state.Lawsuits {
+		if a.ID != id && hasStr(a.Connected, id) {
+			ids = append(ids, a.ID)
+		}
+	}
+	return ids
+}
+
+// The lawsuits ids, connected to oldID, are connected to newID (ts.mu must be locked)
+func (ts *TrialStore) relinkLocked(ids []string, oldID, newID string, ev journalEvent) {
+	for _, id := range ids {
+		a, ok := ts.lookupLocked(id)
+		if !ok || !hasStr(a.Connected, oldID) {
+			continue
+		}
+		connected := make([]string, 0, len(a.Connected))
+		for _, c := range a.Connected {
+			if c == oldID {
+				c = newID
+			}
+			if !hasStr(connected, c) {
+				connected = append(connected, c)
+			}
+		}
+		a.Connected = connected
+		a.History = append(a.History, HistoryEntry{At: ev.At, Event: histConnectionMoved, From: oldID, Other: newID, Audit: ev.Audit})
+	}
+}
+
+// Lawsuit of the transfer messages
+func transferOf(a Lawsuit) protocol.TransferLawsuit {
+	return protocol.TransferLawsuit{
+		ID:          a.ID,
+		Plaintiff:   a.Plaintiff,
+		Defendant:   a.Defendant,
+		CauseAction: a.CauseAction,
+		Claims:      append([]int(nil), a.Claims...),
+		Connected:   append([]string(nil), a.Connected...),
+		FiledAt:     a.FiledAt,
+		Status:      a.Status,
+		StatusAt:    a.StatusAt,
+		History:     a.History,
+	}
+}
+
+func lawsuitOfTransfer(t protocol.TransferLawsuit) Lawsuit {
+	return Lawsuit{
+		ID:          t.ID,
+		Plaintiff:   t.Plaintiff,
+		Defendant:   t.Defendant,
+		CauseAction: t.CauseAction,
+		Claims:      t.Claims,
+		Connected:   t.Connected,
+		FiledAt:     t.FiledAt,
+		Status:      t.Status,
+		StatusAt:    t.StatusAt,
+		History:     t.History,
+	}
+}
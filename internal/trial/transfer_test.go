@@ -0,0 +1,187 @@
+package trial
+
+import (
+	"testing"
+
+	"judiciary/internal/protocol"
+)
+
+var recusal = protocol.Audit{Reason: protocol.TransferRecusal}
+
+// Export from src, import in dst and registration as transferred in src, as
+// the district does (see transferLawsuit)
+func mustTransfer(t *testing.T, src, dst *TrialStore, id string, by protocol.Audit) Lawsuit {
+	t.Helper()
+	exported, err := src.ExportLawsuit(id, by.Reason)
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, err := dst.ImportLawsuit(exported, by)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.MarkTransferred(id, imported.ID, by); err != nil {
+		t.Fatal(err)
+	}
+	return imported
+}
+
+func mustLookup(t *testing.T, ts *TrialStore, id string) Lawsuit {
+	t.Helper()
+	a, ok := ts.lookupLocked(id)
+	if !ok {
+		t.Fatalf("lawsuit %s not found", id)
+	}
+	return *a
+}
+
+func TestTransferLawsuit(t *testing.T) {
+	src, _ := newTestTrialID(t, 2)
+	dst, dstPath := newTestTrialID(t, 3)
+	a := mustCreate(t, src, "Alice", 1)
+	mustCreate(t, dst, "Xavier", 9)
+
+	exported, err := src.ExportLawsuit(a.ID, protocol.TransferRecusal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, err := dst.ImportLawsuit(exported, recusal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.ID != "1.3.2" || imported.TransferredFrom != a.ID || imported.Status != StatusActive {
+		t.Fatalf("imported %+v, want 1.3.2 from %s, active", imported, a.ID)
+	}
+	last := imported.History[len(imported.History)-1]
+	if len(imported.History) != 2 || last.Event != evImported || last.Other != a.ID || last.Reason != protocol.TransferRecusal {
+		t.Fatalf("history of the imported lawsuit: %+v", imported.History)
+	}
+
+	// the transfer repeated after a fault finds the lawsuit already imported,
+	// also after a restart of the target trial
+	seq := dst.state.JournalSeq
+	again, err := dst.ImportLawsuit(exported, recusal)
+	if err != nil || again.ID != imported.ID || dst.state.JournalSeq != seq {
+		t.Fatalf("import repeated: %+v %v (journal %d -> %d)", again, err, seq, dst.state.JournalSeq)
+	}
+	closeTestTrial(dst)
+	dst = reopenTestTrial(t, dstPath)
+	again, err = dst.ImportLawsuit(exported, recusal)
+	if err != nil || again.ID != imported.ID {
+		t.Fatalf("import repeated after the restart: %+v %v", again, err)
+	}
+	if n := len(dst.GetActives()); n != 2 {
+		t.Fatalf("%d actives in the target, want 2", n)
+	}
+
+	moved, err := src.MarkTransferred(a.ID, imported.ID, recusal)
+	if err != nil || moved.Status != StatusTransferred || moved.TransferredTo != imported.ID {
+		t.Fatalf("MarkTransferred: %+v %v", moved, err)
+	}
+	seq = src.state.JournalSeq
+	if repeated, err := src.MarkTransferred(a.ID, imported.ID, recusal); err != nil || repeated.TransferredTo != imported.ID || src.state.JournalSeq != seq {
+		t.Fatalf("MarkTransferred repeated: %+v %v", repeated, err)
+	}
+	if _, err := src.MarkTransferred(a.ID, "1.3.9", recusal); err == nil {
+		t.Fatal("lawsuit transferred twice to different IDs")
+	}
+	if _, err := src.ExportLawsuit(a.ID, protocol.TransferRecusal); err == nil {
+		t.Fatal("transferred lawsuit exported again")
+	}
+}
+
+// The lawsuits connected to the old ID are connected to the new one, in the
+// source trial and in the target
+func TestTransferRewritesConnected(t *testing.T) {
+	src, _ := newTestTrialID(t, 2)
+	dst, _ := newTestTrialID(t, 3)
+	a := mustCreate(t, src, "Alice", 1)
+	b := mustCreate(t, src, "Bob", 2)
+	c := mustCreate(t, src, "Carl", 3)
+	for _, other := range []string{b.ID, c.ID} {
+		if err := src.AddConnection(a.ID, other, protocol.Audit{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a goes first: b and c, left in src, point to its new ID
+	newA := mustTransfer(t, src, dst, a.ID, recusal)
+	if !sameStrs(newA.Connected, []string{b.ID, c.ID}) {
+		t.Fatalf("%s connected to %v, want %v", newA.ID, newA.Connected, []string{b.ID, c.ID})
+	}
+	for _, id := range []string{b.ID, c.ID} {
+		got := mustLookup(t, src, id)
+		if !sameStrs(got.Connected, []string{newA.ID}) {
+			t.Fatalf("%s connected to %v, want [%s]", id, got.Connected, newA.ID)
+		}
+		last := got.History[len(got.History)-1]
+		if last.Event != histConnectionMoved || last.From != a.ID || last.Other != newA.ID {
+			t.Fatalf("history of %s: %+v", id, last)
+		}
+	}
+
+	// then b: the lawsuit already in dst that was connected to b points to its new ID
+	newB := mustTransfer(t, src, dst, b.ID, recusal)
+	if !sameStrs(newB.Connected, []string{newA.ID}) {
+		t.Fatalf("%s connected to %v, want [%s]", newB.ID, newB.Connected, newA.ID)
+	}
+	if got := mustLookup(t, dst, newA.ID); !sameStrs(got.Connected, []string{newB.ID, c.ID}) {
+		t.Fatalf("%s connected to %v, want [%s %s]", newA.ID, got.Connected, newB.ID, c.ID)
+	}
+	if got := mustLookup(t, src, c.ID); !sameStrs(got.Connected, []string{newA.ID}) {
+		t.Fatalf("%s connected to %v", c.ID, got.Connected)
+	}
+}
+
+func TestTransferableStatuses(t *testing.T) {
+	for _, tc := range []struct {
+		status       string
+		recusal      bool // transferred for a recusal (pending only)
+		decommission bool // transferred when the trial is removed
+	}{
+		{StatusActive, true, true},
+		{StatusSuspended, true, true},
+		{StatusOnAppeal, true, true},
+		{StatusDisWithMerit, false, true},
+		{StatusDisWithoutMerit, false, true},
+		{StatusTransferred, false, false},
+	} {
+		t.Run(tc.status, func(t *testing.T) {
+			src, _ := newTestTrialID(t, 2)
+			a := lawsuitWithStatus(t, src, tc.status, 1)
+			for _, r := range []struct {
+				reason string
+				ok     bool
+			}{{protocol.TransferRecusal, tc.recusal}, {protocol.TransferDecommission, tc.decommission}} {
+				exported, err := src.ExportLawsuit(a.ID, r.reason)
+				if (err == nil) != r.ok {
+					t.Fatalf("export (%s): %v, want ok=%v", r.reason, err, r.ok)
+				}
+				if err != nil {
+					continue
+				}
+				dst, _ := newTestTrialID(t, 3)
+				imported, err := dst.ImportLawsuit(exported, protocol.Audit{Reason: r.reason})
+				if err != nil || imported.Status != tc.status {
+					t.Fatalf("import (%s): %+v %v", r.reason, imported, err)
+				}
+			}
+		})
+	}
+}
+
+// Lawsuits received by transfer count for the compensation while pending
+// and not active (the active ones are in the workload already)
+func TestCountPendingNotActive(t *testing.T) {
+	ts, _ := newTestTrial(t)
+	active := mustCreate(t, ts, "Alice", 1)
+	suspended := lawsuitWithStatus(t, ts, StatusSuspended, 2)
+	appeal := lawsuitWithStatus(t, ts, StatusOnAppeal, 3)
+	dismissed := lawsuitWithStatus(t, ts, StatusDisWithoutMerit, 4)
+	moved := lawsuitWithStatus(t, ts, StatusTransferred, 5)
+
+	ids := []string{active.ID, suspended.ID, appeal.ID, dismissed.ID, moved.ID, "1.9.9"}
+	if n := ts.CountPendingNotActive(ids); n != 2 {
+		t.Fatalf("CountPendingNotActive = %d, want 2 (%s and %s)", n, suspended.ID, appeal.ID)
+	}
+}
@@ -0,0 +1,2323 @@
+/***************************************************************************
+        Distributed Architecture for Judiciary Processes Distribution
+        ===== Trial Agent ====
+
+        Authors:
+                Antonio Gilberto de Moura (A - AGM)
+                Fernado Maurício Gomes (F - FMG)
+
+        Rel 1.1.0
+
+Revision History for court.go:
+
+   Release   Author   Date           Description
+    1.0.0    A/F      19/NoV/2025    Initial stable release
+    1.1.0    A        28/Jan/2026    Translation to English
+
+***************************************************************************/
+
+package trial
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"judiciary/internal/console"
+	"judiciary/internal/persist"
+	"judiciary/internal/protocol"
+	"judiciary/internal/query"
+	"judiciary/internal/storage"
+	"judiciary/internal/transport"
+)
+
+// Release identification
+const Release = "1.1.0"  // Translation to English
+
+// Identity of this trial in the envelopes (set in Main)
+var localSender = "trial"
+
+// Transport used with the district and to serve the requests (-transport flag)
+var tport transport.Transport = transport.UDP{}
+
+
+// ---------- Data Structures ----------
+
+// Lawsuit with ID "ID_District.ID_Trial.Sequence"
+// Now with claims list (Claims []int) and possible connected lawsuits list.
+// ClaimLegacy necessary only for read old files (where there was only one "claim" int)
+// and it is clean before saving again.
+type Lawsuit struct {
+	ID          string   `json:"id"`
+	Plaintiff   string   `json:"plaintiff"`
+	Defendant   string   `json:"defendant"`
+	CauseAction int      `json:"cause_action"`
+	Claims      []int    `json:"claims,omitempty"`
+	Connected   []string `json:"connected,omitempty"`
+
+	// Filing (distribution) date, in the first trial for a transferred lawsuit; zero
+	// for lawsuits registered before dates were kept
+	FiledAt time.Time `json:"filed_at,omitzero"`
+
+	// Status (see status.go) and time of its last change (zero: unknown)
+	Status   string    `json:"status"`
+	StatusAt time.Time `json:"status_at,omitzero"`
+
+	// Changes of the lawsuit, in order (see history.go); a transferred lawsuit
+	// brings the history of the previous trials
+	History []HistoryEntry `json:"history,omitempty"`
+
+	// ID of the lawsuit in the trial it was transferred to / from (see transfer.go)
+	TransferredTo   string `json:"transferred_to,omitempty"`
+	TransferredFrom string `json:"transferred_from,omitempty"`
+
+	// Legacy field for migration of old files (where there was only one int "claim").
+	ClaimLegacy int      `json:"claim,omitempty"`
+}
+
+// Complete status for the trail (persistence in JSON)
+type TrialState struct {
+	DistrictID              int       `json:"district_id"`
+	DistrictName            string    `json:"district_name"`
+	TrialID                 int       `json:"trial_id"`
+	TrialAddr               string    `json:"trial_addr"`
+	NextSeq                 int       `json:"next_seq"`
+
+	// Every lawsuit of the trial, in filing order; the lists are views by status
+	Lawsuits []Lawsuit `json:"lawsuits"`
+
+	// Lists of the files of previous versions (moved to Lawsuits when loaded)
+	ActivesLawsuits         []Lawsuit `json:"actives_lawsuits,omitempty"`
+	LawsuitsDisWithMerit    []Lawsuit `json:"lawsuits_dismissed_with_merit,omitempty"`
+	LawsuitsDisWithoutMerit []Lawsuit `json:"lawsuits_dismissed_without_merit,omitempty"`
+
+	// Responses of the last mutating requests, by request ID (see once)
+	Requests []RequestRecord `json:"requests,omitempty"`
+
+	// Last commit of the journal included in this snapshot (see journal.go)
+	JournalSeq int64 `json:"journal_seq,omitempty"`
+}
+
+// Wrapper with mutex + file path
+type TrialStore struct {
+	mu       sync.RWMutex
+	state    TrialState
+	filePath string
+
+	// Indexes of the lists by ID, party, cause and claim (see index.go)
+	index *trialIndex
+
+	// Where the state is kept (JSON file + journal, or one record per lawsuit)
+	// and the events not yet committed (see journal.go)
+	layout  trialLayout
+	pending []journalEvent
+
+	// Error of a commit that could not be undone: no change is accepted
+	failed error
+}
+
+// Creates a new store with file (IDs will be filled by handshake / mirror).
+// The state is kept in store (nil: in the JSON file filePath).
+func NewTrialStore(filePath string, store storage.Backend) *TrialStore {
+	if store == nil {
+		store = storage.NewJSONFiles(map[string]string{keyLawsuits: filePath})
+	}
+	ts := &TrialStore{
+		state: TrialState{
+			DistrictID:   0,
+			DistrictName: "",
+			TrialID:      0,
+			TrialAddr:    "",
+			NextSeq:      1,
+			Lawsuits:     []Lawsuit{},
+		},
+		filePath: filePath,
+		index:    newTrialIndex(),
+	}
+	if store.Name() == storage.NameKV {
+		ts.layout = &recordsLayout{store: store}
+	} else {
+		ts.layout = &jsonLayout{store: store}
+	}
+	return ts
+}
+
+// Lawsuits migration with legacy field "claim" -> "claims"
+func migrateLegacyClaims(a *Lawsuit) {
+	if len(a.Claims) == 0 && a.ClaimLegacy != 0 {
+		a.Claims = []int{a.ClaimLegacy}
+		a.ClaimLegacy = 0
+	}
+}
+
+func (ts *TrialStore) Load() error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	return ts.layout.load(ts)
+}
+
+// State read from the storage (ts.mu must be locked)
+func (ts *TrialStore) setStateLocked(st TrialState) {
+	// Lists of previous versions -> statuses, in filing order
+	if n := len(st.ActivesLawsuits) + len(st.LawsuitsDisWithMerit) + len(st.LawsuitsDisWithoutMerit); n > 0 {
+		for _, l := range []struct {
+			lawsuits []Lawsuit
+			status   string
+		}{
+			{st.ActivesLawsuits, StatusActive},
+			{st.LawsuitsDisWithMerit, StatusDisWithMerit},
+			{st.LawsuitsDisWithoutMerit, StatusDisWithoutMerit},
+		} {
+			for _, a := range l.lawsuits {
+				a.Status = l.status
+				st.Lawsuits = append(st.Lawsuits, a)
+			}
+		}
+		sort.SliceStable(st.Lawsuits, func(i, j int) bool {
+			return compareLawsuitIDs(st.Lawsuits[i].ID, st.Lawsuits[j].ID) < 0
+		})
+		st.ActivesLawsuits, st.LawsuitsDisWithMerit, st.LawsuitsDisWithoutMerit = nil, nil, nil
+		log.Printf("[TRIAL] %d lawsuit(s) of the lists of a previous version moved to the statuses", n)
+	}
+
+	for i := range st.Lawsuits {
+		// Legacy claims migration
+		migrateLegacyClaims(&st.Lawsuits[i])
+		if st.Lawsuits[i].Status == "" {
+			st.Lawsuits[i].Status = StatusActive
+		}
+	}
+	if st.Lawsuits == nil {
+		st.Lawsuits = []Lawsuit{}
+	}
+
+	if st.NextSeq <= 0 {
+		st.NextSeq = 1
+	}
+	ts.state = st
+	ts.reindexLocked()
+}
+
+// Save the whole state (with the JSON file, the journal is emptied)
+func (ts *TrialStore) Save() error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	return ts.snapshotLocked()
+}
+
+func (ts *TrialStore) nextID() string {
+	seq := ts.state.NextSeq
+	ts.state.NextSeq++
+	return fmt.Sprintf("%d.%d.%d", ts.state.DistrictID, ts.state.TrialID, seq)
+}
+
+// ---------- Idempotent mutating requests ----------
+
+// The district retransmits lawsuit_create / lawsuit_merge_claims when the response
+// is lost; the trial answers the retransmissions with the response already given.
+const (
+	maxRememberedRequests = 1000
+	requestRecordTTL      = 24 * time.Hour
+)
+
+type RequestRecord struct {
+	ID       string          `json:"id"`
+	Type     string          `json:"type"`
+	At       time.Time       `json:"at"`
+	Response json.RawMessage `json:"response"`
+}
+
+func (ts *TrialStore) findRequestLocked(reqID string) *RequestRecord {
+	for i := len(ts.state.Requests) - 1; i >= 0; i-- {
+		if ts.state.Requests[i].ID == reqID {
+			return &ts.state.Requests[i]
+		}
+	}
+	return nil
+}
+
+// Runs the request only once per request ID and returns the coded response.
+// run is called with ts.mu locked and does not commit: its changes and the
+// record of the response are saved in one commit, so after a fault the
+// retransmission finds both or neither. If the commit fails, the changes are
+// undone and no response is given (the district retransmits). Without request
+// ID, the request is always executed.
+func (ts *TrialStore) once(reqID, reqType string, run func() any) ([]byte, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if reqID != "" {
+		if rec := ts.findRequestLocked(reqID); rec != nil {
+			log.Printf("[TRIAL] %s request_id=%s already answered: response repeated", reqType, reqID)
+			return rec.Response, nil
+		}
+	}
+
+	b, err := json.Marshal(run())
+	if err != nil {
+		ts.undoLocked(err)
+		return nil, err
+	}
+	if reqID != "" {
+		rec := RequestRecord{ID: reqID, Type: reqType, At: time.Now(), Response: b}
+		ts.recordLocked(journalEvent{Type: evRequest, Request: &rec})
+	}
+	if err := ts.commitLocked(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Keeps the record, dropping the expired ones and the oldest beyond the limit
+func (ts *TrialStore) rememberLocked(rec RequestRecord) {
+	limit := time.Now().Add(-requestRecordTTL)
+	kept := ts.state.Requests[:0]
+	for _, r := range ts.state.Requests {
+		if r.At.After(limit) {
+			kept = append(kept, r)
+		}
+	}
+	kept = append(kept, rec)
+	if len(kept) > maxRememberedRequests {
+		kept = kept[len(kept)-maxRememberedRequests:]
+	}
+	ts.state.Requests = kept
+}
+
+// Creates a new ACTIVE lawsuit (with claims' list and possible connected list);
+// by is kept in its history
+func (ts *TrialStore) CreateLawsuit(plaintiff, defendant string, cause int, claims []int, connected []string, by protocol.Audit) (Lawsuit, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	a := ts.createLocked(plaintiff, defendant, cause, claims, connected, by)
+
+	if err := ts.commitLocked(); err != nil {
+		return Lawsuit{}, err
+	}
+	return a, nil
+}
+
+// Appends the new lawsuit to the actives list (ts.mu must be locked; does not commit)
+func (ts *TrialStore) createLocked(plaintiff, defendant string, cause int, claims []int, connected []string, by protocol.Audit) Lawsuit {
+	id := ts.nextID()
+	a := Lawsuit{
+		ID:          id,
+		Plaintiff:   plaintiff,
+		Defendant:   defendant,
+		CauseAction: cause,
+		Claims:      append([]int(nil), claims...),
+		Connected:   append([]string(nil), connected...),
+		FiledAt:     time.Now().UTC(),
+	}
+	ts.recordLocked(journalEvent{Type: evCreated, At: a.FiledAt, Lawsuit: &a, NextSeq: ts.state.NextSeq, Audit: by})
+	return a
+}
+
+// Lawsuit that prevented a checked creation
+type CreateConflict struct {
+	Match     string // "res_judicata", "lis_pendens", "joinder_contained" or "joinder_continent"
+	LawsuitID string
+}
+
+// Creates a new ACTIVE lawsuit only if, under the store's lock, there is still no
+// identical lawsuit (res judicata / lis pendens) nor joinder for it in this trial.
+// For "connection", the connection with the related lawsuit is registered in the same operation.
+// If the checks fail, returns the conflict with the lawsuit that prevented the creation.
+// The reason and the related lawsuit are those of by.
+func (ts *TrialStore) CreateLawsuitChecked(q protocol.ActionQuery, by protocol.Audit) (Lawsuit, *CreateConflict, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	a, conflict := ts.createCheckedLocked(q, by)
+	if conflict != nil {
+		return Lawsuit{}, conflict, nil
+	}
+	if err := ts.commitLocked(); err != nil {
+		return Lawsuit{}, nil, err
+	}
+	return a, nil, nil
+}
+
+// See CreateLawsuitChecked (ts.mu must be locked; does not commit)
+func (ts *TrialStore) createCheckedLocked(q protocol.ActionQuery, by protocol.Audit) (Lawsuit, *CreateConflict) {
+	if found := ts.findIdenticalDwM("dis_with", q); len(found) > 0 {
+		return Lawsuit{}, &CreateConflict{Match: "res_judicata", LawsuitID: found[0].ID}
+	}
+	if found := ts.findIdenticalDwM("actives", q); len(found) > 0 {
+		return Lawsuit{}, &CreateConflict{Match: "lis_pendens", LawsuitID: found[0].ID}
+	}
+	if found := ts.findJoinder(q); len(found) > 0 {
+		return Lawsuit{}, &CreateConflict{Match: found[0].Kind, LawsuitID: found[0].Lawsuit.ID}
+	}
+
+	a := ts.createLocked(q.Plaintiff, q.Defendant, q.CauseID, q.Claims, nil, by)
+	if by.Reason == "connection" && by.Related != "" {
+		if err := ts.connectLocked(a.ID, by.Related, by); err != nil {
+			log.Printf("Error while registering connection between lawsuits (%s and %s): %v", a.ID, by.Related, err)
+		}
+		if ac, ok := ts.lookupLocked(a.ID); ok {
+			a = *ac
+		}
+	}
+	return a, nil
+}
+
+// Dismiss the lawsuit (-> dismissed WITH merit; see statusTransitions)
+func (ts *TrialStore) DismissWithMerit(id string, by protocol.Audit) (Lawsuit, error) {
+	return ts.SetStatus(id, StatusDisWithMerit, by)
+}
+
+// Dismiss the lawsuit (-> dismissed WITHOUT merit)
+func (ts *TrialStore) DismissWithoutmerit(id string, by protocol.Audit) (Lawsuit, error) {
+	return ts.SetStatus(id, StatusDisWithoutMerit, by)
+}
+
+// Copy for reading
+func (ts *TrialStore) GetActives() []Lawsuit {
+	return ts.GetByStatus(StatusActive)
+}
+
+func (ts *TrialStore) GetDisWithMerit() []Lawsuit {
+	return ts.GetByStatus(StatusDisWithMerit)
+}
+
+func (ts *TrialStore) GetDisWithoutMerit() []Lawsuit {
+	return ts.GetByStatus(StatusDisWithoutMerit)
+}
+
+func (ts *TrialStore) CountActives() int {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	return len(ts.index.byStatus[StatusActive])
+}
+
+// Lawsuits of ids that are still pending but not active (suspended, archived
+// provisionally or on appeal)
+func (ts *TrialStore) CountPendingNotActive(ids []string) int {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	n := 0
+	for _, id := range ids {
+		if a, ok := ts.lookupLocked(id); ok && a.Status != StatusActive && hasStr(pendingStatuses, a.Status) {
+			n++
+		}
+	}
+	return n
+}
+
+func (ts *TrialStore) GetTrialAddr() string {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	return ts.state.TrialAddr
+}
+
+func (ts *TrialStore) GetIDs() (int, int) {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	return ts.state.DistrictID, ts.state.TrialID
+}
+
+func (ts *TrialStore) GetDistrictName() string {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	return ts.state.DistrictName
+}
+
+// Update IDs, district's name and trial's address, saving in disc (mirror)
+func (ts *TrialStore) UpdateInfo(districtID int, districtName string, trialID int, trialAddr string) error {
+	ts.mu.Lock()
+	if districtID > 0 {
+		ts.state.DistrictID = districtID
+	}
+	if strings.TrimSpace(districtName) != "" {
+		ts.state.DistrictName = strings.TrimSpace(districtName)
+	}
+	if trialID > 0 {
+		ts.state.TrialID = trialID
+	}
+	if strings.TrimSpace(trialAddr) != "" {
+		ts.state.TrialAddr = strings.TrimSpace(trialAddr)
+	}
+	if ts.state.NextSeq <= 0 {
+		ts.state.NextSeq = 1
+	}
+	err := ts.snapshotLocked()
+	ts.mu.Unlock()
+	return err
+}
+
+// Update claims of one existent lawsuit (joinder - gathering of lawsuits) 
+func (ts *TrialStore) AddClaims(LawsuitID string, newClaims []int, by protocol.Audit) error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if err := ts.addClaimsLocked(LawsuitID, newClaims, by); err != nil {
+		return err
+	}
+	return ts.commitLocked()
+}
+
+// ts.mu must be locked; does not commit
+func (ts *TrialStore) addClaimsLocked(LawsuitID string, newClaims []int, by protocol.Audit) error {
+	if a, ok := ts.lookupLocked(LawsuitID); !ok || a.Status != StatusActive {
+		return fmt.Errorf("lawsuit %s not found between the actives lawsuits for claims' merge", LawsuitID)
+	}
+	ts.recordLocked(journalEvent{Type: evClaimsMerged, ID: LawsuitID, Claims: append([]int(nil), newClaims...), Audit: by})
+	return nil
+}
+
+// Add connection link between two lawsuits (bidirectional, if possible)
+func (ts *TrialStore) AddConnection(LawsuitID string, otherID string, by protocol.Audit) error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if err := ts.connectLocked(LawsuitID, otherID, by); err != nil {
+		return err
+	}
+	return ts.commitLocked()
+}
+
+// Connection link between two lawsuits (ts.mu must be locked; does not commit)
+func (ts *TrialStore) connectLocked(LawsuitID string, otherID string, by protocol.Audit) error {
+	if a, ok := ts.lookupLocked(LawsuitID); !ok || a.Status != StatusActive {
+		return fmt.Errorf("lawsuit %s not found for connection", LawsuitID)
+	}
+	// both ends are linked if the other is active here too (see applyLocked)
+	ts.recordLocked(journalEvent{Type: evConnected, ID: LawsuitID, Other: otherID, Audit: by})
+	return nil
+}
+
+
+// ---------- Search in all lists (for the "Search lawsuit" menu) ----------
+
+type SearchResult struct {
+	List     string
+	Lawsuit  Lawsuit
+}
+
+func (ts *TrialStore) SearchLawsuits(field, value string) ([]SearchResult, error) {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+
+	match := func(a Lawsuit) bool {
+		switch field {
+		case "id":
+			return strings.EqualFold(a.ID, value)
+		case "plaintiff":
+			return strings.Contains(strings.ToLower(a.Plaintiff), strings.ToLower(value))
+		case "defendant":
+			return strings.Contains(strings.ToLower(a.Defendant), strings.ToLower(value))
+		case "cause":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return false
+			}
+			return a.CauseAction == n
+		case "claim":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return false
+			}
+			for _, p := range a.Claims {
+				if p == n {
+					return true
+				}
+			}
+			return false
+		default:
+			return false
+		}
+	}
+
+	return ts.searchIDsLocked(ts.searchCandidatesLocked(field, value), func(list string, a Lawsuit) bool { return match(a) }), nil
+}
+
+// Lawsuits that can match the search by field (from the indexes; superset of the results)
+func (ts *TrialStore) searchCandidatesLocked(field, value string) idSet {
+	ids := idSet{}
+	switch field {
+	case "id":
+		// IDs are "district.trial.seq" (digits): no case to ignore
+		if _, ok := ts.index.byID[value]; ok {
+			ids[value] = struct{}{}
+		}
+	case "plaintiff", "defendant":
+		// the parties repeat between lawsuits: the names are much less than the lawsuits
+		v := strings.ToLower(value)
+		for key, set := range ts.index.byParty {
+			if strings.Contains(strings.ToLower(key), v) {
+				for id := range set {
+					ids[id] = struct{}{}
+				}
+			}
+		}
+	case "cause", "claim":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return ids
+		}
+		byNum := ts.index.byCause
+		if field == "claim" {
+			byNum = ts.index.byClaim
+		}
+		for id := range byNum[n] {
+			ids[id] = struct{}{}
+		}
+	}
+	return ids
+}
+
+// Search with a compound query (package query), e.g. "defendant contains Banco AND cause=12"
+func (ts *TrialStore) SearchQuery(q query.Node) []SearchResult {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+
+	return ts.searchLocked(func(list string, a Lawsuit) bool {
+		return q.Match(query.Record{
+			ID:        a.ID,
+			Plaintiff: a.Plaintiff,
+			Defendant: a.Defendant,
+			Cause:     a.CauseAction,
+			Claims:    a.Claims,
+			List:      list,
+		})
+	})
+}
+
+// Lawsuits of every list accepted by match (in the order of the lists)
+func (ts *TrialStore) searchLocked(match func(list string, a Lawsuit) bool) []SearchResult {
+	return inListOrder(ts.state.Lawsuits, match)
+}
+
+// Lawsuits of the candidates accepted by match (in the order of the lists, as searchLocked)
+func (ts *TrialStore) searchIDsLocked(ids idSet, match func(list string, a Lawsuit) bool) []SearchResult {
+	return inListOrder(ts.collectLocked(ids, statusOrder...), match)
+}
+
+// Lawsuits accepted by match, list by list (each in filing order)
+func inListOrder(lawsuits []Lawsuit, match func(list string, a Lawsuit) bool) []SearchResult {
+	byStatus := map[string][]SearchResult{}
+	for _, a := range lawsuits {
+		if list := statusLabel(a.Status); match(list, a) {
+			byStatus[a.Status] = append(byStatus[a.Status], SearchResult{List: list, Lawsuit: a})
+		}
+	}
+	results := []SearchResult{}
+	for _, st := range statusOrder {
+		results = append(results, byStatus[st]...)
+	}
+	return results
+}
+
+// ---------- Search pages (sort and cursor) ----------
+
+// Largest page accepted (limit of the request)
+const maxSearchPage = 1000
+
+// Position after the last result of a page (opaque for the district)
+type searchCursor struct {
+	Sort string `json:"s"`
+	Key  string `json:"k"`
+	ID   string `json:"i"`
+}
+
+// Order of the lists when sorting by list
+var listOrder = func() map[string]int {
+	order := map[string]int{}
+	for i, st := range statusOrder {
+		order[statusLabel(st)] = i
+	}
+	return order
+}()
+
+func searchSortKey(r SearchResult, sortBy string) string {
+	switch sortBy {
+	case protocol.SortByList:
+		return strconv.Itoa(listOrder[r.List])
+	case protocol.SortByPlaintiff:
+		return strings.ToLower(r.Lawsuit.Plaintiff)
+	}
+	return ""
+}
+
+// Numeric comparison of IDs "district.trial.sequence"
+func compareLawsuitIDs(a, b string) int {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		na, errA := strconv.Atoi(pa[i])
+		nb, errB := strconv.Atoi(pb[i])
+		if errA != nil || errB != nil {
+			if c := strings.Compare(pa[i], pb[i]); c != 0 {
+				return c
+			}
+			continue
+		}
+		if na != nb {
+			if na < nb {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(pa) - len(pb)
+}
+
+func compareSearchPos(key, id, otherKey, otherID string) int {
+	if c := strings.Compare(key, otherKey); c != 0 {
+		return c
+	}
+	return compareLawsuitIDs(id, otherID)
+}
+
+// Sort the results and cut the page after cursor; returns the page and the cursor
+// of the next page ("" in the last one). limit <= 0 returns every result.
+func pageSearchResults(results []SearchResult, sortBy, cursor string, limit int) ([]SearchResult, string, error) {
+	if sortBy == "" {
+		sortBy = protocol.SortByID
+	}
+	if sortBy != protocol.SortByID && sortBy != protocol.SortByList && sortBy != protocol.SortByPlaintiff {
+		return nil, "", fmt.Errorf("unknown sort %q", sortBy)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return compareSearchPos(searchSortKey(results[i], sortBy), results[i].Lawsuit.ID,
+			searchSortKey(results[j], sortBy), results[j].Lawsuit.ID) < 0
+	})
+
+	start := 0
+	if cursor != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(cursor)
+		var c searchCursor
+		if err == nil {
+			err = json.Unmarshal(raw, &c)
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid cursor")
+		}
+		if c.Sort != sortBy {
+			return nil, "", fmt.Errorf("cursor of a search sorted by %q", c.Sort)
+		}
+		// first result after the cursor (lawsuits created or removed between
+		// the pages do not shift the following ones)
+		start = sort.Search(len(results), func(i int) bool {
+			return compareSearchPos(searchSortKey(results[i], sortBy), results[i].Lawsuit.ID, c.Key, c.ID) > 0
+		})
+	}
+	if limit > maxSearchPage {
+		limit = maxSearchPage
+	}
+	if limit <= 0 || start+limit >= len(results) {
+		return results[start:], "", nil
+	}
+
+	page := results[start : start+limit]
+	last := page[len(page)-1]
+	raw, _ := json.Marshal(searchCursor{Sort: sortBy, Key: searchSortKey(last, sortBy), ID: last.Lawsuit.ID})
+	return page, base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+
+// ---------- Aux functions for claims comparation ----------
+
+func sameIntSet(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	m := make(map[int]int, len(a))
+	for _, x := range a {
+		m[x]++
+	}
+	for _, x := range b {
+		if m[x] == 0 {
+			return false
+		}
+		m[x]--
+	}
+	for _, v := range m {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func isSubset(sub, sup []int) bool {
+	if len(sub) > len(sup) {
+		return false
+	}
+	setSup := make(map[int]bool, len(sup))
+	for _, x := range sup {
+		setSup[x] = true
+	}
+	for _, x := range sub {
+		if !setSup[x] {
+			return false
+		}
+	}
+	return true
+}
+
+// Claims of a that are also in b (in the order of a)
+func intersection(a, b []int) []int {
+	set := make(map[int]bool, len(b))
+	for _, x := range b {
+		set[x] = true
+	}
+	var res []int
+	for _, x := range a {
+		if set[x] {
+			res = append(res, x)
+			delete(set, x)
+		}
+	}
+	return res
+}
+
+func hasOverlap(a, b []int) bool {
+	set := make(map[int]bool, len(a))
+	for _, x := range a {
+		set[x] = true
+	}
+	for _, x := range b {
+		if set[x] {
+			return true
+		}
+	}
+	return false
+}
+
+
+// ---------- District address persistence ----------
+
+const districtAddrFile = "district_addr.txt"
+
+func loadDistrictAddress(path string) string {
+	addr, err := persist.LoadLine(path)
+	if err != nil {
+		log.Printf("Error while reading district address file (%s): %v", path, err)
+	}
+	return addr
+}
+
+func saveDistrictAddress(path, addr string) {
+	if err := persist.SaveLine(path, addr); err != nil {
+		log.Printf("Error while saving the district address in %s: %v", path, err)
+	}
+}
+
+
+// ---------- Protocol with the district (initial handshake) ----------
+
+// Try to get (from district) DistricID, DistrictName, TrialID and TrialAddr.
+// If error, log only; do not stop the initialization. The only error returned
+// is the district's refusal of a removed trial ID (IDs are not reused).
+func getInfoFromDistrict(districtAddr string, trialID int, ts *TrialStore) error {
+	if trialID <= 0 {
+		log.Printf("getInfoFromDistrict: Invalid trialID (%d);  it is not possible to verify the district.", trialID)
+		return nil
+	}
+
+	req := protocol.DistrictInfoRequest{
+		Envelope: protocol.NewEnvelope(localSender, ""),
+		Type:     "trial_info",
+		TrialID:  trialID,
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		log.Printf("Error while decoding JSON for district: %v", err)
+		return nil
+	}
+
+	log.Printf("[TRIAL->DISTRICT] %s - trace=%s sending trial_info (TrialID=%d) to %s",
+		time.Now().Format(time.RFC3339), req.TraceID, trialID, districtAddr)
+
+	reply, err := protocol.Exchange(tport, districtAddr, data, req.MsgID, time.Now().Add(2*time.Second))
+	if err != nil {
+		log.Printf("Error while receiving response from district: %v", err)
+		return nil
+	}
+
+	var resp protocol.DistrictInfoResponse
+	if err := json.Unmarshal(reply, &resp); err != nil {
+		log.Printf("Error while decoding response from district: %v", err)
+		return nil
+	}
+
+	if !resp.Success {
+		log.Printf("District responded with error in the trial_info: %s", resp.Message)
+		if resp.Retired {
+			return fmt.Errorf("%s", resp.Message)
+		}
+		return nil
+	}
+
+	log.Printf("[DISTRICT->TRIAL] %s - trace=%s trial_info OK: DistrictID=%d, DistrictName=%q, TrialID=%d, TrialAddr=%q",
+		time.Now().Format(time.RFC3339), req.TraceID,
+		resp.DistrictID, resp.DistrictName, resp.TrialID, resp.TrialAddr,
+	)
+
+	if err := ts.UpdateInfo(resp.DistrictID, resp.DistrictName, resp.TrialID, resp.TrialAddr); err != nil {
+		log.Printf("Error while updating IDs' local mirror of trial: %v", err)
+	}
+	return nil
+}
+
+
+// ---------- Search logic for rules 1 to 5 in the trial ----------
+
+// The find* functions return EVERY lawsuit that matches (in list order)
+// and must be called with ts.mu held (see TrialRule)
+
+func (ts *TrialStore) findIdenticalDwM(list string, q protocol.ActionQuery) []Lawsuit {
+	match := func(a Lawsuit) bool {
+		return strings.EqualFold(a.Plaintiff, q.Plaintiff) &&
+			strings.EqualFold(a.Defendant, q.Defendant) &&
+			a.CauseAction == q.CauseID &&
+			sameIntSet(a.Claims, q.Claims)
+	}
+
+	var lawsuits []Lawsuit
+	candidates := ts.samePartiesLocked(q.Plaintiff, q.Defendant, q.CauseID)
+	switch list {
+	case "dis_with":
+		// res judicata: merit judgment not under appeal
+		lawsuits = ts.collectLocked(candidates, StatusDisWithMerit)
+	case "dis_without":
+		lawsuits = ts.collectLocked(candidates, StatusDisWithoutMerit)
+	case "actives":
+		// lis pendens: still pending, even if suspended, archived or on appeal
+		lawsuits = ts.collectLocked(candidates, pendingStatuses...)
+	}
+
+	var found []Lawsuit
+	for _, a := range lawsuits {
+		if match(a) {
+			found = append(found, a)
+		}
+	}
+	return found
+}
+
+// Joinder found for one existent lawsuit
+type joinderMatch struct {
+	Kind    string // "joinder_contained" or "joinder_continent"
+	Lawsuit Lawsuit
+}
+
+// Joinder (continence): same parts (plaintiff, defendant), same cause of action,
+// but claims with a set relationship (contained/continent).
+// Kind:
+//   - "joinder_contained": the new lawsuit is CONTAINED in the existent one (does not create a new lawsuit).
+//   - "joinder_continent": the new lawsuit is CONTINENT (it is necessay to merge the claims into existent lawsuit).
+func (ts *TrialStore) findJoinder(q protocol.ActionQuery) []joinderMatch {
+	var found []joinderMatch
+	for _, a := range ts.collectLocked(ts.samePartiesLocked(q.Plaintiff, q.Defendant, q.CauseID), StatusActive) {
+		if !strings.EqualFold(a.Plaintiff, q.Plaintiff) {
+			continue
+		}
+		if !strings.EqualFold(a.Defendant, q.Defendant) {
+			continue
+		}
+		if a.CauseAction != q.CauseID {
+			continue
+		}
+
+		// equal -> already treated in the previous rules
+		if sameIntSet(a.Claims, q.Claims) {
+			continue
+		}
+
+		if isSubset(q.Claims, a.Claims) {
+			found = append(found, joinderMatch{Kind: "joinder_contained", Lawsuit: a})
+		} else if isSubset(a.Claims, q.Claims) {
+			found = append(found, joinderMatch{Kind: "joinder_continent", Lawsuit: a})
+		}
+	}
+
+	return found
+}
+
+// Connection: same cause of action and/or common claims (ACTIVES lawsuits),
+// BUT **CANNOT** be the case of same parts + cause of action,
+// because these cases are reserved as JOINDER
+func (ts *TrialStore) findConnection(q protocol.ActionQuery) []Lawsuit {
+	// candidates: same cause or some common claim
+	candidates := idSet{}
+	for id := range ts.index.byCause[q.CauseID] {
+		candidates[id] = struct{}{}
+	}
+	for _, c := range q.Claims {
+		for id := range ts.index.byClaim[c] {
+			candidates[id] = struct{}{}
+		}
+	}
+
+	var found []Lawsuit
+	for _, a := range ts.collectLocked(candidates, StatusActive) {
+		// 1) If have SAME plaintiff, SAME defendant and SAME cause,
+		//    this case must be treated in the JOINDER rule,
+		//    not in the connection. Jum here.
+		if strings.EqualFold(a.Plaintiff, q.Plaintiff) &&
+			strings.EqualFold(a.Defendant, q.Defendant) &&
+			a.CauseAction == q.CauseID {
+			continue
+		}
+
+		// 2) Rule for connection properly speaking:
+		sameCause := (a.CauseAction == q.CauseID)
+		commonClaims := hasOverlap(a.Claims, q.Claims)
+
+		if sameCause || commonClaims {
+			found = append(found, a)
+		}
+	}
+	return found
+}
+
+
+// ---------- Distribution rules (stages) evaluated by the trial ----------
+
+// Rule that matches a lawsuit query against the TrialStore for one stage.
+// Match is called with ts.mu read locked and returns one entry for EACH lawsuit
+// found (Match, Message, LawsuitID and the stage specific fields filled).
+type TrialRule interface {
+	Stage() string
+	Match(ts *TrialStore, q protocol.ActionQuery) []protocol.TrialActionQueryResponse
+}
+
+// Registry of the rules known by the trial (stage -> rule)
+var trialRules = map[string]TrialRule{}
+
+// Registration order, used when the district does not inform the stages
+var trialRulesOrder []string
+
+func registerTrialRule(r TrialRule) {
+	if _, ok := trialRules[r.Stage()]; !ok {
+		trialRulesOrder = append(trialRulesOrder, r.Stage())
+	}
+	trialRules[r.Stage()] = r
+}
+
+func init() {
+	registerTrialRule(resJudicataRule{})
+	registerTrialRule(lisPendensRule{})
+	registerTrialRule(repeatedRequestRule{})
+	registerTrialRule(joinderRule{})
+	registerTrialRule(connectionRule{})
+}
+
+// 1) Res judicata: identical lawsuit dismissed WITH merit judgment (not on appeal)
+type resJudicataRule struct{}
+
+func (resJudicataRule) Stage() string { return protocol.StageResJudicata }
+
+func (resJudicataRule) Match(ts *TrialStore, q protocol.ActionQuery) []protocol.TrialActionQueryResponse {
+	var matches []protocol.TrialActionQueryResponse
+	for _, a := range ts.findIdenticalDwM("dis_with", q) {
+		matches = append(matches, protocol.TrialActionQueryResponse{
+			Match:     "res_judicata",
+			Message:   "identical lawsuit found in dismissed whith prejudice (merit judgment -> res judicata).",
+			LawsuitID: a.ID,
+			FiledAt:   a.FiledAt,
+		})
+	}
+	return matches
+}
+
+// 2) Lis pendens: identical lawsuit still PENDING (active, suspended, archived provisionally or on appeal)
+type lisPendensRule struct{}
+
+func (lisPendensRule) Stage() string { return protocol.StageLisPendens }
+
+func (lisPendensRule) Match(ts *TrialStore, q protocol.ActionQuery) []protocol.TrialActionQueryResponse {
+	var matches []protocol.TrialActionQueryResponse
+	for _, a := range ts.findIdenticalDwM("actives", q) {
+		matches = append(matches, protocol.TrialActionQueryResponse{
+			Match:     "lis_pendens",
+			Message:   fmt.Sprintf("identical lawsuit found in pending lawsuits (lis pendens; %s).", strings.ToLower(statusLabel(a.Status))),
+			LawsuitID: a.ID,
+			FiledAt:   a.FiledAt,
+		})
+	}
+	return matches
+}
+
+// 3) Repeated request: identical lawsuit dismissed WITHOUT merit judgment
+type repeatedRequestRule struct{}
+
+func (repeatedRequestRule) Stage() string { return protocol.StageRepeatedRequest }
+
+func (repeatedRequestRule) Match(ts *TrialStore, q protocol.ActionQuery) []protocol.TrialActionQueryResponse {
+	var matches []protocol.TrialActionQueryResponse
+	for _, a := range ts.findIdenticalDwM("dis_without", q) {
+		matches = append(matches, protocol.TrialActionQueryResponse{
+			Match:     "repeated_request",
+			Message:   "identical lawsuit found in dismissed without prejudice (no merit judgment -> repeated request).",
+			LawsuitID: a.ID,
+			FiledAt:   a.FiledAt,
+		})
+	}
+	return matches
+}
+
+// 4) Joinder: same parties and cause, claims contained/continent
+type joinderRule struct{}
+
+func (joinderRule) Stage() string { return protocol.StageJoinder }
+
+func (joinderRule) Match(ts *TrialStore, q protocol.ActionQuery) []protocol.TrialActionQueryResponse {
+	var matches []protocol.TrialActionQueryResponse
+	for _, j := range ts.findJoinder(q) {
+		m := protocol.TrialActionQueryResponse{
+			Match:          j.Kind,
+			LawsuitID:      j.Lawsuit.ID,
+			FiledAt:        j.Lawsuit.FiledAt,
+			ExistentClaims: append([]int(nil), j.Lawsuit.Claims...),
+			OverlapClaims:  intersection(j.Lawsuit.Claims, q.Claims),
+			SharedCause:    true,
+		}
+		switch j.Kind {
+		case "joinder_contained":
+			m.Message = "the new lawsuit is CONTAINED in the already existent lawsuit (smaller claim)."
+		case "joinder_continent":
+			m.Message = "the new lawsuit is CONTINENT in relation with already existent lawsuit (bigger claim)."
+		}
+		matches = append(matches, m)
+	}
+	return matches
+}
+
+// 5) Connection: same cause of action and/or common claims
+type connectionRule struct{}
+
+func (connectionRule) Stage() string { return protocol.StageConnection }
+
+func (connectionRule) Match(ts *TrialStore, q protocol.ActionQuery) []protocol.TrialActionQueryResponse {
+	var matches []protocol.TrialActionQueryResponse
+	for _, a := range ts.findConnection(q) {
+		matches = append(matches, protocol.TrialActionQueryResponse{
+			Match:             "connection",
+			Message:           "found a connected lawsuit (same cause of action and/or common claims).",
+			LawsuitID:         a.ID,
+			FiledAt:           a.FiledAt,
+			ConnectedLawsuits: append([]string(nil), a.Connected...),
+			OverlapClaims:     intersection(a.Claims, q.Claims),
+			SharedCause:       a.CauseAction == q.CauseID,
+		})
+	}
+	return matches
+}
+
+
+// ---------- Handlers: lawsuit_query / lawsuit_classify / lawsuit_create / lawsuit_merge_claims ----------
+
+// History's data of a change asked by a district (without actor: the sender)
+func auditOf(env protocol.Envelope, actor, district, reason, related string) protocol.Audit {
+	if actor == "" {
+		actor = env.Sender
+	}
+	return protocol.Audit{Actor: actor, District: district, Reason: reason, Related: related, Filing: env.TraceID}
+}
+
+func handleLawsuitQuery(w transport.Replier, data []byte, ts *TrialStore) {
+	var req protocol.TrialActionQueryRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		log.Printf("Error while decoding TrialActionQueryRequest from %s: %v", w.Remote(), err)
+		return
+	}
+
+	districtID, trialID := ts.GetIDs()
+	districtName := ts.GetDistrictName()
+	trialAddr := ts.GetTrialAddr()
+
+	resp := protocol.TrialActionQueryResponse{
+		Envelope:     req.Reply(localSender),
+		Success:     true,
+		Stage:       req.Stage,
+		Match:       "none",
+		Message:     "no corresponding lawsuit found in this trial",
+		DistrictID:   districtID,
+		DistrictName: districtName,
+		TrialID:      trialID,
+		TrialAddr:    trialAddr,
+	}
+
+	rule, ok := trialRules[req.Stage]
+	if !ok {
+		resp.Success = false
+		resp.Message = "unknown stage in the lawsuit_query"
+	} else {
+		ts.mu.RLock()
+		matches := rule.Match(ts, req.Lawsuit)
+		ts.mu.RUnlock()
+
+		// lawsuit_query answers only the first lawsuit found (see lawsuit_classify)
+		if len(matches) > 0 {
+			m := matches[0]
+			resp.Match = m.Match
+			resp.Message = m.Message
+			resp.LawsuitID = m.LawsuitID
+			resp.FiledAt = m.FiledAt
+			resp.ExistentClaims = m.ExistentClaims
+			resp.ConnectedLawsuits = m.ConnectedLawsuits
+			resp.OverlapClaims = m.OverlapClaims
+			resp.SharedCause = m.SharedCause
+		}
+	}
+
+	b, err := json.Marshal(resp)
+	if err != nil {
+		log.Printf("Error while decoding TrialActionQueryResponse from %s: %v", w.Remote(), err)
+		return
+	}
+	if err := w.Reply(b); err != nil {
+		log.Printf("Error while sending the response lawsuit_query to %s: %v", w.Remote(), err)
+		return
+	}
+
+	log.Printf("[TRIAL] trace=%s lawsuit_query stage=%s match=%s to %s (lawsuit_id=%s)",
+		req.TraceID, resp.Stage, resp.Match, w.Remote(), resp.LawsuitID)
+}
+
+func handleLawsuitClassify(w transport.Replier, data []byte, ts *TrialStore) {
+	var req protocol.TrialClassifyRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		log.Printf("Error while decoding TrialClassifyRequest from %s: %v", w.Remote(), err)
+		return
+	}
+
+	districtID, trialID := ts.GetIDs()
+	districtName := ts.GetDistrictName()
+	trialAddr := ts.GetTrialAddr()
+
+	resp := protocol.TrialClassifyResponse{
+		Envelope:     req.Reply(localSender),
+		Success:      true,
+		DistrictID:   districtID,
+		DistrictName: districtName,
+		TrialID:      trialID,
+		TrialAddr:    trialAddr,
+		Stages:       []protocol.StageMatches{},
+	}
+
+	stages := req.Stages
+	if len(stages) == 0 {
+		stages = trialRulesOrder
+	}
+
+	rules := make([]TrialRule, 0, len(stages))
+	for _, stage := range stages {
+		rule, ok := trialRules[stage]
+		if !ok {
+			resp.Success = false
+			resp.Message = fmt.Sprintf("unknown stage %q in the lawsuit_classify", stage)
+			break
+		}
+		rules = append(rules, rule)
+	}
+
+	if resp.Success {
+		total := 0
+
+		// All the stages in one pass, with the same view of the lists
+		ts.mu.RLock()
+		for _, rule := range rules {
+			matches := rule.Match(ts, req.Lawsuit)
+			for i := range matches {
+				matches[i].Success = true
+				matches[i].Stage = rule.Stage()
+				matches[i].DistrictID = districtID
+				matches[i].DistrictName = districtName
+				matches[i].TrialID = trialID
+				matches[i].TrialAddr = trialAddr
+			}
+			total += len(matches)
+			resp.Stages = append(resp.Stages, protocol.StageMatches{Stage: rule.Stage(), Matches: matches})
+		}
+		ts.mu.RUnlock()
+
+		resp.Message = fmt.Sprintf("%d stages evaluated, %d matches found", len(rules), total)
+	}
+
+	b, err := json.Marshal(resp)
+	if err != nil {
+		log.Printf("Error while coding TrialClassifyResponse to %s: %v", w.Remote(), err)
+		return
+	}
+	if err := w.Reply(b); err != nil {
+		log.Printf("Error while sending the response lawsuit_classify to %s: %v", w.Remote(), err)
+		return
+	}
+
+	log.Printf("[TRIAL] trace=%s lawsuit_classify stages=%v success=%v msg=%q to %s",
+		req.TraceID, stages, resp.Success, resp.Message, w.Remote())
+}
+
+func handleLawsuitCreate(w transport.Replier, data []byte, ts *TrialStore) {
+	var req protocol.TrialCreateActionRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		log.Printf("Error while decoding TrialCreateActionRequest from %s: %v", w.Remote(), err)
+		return
+	}
+
+	b, err := ts.once(req.RequestID, req.Type, func() any { return lawsuitCreate(ts, req) })
+	if err != nil {
+		log.Printf("Error while executing lawsuit_create for %s: %v", w.Remote(), err)
+		return
+	}
+	if err := w.Reply(b); err != nil {
+		log.Printf("Error while sending response lawsuit_create to %s: %v", w.Remote(), err)
+		return
+	}
+
+	log.Printf("[TRIAL] trace=%s lawsuit_create reason=%s request_id=%s to %s",
+		req.TraceID, req.Reason, req.RequestID, w.Remote())
+}
+
+// Executed by once (ts.mu locked, the changes are committed with the response)
+func lawsuitCreate(ts *TrialStore, req protocol.TrialCreateActionRequest) protocol.TrialCreateActionResponse {
+	resp := protocol.TrialCreateActionResponse{
+		Envelope:     req.Reply(localSender),
+		Success:      false,
+		Message:      "",
+		DistrictID:   ts.state.DistrictID,
+		DistrictName: ts.state.DistrictName,
+		TrialID:      ts.state.TrialID,
+		TrialAddr:    ts.state.TrialAddr,
+	}
+
+	if req.Lawsuit.Plaintiff == "" || req.Lawsuit.Defendant == "" || req.Lawsuit.CauseID == 0 || len(req.Lawsuit.Claims) == 0 {
+		resp.Message = "iInsufficient data for the lawsuit in the lawsuit_create"
+	} else {
+		by := auditOf(req.Envelope, req.Actor, req.District, req.Reason, req.Related)
+		new_lawsuit := ts.createLocked(
+			req.Lawsuit.Plaintiff,
+			req.Lawsuit.Defendant,
+			req.Lawsuit.CauseID,
+			req.Lawsuit.Claims,
+			nil,
+			by,
+		)
+		resp.Success = true
+		resp.LawsuitID = new_lawsuit.ID
+		switch req.Reason {
+		case "free":
+			resp.Message = "lawsuit created by free distribution"
+		case "repeated_request":
+			resp.Message = fmt.Sprintf("lawsuit created as REPEATED REQUEST (related to the lawsuit %s)", req.Related)
+		case "connection":
+			resp.Message = fmt.Sprintf("lawsuit created as CONNECTED to the lawsuit %s", req.Related)
+			if req.Related != "" {
+				if err := ts.connectLocked(new_lawsuit.ID, req.Related, by); err != nil {
+					log.Printf("Error while registering connection between lawsuits (%s and %s): %v", new_lawsuit.ID, req.Related, err)
+				}
+			}
+		default:
+			resp.Message = "lawsuit created"
+		}
+	}
+
+	log.Printf("[TRIAL] trace=%s lawsuit_create reason=%s success=%v lawsuit_id=%s",
+		req.TraceID, req.Reason, resp.Success, resp.LawsuitID)
+	return resp
+}
+
+// Check-and-create in one message: the identity and joinder checks are executed
+// again under the store's lock, so no other district can create an identical
+// lawsuit between the verification and the creation.
+func handleLawsuitCreateChecked(w transport.Replier, data []byte, ts *TrialStore) {
+	var req protocol.TrialCreateActionRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		log.Printf("Error while decoding TrialCreateActionRequest (checked) from %s: %v", w.Remote(), err)
+		return
+	}
+
+	b, err := ts.once(req.RequestID, req.Type, func() any { return lawsuitCreateChecked(ts, req) })
+	if err != nil {
+		log.Printf("Error while executing lawsuit_create_checked for %s: %v", w.Remote(), err)
+		return
+	}
+	if err := w.Reply(b); err != nil {
+		log.Printf("Error while sending response lawsuit_create_checked to %s: %v", w.Remote(), err)
+		return
+	}
+
+	log.Printf("[TRIAL] trace=%s lawsuit_create_checked reason=%s request_id=%s to %s",
+		req.TraceID, req.Reason, req.RequestID, w.Remote())
+}
+
+// Executed by once (ts.mu locked, the changes are committed with the response)
+func lawsuitCreateChecked(ts *TrialStore, req protocol.TrialCreateActionRequest) protocol.TrialCreateActionResponse {
+	resp := protocol.TrialCreateActionResponse{
+		Envelope:     req.Reply(localSender),
+		Success:      false,
+		DistrictID:   ts.state.DistrictID,
+		DistrictName: ts.state.DistrictName,
+		TrialID:      ts.state.TrialID,
+		TrialAddr:    ts.state.TrialAddr,
+	}
+
+	if req.Lawsuit.Plaintiff == "" || req.Lawsuit.Defendant == "" || req.Lawsuit.CauseID == 0 || len(req.Lawsuit.Claims) == 0 {
+		resp.Message = "insufficient data for the lawsuit in the lawsuit_create_checked"
+	} else {
+		by := auditOf(req.Envelope, req.Actor, req.District, req.Reason, req.Related)
+		new_lawsuit, conflict := ts.createCheckedLocked(req.Lawsuit, by)
+		switch {
+		case conflict != nil:
+			resp.Conflict = true
+			resp.ConflictMatch = conflict.Match
+			resp.ConflictLawsuitID = conflict.LawsuitID
+			resp.Message = fmt.Sprintf("lawsuit NOT created: conflict with the lawsuit %s (%s)", conflict.LawsuitID, conflict.Match)
+		default:
+			resp.Success = true
+			resp.LawsuitID = new_lawsuit.ID
+			switch req.Reason {
+			case "free":
+				resp.Message = "lawsuit created by free distribution"
+			case "repeated_request":
+				resp.Message = fmt.Sprintf("lawsuit created as REPEATED REQUEST (related to the lawsuit %s)", req.Related)
+			case "connection":
+				resp.Message = fmt.Sprintf("lawsuit created as CONNECTED to the lawsuit %s", req.Related)
+			default:
+				resp.Message = "lawsuit created"
+			}
+		}
+	}
+
+	log.Printf("[TRIAL] trace=%s lawsuit_create_checked reason=%s success=%v conflict=%s/%s lawsuit_id=%s",
+		req.TraceID, req.Reason, resp.Success, resp.ConflictMatch, resp.ConflictLawsuitID, resp.LawsuitID)
+	return resp
+}
+
+func handleLawsuitMergeClaims(w transport.Replier, data []byte, ts *TrialStore) {
+	var req protocol.TrialMergeClaimsRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		log.Printf("Error while decoding TrialMergeClaimsRequest from %s: %v", w.Remote(), err)
+		return
+	}
+
+	b, err := ts.once(req.RequestID, req.Type, func() any { return lawsuitMergeClaims(ts, req) })
+	if err != nil {
+		log.Printf("Error while executing lawsuit_merge_claims for %s: %v", w.Remote(), err)
+		return
+	}
+	if err := w.Reply(b); err != nil {
+		log.Printf("Error while sending response lawsuit_merge_claims to %s: %v", w.Remote(), err)
+		return
+	}
+
+	log.Printf("[TRIAL] trace=%s lawsuit_merge_claims lawsuit_id=%s request_id=%s to %s",
+		req.TraceID, req.LawsuitID, req.RequestID, w.Remote())
+}
+
+// Executed by once (ts.mu locked, the changes are committed with the response)
+func lawsuitMergeClaims(ts *TrialStore, req protocol.TrialMergeClaimsRequest) protocol.TrialMergeClaimsResponse {
+	resp := protocol.TrialMergeClaimsResponse{
+		Envelope: req.Reply(localSender),
+		Success:  false,
+		Message:  "",
+	}
+
+	if req.LawsuitID == "" || len(req.NewClaims) == 0 {
+		resp.Message = "Invalid lawsuit_id or new_claims in the lawsuit_merge_claims"
+	} else {
+		by := auditOf(req.Envelope, req.Actor, req.District, protocol.StageJoinder, "")
+		if err := ts.addClaimsLocked(req.LawsuitID, req.NewClaims, by); err != nil {
+			resp.Message = fmt.Sprintf("error while merging claims to the lawsuit %s: %v", req.LawsuitID, err)
+		} else {
+			resp.Success = true
+			resp.Message = fmt.Sprintf("claims were merged with success to the lawsuit %s", req.LawsuitID)
+		}
+	}
+	return resp
+}
+
+
+// ---------- Handlers: lawsuit_export / lawsuit_import / lawsuit_transferred ----------
+
+// Handler to lawsuit_export (source of a transfer: the lawsuit is not changed)
+func handleLawsuitExport(w transport.Replier, data []byte, ts *TrialStore) {
+	var req protocol.TrialExportRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		log.Printf("Error while decoding TrialExportRequest from %s: %v", w.Remote(), err)
+		return
+	}
+
+	resp := protocol.TrialExportResponse{Envelope: req.Reply(localSender)}
+	if a, err := ts.ExportLawsuit(strings.TrimSpace(req.LawsuitID), req.Reason); err != nil {
+		resp.Message = fmt.Sprintf("the lawsuit cannot be transferred: %v", err)
+	} else {
+		t := transferOf(a)
+		resp.Success = true
+		resp.Lawsuit = &t
+		resp.Message = fmt.Sprintf("lawsuit %s exported", a.ID)
+	}
+
+	b, err := json.Marshal(resp)
+	if err != nil {
+		log.Printf("Error while decoding TrialExportResponse to %s: %v", w.Remote(), err)
+		return
+	}
+	if err := w.Reply(b); err != nil {
+		log.Printf("Error while sending response lawsuit_export to %s: %v", w.Remote(), err)
+		return
+	}
+
+	log.Printf("[TRIAL] trace=%s lawsuit_export lawsuit_id=%s success=%v msg=%q to %s",
+		req.TraceID, req.LawsuitID, resp.Success, resp.Message, w.Remote())
+}
+
+// Handler to lawsuit_import (target of a transfer)
+func handleLawsuitImport(w transport.Replier, data []byte, ts *TrialStore) {
+	var req protocol.TrialImportRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		log.Printf("Error while decoding TrialImportRequest from %s: %v", w.Remote(), err)
+		return
+	}
+
+	b, err := ts.once(req.RequestID, req.Type, func() any { return lawsuitImport(ts, req) })
+	if err != nil {
+		log.Printf("Error while executing lawsuit_import for %s: %v", w.Remote(), err)
+		return
+	}
+	if err := w.Reply(b); err != nil {
+		log.Printf("Error while sending response lawsuit_import to %s: %v", w.Remote(), err)
+		return
+	}
+
+	log.Printf("[TRIAL] trace=%s lawsuit_import lawsuit_id=%s request_id=%s to %s",
+		req.TraceID, req.Lawsuit.ID, req.RequestID, w.Remote())
+}
+
+// Executed by once (ts.mu locked, the changes are committed with the response)
+func lawsuitImport(ts *TrialStore, req protocol.TrialImportRequest) protocol.TrialImportResponse {
+	resp := protocol.TrialImportResponse{
+		Envelope:     req.Reply(localSender),
+		DistrictID:   ts.state.DistrictID,
+		DistrictName: ts.state.DistrictName,
+		TrialID:      ts.state.TrialID,
+		TrialAddr:    ts.state.TrialAddr,
+	}
+
+	by := auditOf(req.Envelope, req.Actor, req.District, req.Reason, "")
+	a, err := ts.importLocked(lawsuitOfTransfer(req.Lawsuit), by)
+	if err != nil {
+		resp.Message = fmt.Sprintf("error while importing the lawsuit %s: %v", req.Lawsuit.ID, err)
+	} else {
+		resp.Success = true
+		resp.LawsuitID = a.ID
+		resp.Message = fmt.Sprintf("lawsuit %s imported as %s", a.TransferredFrom, a.ID)
+	}
+
+	log.Printf("[TRIAL] trace=%s lawsuit_import reason=%s success=%v lawsuit_id=%s (from %s)",
+		req.TraceID, req.Reason, resp.Success, resp.LawsuitID, req.Lawsuit.ID)
+	return resp
+}
+
+// Handler to lawsuit_transferred (source of a transfer, after the import)
+func handleLawsuitTransferred(w transport.Replier, data []byte, ts *TrialStore) {
+	var req protocol.TrialTransferredRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		log.Printf("Error while decoding TrialTransferredRequest from %s: %v", w.Remote(), err)
+		return
+	}
+
+	b, err := ts.once(req.RequestID, req.Type, func() any { return lawsuitTransferred(ts, req) })
+	if err != nil {
+		log.Printf("Error while executing lawsuit_transferred for %s: %v", w.Remote(), err)
+		return
+	}
+	if err := w.Reply(b); err != nil {
+		log.Printf("Error while sending response lawsuit_transferred to %s: %v", w.Remote(), err)
+		return
+	}
+
+	log.Printf("[TRIAL] trace=%s lawsuit_transferred lawsuit_id=%s new_id=%s request_id=%s to %s",
+		req.TraceID, req.LawsuitID, req.NewID, req.RequestID, w.Remote())
+}
+
+// Executed by once (ts.mu locked, the changes are committed with the response)
+func lawsuitTransferred(ts *TrialStore, req protocol.TrialTransferredRequest) protocol.TrialTransferredResponse {
+	resp := protocol.TrialTransferredResponse{Envelope: req.Reply(localSender)}
+
+	by := auditOf(req.Envelope, req.Actor, req.District, req.Reason, "")
+	if _, err := ts.markTransferredLocked(req.LawsuitID, req.NewID, by); err != nil {
+		resp.Message = fmt.Sprintf("error while registering the transfer of the lawsuit %s: %v", req.LawsuitID, err)
+	} else {
+		resp.Success = true
+		resp.Message = fmt.Sprintf("lawsuit %s transferred (now %s)", req.LawsuitID, req.NewID)
+	}
+	return resp
+}
+
+// Treats claims of search_Lasuit from district.
+func handleSearchLawsuit(w transport.Replier, data []byte, ts *TrialStore) {
+	var req protocol.TrialSearchLawsuitsRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		log.Printf("Error while decoding TrialSearchLawsuitsRequest from %s: %v", w.Remote(), err)
+		return
+	}
+
+	districtID, trialID := ts.GetIDs()
+	districtName := ts.GetDistrictName()
+	trialAddr := ts.GetTrialAddr()
+
+	resp := protocol.TrialSearchLawsuitsResponse{
+		Envelope:     req.Reply(localSender),
+		Success:     true,
+		Message:     "",
+		DistrictID:   districtID,
+		DistrictName: districtName,
+		TrialID:      trialID,
+		TrialAddr:    trialAddr,
+		Results:      []protocol.TrialSearchResult{},
+	}
+
+	var results []SearchResult
+	var err error
+	if req.Query != "" {
+		var q query.Node
+		if q, err = query.Parse(req.Query); err == nil {
+			results = ts.SearchQuery(q)
+		}
+	} else {
+		results, err = ts.SearchLawsuits(req.Field, req.Value)
+	}
+	var page []SearchResult
+	if err == nil {
+		resp.Total = len(results)
+		page, resp.NextCursor, err = pageSearchResults(results, req.Sort, req.Cursor, req.Limit)
+	}
+	if err != nil {
+		resp.Success = false
+		resp.Message = fmt.Sprintf("error while searching for lawsuits: %v", err)
+	} else {
+		resp.Message = fmt.Sprintf("%d lawsuits found", len(results))
+
+		for _, r := range page {
+			a := r.Lawsuit
+			resp.Results = append(resp.Results, protocol.TrialSearchResult{
+				List:        r.List,
+				ID:          a.ID,
+				Plaintiff:   a.Plaintiff,
+				Defendant:   a.Defendant,
+				CauseAction: a.CauseAction,
+				Claims:      append([]int(nil), a.Claims...),
+				History:     a.History,
+
+				DistrictID:   districtID,
+				DistrictName: districtName,
+				TrialID:      trialID,
+				TrialAddr:    trialAddr,
+			})
+		}
+	}
+
+	b, err := json.Marshal(resp)
+	if err != nil {
+		log.Printf("Error while decoding TrialSearchLawsuitsResponse to %s: %v", w.Remote(), err)
+		return
+	}
+	if err := w.Reply(b); err != nil {
+		log.Printf("Error while sending response search_lawsuit to %s: %v", w.Remote(), err)
+		return
+	}
+
+	log.Printf("[TRIAL] trace=%s search_lawsuit field=%s value=%q query=%q sort=%s limit=%d results=%d total=%d more=%v to %s",
+		req.TraceID, req.Field, req.Value, req.Query, req.Sort, req.Limit, len(resp.Results), resp.Total, resp.NextCursor != "", w.Remote())
+}
+
+// Handler to docket_as_of (lists of the trial at a past moment)
+func handleDocketAsOf(w transport.Replier, data []byte, ts *TrialStore) {
+	var req protocol.TrialDocketAsOfRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		log.Printf("Error while decoding TrialDocketAsOfRequest from %s: %v", w.Remote(), err)
+		return
+	}
+
+	districtID, trialID := ts.GetIDs()
+	districtName := ts.GetDistrictName()
+	trialAddr := ts.GetTrialAddr()
+
+	resp := protocol.TrialDocketAsOfResponse{
+		Envelope:     req.Reply(localSender),
+		At:           req.At,
+		DistrictID:   districtID,
+		DistrictName: districtName,
+		TrialID:      trialID,
+		TrialAddr:    trialAddr,
+	}
+	if req.At.IsZero() {
+		resp.Message = "the moment of the query (at) is missing"
+	} else {
+		d := ts.DocketAt(req.At)
+		results := func(lawsuits []Lawsuit, status string) []protocol.TrialSearchResult {
+			res := []protocol.TrialSearchResult{}
+			for _, a := range lawsuits {
+				res = append(res, protocol.TrialSearchResult{
+					List:        statusLabel(status),
+					ID:          a.ID,
+					Plaintiff:   a.Plaintiff,
+					Defendant:   a.Defendant,
+					CauseAction: a.CauseAction,
+					Claims:      append([]int(nil), a.Claims...),
+					History:     a.History,
+
+					DistrictID:   districtID,
+					DistrictName: districtName,
+					TrialID:      trialID,
+					TrialAddr:    trialAddr,
+				})
+			}
+			return res
+		}
+		resp.Success = true
+		resp.Actives = results(d.ByStatus[StatusActive], StatusActive)
+		resp.DismissedWithMerit = results(d.ByStatus[StatusDisWithMerit], StatusDisWithMerit)
+		resp.DismissedWithoutMerit = results(d.ByStatus[StatusDisWithoutMerit], StatusDisWithoutMerit)
+		for _, st := range []string{StatusSuspended, StatusArchived, StatusOnAppeal} {
+			resp.Others = append(resp.Others, results(d.ByStatus[st], st)...)
+		}
+		resp.Undated = d.Undated
+		resp.Message = fmt.Sprintf("%d active, %d suspended/archived/on appeal, %d dismissed with merit, %d dismissed without merit at %s",
+			len(resp.Actives), len(resp.Others), len(resp.DismissedWithMerit), len(resp.DismissedWithoutMerit), req.At.Format(time.RFC3339))
+	}
+
+	b, err := json.Marshal(resp)
+	if err != nil {
+		log.Printf("Error while decoding TrialDocketAsOfResponse to %s: %v", w.Remote(), err)
+		return
+	}
+	if err := w.Reply(b); err != nil {
+		log.Printf("Error while sending response docket_as_of to %s: %v", w.Remote(), err)
+		return
+	}
+
+	log.Printf("[TRIAL] trace=%s docket_as_of at=%s success=%v (%s) to %s",
+		req.TraceID, req.At.Format(time.RFC3339), resp.Success, resp.Message, w.Remote())
+}
+
+// Handler to workload_info (workload verification by the district)
+func handleWorkloadInfo(w transport.Replier, data []byte, ts *TrialStore) {
+	var req protocol.WorkloadInfoRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		log.Printf("Error while decoding WorkloadInfoRequest from %s: %v", w.Remote(), err)
+		return
+	}
+
+	districtID, trialID := ts.GetIDs()
+	districtName := ts.GetDistrictName()
+	trialAddr := ts.GetTrialAddr()
+	workload := ts.CountActives()
+	received := ts.CountPendingNotActive(req.Received)
+
+	resp := protocol.WorkloadInfoResponse{
+		Envelope:        req.Reply(localSender),
+		Success:         true,
+		Message:         "Trial's workload successfully returned.",
+		DistrictID:      districtID,
+		DistrictName:    districtName,
+		TrialID:         trialID,
+		TrialAddr:       trialAddr,
+		ActiveWorkload:  workload,
+		Received:        received,
+	}
+
+	b, err := json.Marshal(resp)
+	if err != nil {
+		log.Printf("Error while decoding WorkloadInfoResponse for %s: %v", w.Remote(), err)
+		return
+	}
+	if err := w.Reply(b); err != nil {
+		log.Printf("Error while sending response workload_info to %s: %v", w.Remote(), err)
+		return
+	}
+
+	log.Printf("[TRIAL] trace=%s workload_info sent to %s (workload=%d received=%d/%d)", req.TraceID, w.Remote(), workload, received, len(req.Received))
+}
+
+
+// ---------- Generic protocol (fallback) ----------
+
+// Serves the district's requests to ts at addr. With "mem://" addresses the
+// trial runs in the process of its district (simulations and tests).
+func Serve(ts *TrialStore, addr string) (transport.Listener, error) {
+	return transport.Listen(tport, addr, func(w transport.Replier, data []byte) {
+		go handlePacket(w, data, ts)
+	})
+}
+
+func handlePacket(w transport.Replier, data []byte, ts *TrialStore) {
+	log.Printf("[REQ] %s - package received from %s (%d bytes)",
+		time.Now().Format(time.RFC3339), w.Remote(), len(data))
+
+	var base struct {
+		protocol.Envelope
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &base); err != nil {
+		log.Printf("Error while decoding message type from %s: %v", w.Remote(), err)
+		resp := protocol.GenericResponse{
+			Success: false,
+			Message: "error while decoding trial's message",
+		}
+		b, _ := json.Marshal(resp)
+		_ = w.Reply(b)
+		return
+	}
+
+	switch base.Type {
+	case "lawsuit_query":
+		handleLawsuitQuery(w, data, ts)
+	case "lawsuit_classify":
+		handleLawsuitClassify(w, data, ts)
+	case "lawsuit_create":
+		handleLawsuitCreate(w, data, ts)
+	case "lawsuit_create_checked":
+		handleLawsuitCreateChecked(w, data, ts)
+	case "lawsuit_merge_claims":
+		handleLawsuitMergeClaims(w, data, ts)
+	case "search_lawsuit":
+		handleSearchLawsuit(w, data, ts)
+	case "workload_info":
+		handleWorkloadInfo(w, data, ts)
+	case "docket_as_of":
+		handleDocketAsOf(w, data, ts)
+	case "lawsuit_export":
+		handleLawsuitExport(w, data, ts)
+	case "lawsuit_import":
+		handleLawsuitImport(w, data, ts)
+	case "lawsuit_transferred":
+		handleLawsuitTransferred(w, data, ts)
+	default:
+		resp := protocol.GenericResponse{
+			Envelope: base.Reply(localSender),
+			Success: true,
+			Message: "Trial received message, but the type is not recognized by the lawsuits' logic.",
+		}
+		b, err := json.Marshal(resp)
+		if err != nil {
+			log.Printf("Error while decoding generic response: %v", err)
+			return
+		}
+		if err := w.Reply(b); err != nil {
+			log.Printf("Error while sending generic response: %v", err)
+			return
+		}
+		log.Printf("[RESP] %s - generic response sent to %s", time.Now().Format(time.RFC3339), w.Remote())
+	}
+}
+
+// ---------- Interactive Menu ----------
+
+// History of the lawsuit, one change per line, below the lawsuit
+func printHistory(a Lawsuit) {
+	for _, h := range a.History {
+		fmt.Println("    " + h.String())
+	}
+}
+
+func startMenu(ts *TrialStore, quit chan bool) {
+	reader := bufio.NewReader(os.Stdin)
+
+	for {
+		workload := ts.CountActives()
+		districtID, trialID := ts.GetIDs()
+		districtName := ts.GetDistrictName()
+
+		// Header with "CIVEL TRIAL number"
+		title := "CIVEL TRIAL"
+		if trialID > 0 {
+			title = fmt.Sprintf("CIVEL TRIAL %d", trialID)
+		}
+
+		fmt.Println()
+		fmt.Printf("========== %s ==========\n", title)
+		if districtName != "" && districtID > 0 {
+			fmt.Printf("(District: %s - ID: %d)\n", districtName, districtID)
+		} else if districtName != "" {
+			fmt.Printf("(District: %s)\n", districtName)
+		} else if districtID > 0 {
+			fmt.Printf("(District ID: %d)\n", districtID)
+		}
+		fmt.Printf("Workload (actives lawsuits): %d\n", workload)
+		fmt.Println("1 (L) - List lawsuits (actives, dismissed or gathered)")
+		fmt.Println("2 (F) - Finish lawsuit")
+		fmt.Println("3 (S) - Search lawsuit")
+		fmt.Println("4 (Q) - Quit")
+		fmt.Println("5 (R) - Refresh (clear screen)")
+		fmt.Println("6 (T) - Lawsuits at a past date")
+		fmt.Println("7 (U) - Change the status of a lawsuit (suspend, archive, appeal, reopen)")
+		fmt.Print("Your option> ")
+
+		line, _ := reader.ReadString('\n')
+		opt := strings.TrimSpace(line)
+
+		switch opt {
+
+		case "5","r", "R":
+			console.ClearScreen()
+			continue
+
+		case "1", "l", "L":
+			for {
+				console.ClearScreen()
+				fmt.Println("\n--- LIST LAWSUITS ---")
+				fmt.Println("1 (A) - List active lawsuits")
+				fmt.Println("2 (W) - List lawsuits dismissed WITH merit judgment")
+				fmt.Println("3 (O) - List lawsuit dismissed WITHOUT merit judgment")
+				fmt.Println("4 (G) - List gathered lawsuits (connected)")
+				fmt.Println("5 (S) - List suspended, provisionally archived, on appeal and transferred lawsuits")
+				fmt.Println("6 (R) - Return to main menu")
+				fmt.Print("Your option> ")
+
+				subLine, _ := reader.ReadString('\n')
+				SubOpt := strings.TrimSpace(subLine)
+
+				if SubOpt == "6" || SubOpt == "r" || SubOpt == "R" {
+					break
+				}
+
+				switch SubOpt {
+				case "1", "a", "A":
+					actives := ts.GetActives()
+					if len(actives) == 0 {
+						fmt.Println("(No active lawsuits)")
+					} else {
+						fmt.Println("\n--- ACTIVE LAWSUITS ---")
+						for _, a := range actives {
+							fmt.Printf("ID: %s | Plaintiff: %s | Defendant: %s | Cause: %d | Claims: %v\n",
+								a.ID, a.Plaintiff, a.Defendant, a.CauseAction, a.Claims)
+							printHistory(a)
+						}
+					}
+				case "2", "w", "W":
+					ext := ts.GetDisWithMerit()
+					if len(ext) == 0 {
+						fmt.Println("(No lawsuits dismissed with merit judgment)")
+					} else {
+						fmt.Println("\n--- LAWSUITS DISMISSED WITH MERIT JUDGMENT ---")
+						for _, a := range ext {
+							fmt.Printf("ID: %s | Plaintiff: %s | Defendant: %s | Cause: %d | Claims: %v\n",
+								a.ID, a.Plaintiff, a.Defendant, a.CauseAction, a.Claims)
+							printHistory(a)
+						}
+					}
+				case "3", "o", "O":
+					ext := ts.GetDisWithoutMerit()
+					if len(ext) == 0 {
+						fmt.Println("(no lawsuits dismissed without merit judgment)")
+					} else {
+						fmt.Println("\n--- LAWSUITS DISMISSED WITHOUT MERIT JUDGMENT ---")
+						for _, a := range ext {
+							fmt.Printf("ID: %s | Plaintiff: %s | Defendant: %s | Cause: %d | Claims: %v\n",
+								a.ID, a.Plaintiff, a.Defendant, a.CauseAction, a.Claims)
+							printHistory(a)
+						}
+					}
+				case "4", "g", "G":
+					actives := ts.GetActives()
+					found := false
+					fmt.Println("\n--- GATHERED LAWSUITS (CONNECTED) ---")
+					for _, a := range actives {
+						if len(a.Connected) > 0 {
+							found = true
+							fmt.Printf("ID: %s | Plaintiff: %s | Defendant: %s | Cause: %d | Claims: %v | Connected: %v\n",
+								a.ID, a.Plaintiff, a.Defendant, a.CauseAction, a.Claims, a.Connected)
+							printHistory(a)
+						}
+					}
+					if !found {
+						fmt.Println("(No gathered/connected lawsuit is registered)")
+					}
+				case "5", "s", "S":
+					for _, st := range []string{StatusSuspended, StatusArchived, StatusOnAppeal, StatusTransferred} {
+						fmt.Printf("\n--- %s LAWSUITS ---\n", strings.ToUpper(statusLabel(st)))
+						lawsuits := ts.GetByStatus(st)
+						if len(lawsuits) == 0 {
+							fmt.Println("(None)")
+						}
+						for _, a := range lawsuits {
+							fmt.Printf("ID: %s | Plaintiff: %s | Defendant: %s | Cause: %d | Claims: %v | Since: %s",
+								a.ID, a.Plaintiff, a.Defendant, a.CauseAction, a.Claims, a.StatusAt.Local().Format("2006-01-02 15:04:05"))
+							if a.TransferredTo != "" {
+								fmt.Printf(" | Now: %s", a.TransferredTo)
+							}
+							fmt.Println()
+							printHistory(a)
+						}
+					}
+				default:
+					fmt.Println("Invalid option in the list submenu.")
+				}
+
+				fmt.Print("\nPress ENTER to return to the list submenu...")
+				reader.ReadString('\n')
+				console.ClearScreen()
+			}
+
+		case "2", "f", "F":
+			// Finish lawsuit
+			fmt.Print("ID for the lawsuit that will be finished: ")
+			idStr, _ := reader.ReadString('\n')
+			idStr = strings.TrimSpace(idStr)
+			if idStr == "" {
+				fmt.Println("Empty ID. Operation cancelled.")
+				fmt.Print("\nPress ENTER to return to menu...")
+				reader.ReadString('\n')
+				console.ClearScreen()
+				continue
+			}
+
+			fmt.Print("Finish lawsuit WITH merit judgment? (y/n): ")
+			respStr, _ := reader.ReadString('\n')
+			respStr = strings.TrimSpace(strings.ToLower(respStr))
+			by := protocol.Audit{Actor: console.UserName(), District: ts.GetDistrictName()}
+
+			switch respStr {
+			case "y", "yes", "Y", "Yes" :
+				a, err := ts.DismissWithMerit(idStr, by)
+				if err != nil {
+					fmt.Println("Error while finishing the lawsuit with merit judgment:", err)
+				} else {
+					fmt.Printf("Lawsuit %s finished WITH merit judgment.\n", a.ID)
+				}
+			case "n", "no", "not", "N", "Not":
+				a, err := ts.DismissWithoutmerit(idStr, by)
+				if err != nil {
+					fmt.Println("Error while finishing lawsuit without merit judgment:", err)
+				} else {
+					fmt.Printf("Lawsuit %s finished WITHOUT merit judgment.\n", a.ID)
+				}
+			default:
+				fmt.Println("Resposta inválida. Use 's' para sim ou 'n' para não. Operação cancelada.")
+				fmt.Println("Invalid response. Use 'y' to yes or 'n' to no. Cancelled operation.")
+			}
+
+		case "3", "s", "S":
+			// Search lawsuit 
+			console.ClearScreen()
+			fmt.Println("\nSearch for:")
+			fmt.Println("1 (I) - Lawsuit ID")
+			fmt.Println("2 (P) - Plaintiff")
+			fmt.Println("3 (D) - Defendant")
+			fmt.Println("4 (C) - Cause of action (integer)")
+			fmt.Println("5 (M) - Claim (integer)")
+			fmt.Println("6 (Q) - Compound query (e.g.: defendant contains Banco AND cause=12 AND list=Active)")
+			fmt.Println("7 (R) - Return to menu")
+			fmt.Print("Your option> ")
+			fieldStr, _ := reader.ReadString('\n')
+			fieldStr = strings.TrimSpace(fieldStr)
+
+			var field string
+			switch fieldStr {
+			case "1", "i", "I":
+				field = "id"
+			case "2", "p", "P":
+				field = "plaintiff"
+			case "3", "d", "D":
+				field = "defendant"
+			case "4", "c", "C":
+				field = "cause"
+			case "5", "m", "M":
+				field = "claim"
+			case "6", "q", "Q":
+				field = "query"
+			case "7", "r", "R":
+				console.ClearScreen()
+				continue
+			default:
+				fmt.Println("\nInvalid field option.")
+				fmt.Print("\nPress ENTER to return ao menu...")
+				reader.ReadString('\n')
+				console.ClearScreen()
+				continue
+			}
+
+			fmt.Print("Search value> ")
+			val, _ := reader.ReadString('\n')
+			val = strings.TrimSpace(val)
+			if val == "" {
+				fmt.Println("\nEmptyh search value.")
+				fmt.Print("\nPress ENTER to return to menu...")
+				reader.ReadString('\n')
+				console.ClearScreen()
+				continue
+			}
+
+			var results []SearchResult
+			var err error
+			if field == "query" {
+				var q query.Node
+				if q, err = query.Parse(val); err == nil {
+					results = ts.SearchQuery(q)
+				}
+			} else {
+				results, err = ts.SearchLawsuits(field, val)
+			}
+			if err != nil {
+				fmt.Println("\nError in the search:", err)
+			} else if len(results) == 0 {
+				fmt.Println("\nNo lawsuit found.")
+			} else {
+				fmt.Println("\n--- SEARCH RESULTS ---")
+				for _, r := range results {
+					a := r.Lawsuit
+					fmt.Printf("[%s] ID: %s | Plaintiff: %s | Defendant: %s | Cause: %d | Claims: %v\n",
+						r.List, a.ID, a.Plaintiff, a.Defendant, a.CauseAction, a.Claims)
+					printHistory(a)
+				}
+			}
+
+		case "6", "t", "T":
+			// Lists as they were at a past moment
+			fmt.Print("Date (YYYY-MM-DD, or YYYY-MM-DD HH:MM)> ")
+			atStr, _ := reader.ReadString('\n')
+			at, err := parseMoment(atStr)
+			if err != nil {
+				fmt.Println("\n" + err.Error())
+				break
+			}
+
+			d := ts.DocketAt(at)
+			fmt.Printf("\n--- LAWSUITS AT %s ---\n", at.Format("2006-01-02 15:04:05"))
+			for _, st := range statusOrder {
+				fmt.Printf("\n%s (%d):\n", statusLabel(st), len(d.ByStatus[st]))
+				for _, a := range d.ByStatus[st] {
+					fmt.Printf("ID: %s | Plaintiff: %s | Defendant: %s | Cause: %d | Claims: %v\n",
+						a.ID, a.Plaintiff, a.Defendant, a.CauseAction, a.Claims)
+					printHistory(a)
+				}
+			}
+			if d.Undated > 0 {
+				fmt.Printf("\n(%d lawsuit(s) registered before the dates were kept are not shown)\n", d.Undated)
+			}
+
+		case "7", "u", "U":
+			// Status of a lawsuit (see statusTransitions)
+			fmt.Print("ID of the lawsuit: ")
+			idStr, _ := reader.ReadString('\n')
+			idStr = strings.TrimSpace(idStr)
+			a, next, err := ts.NextStatuses(idStr)
+			if err != nil {
+				fmt.Println("\nError:", err)
+				break
+			}
+			fmt.Printf("\nLawsuit %s is %s", a.ID, strings.ToUpper(statusLabel(a.Status)))
+			if !a.StatusAt.IsZero() {
+				fmt.Printf(" since %s", a.StatusAt.Local().Format("2006-01-02 15:04:05"))
+			}
+			fmt.Println(".")
+			if a.TransferredTo != "" {
+				fmt.Printf("It was transferred to another trial, where its ID is %s.\n", a.TransferredTo)
+			}
+			if len(next) == 0 {
+				fmt.Println("Its status cannot be changed.")
+				break
+			}
+			for i, st := range next {
+				fmt.Printf("%d - %s\n", i+1, statusLabel(st))
+			}
+			fmt.Print("New status (ENTER = cancel)> ")
+			optStr, _ := reader.ReadString('\n')
+			n, err := strconv.Atoi(strings.TrimSpace(optStr))
+			if err != nil || n < 1 || n > len(next) {
+				fmt.Println("Operation cancelled.")
+				break
+			}
+			by := protocol.Audit{Actor: console.UserName(), District: ts.GetDistrictName()}
+			if a, err = ts.SetStatus(idStr, next[n-1], by); err != nil {
+				fmt.Println("\nError while changing the status:", err)
+			} else {
+				fmt.Printf("\nLawsuit %s is now %s.\n", a.ID, strings.ToUpper(statusLabel(a.Status)))
+			}
+
+		case "4", "q", "Q":
+			if err := ts.Save(); err != nil {
+				log.Printf("\nError while saving lawsuits during quit: %v", err)
+			}
+			fmt.Println("\nData saved. Finishing the trial.")
+			quit <- true
+			return
+
+		default:
+			fmt.Println("\nInvalid option.")
+		}
+
+		// General pause before returning to menu
+		fmt.Print("\nPress ENTER to return to menu...")
+		reader.ReadString('\n')
+		console.ClearScreen()
+	}
+}
+
+
+// ---------- MAIN ----------
+// Main runs the trial agent with the command line arguments (after "trial")
+func Main(args []string) {
+	fs := flag.NewFlagSet("trial", flag.ExitOnError)
+	helpFlag := fs.Bool("h", false, "Show help")
+	infoFlag := fs.Bool("info", false, "Show information about option flags")
+	districtAddrFlag := fs.String("district", "", "District's address for this trial")
+	transportFlag := fs.String("transport", "udp", "Transport of the messages: udp, tcp, unix or mem")
+	trialIDFlag := fs.Int("id", 0, "Numeric ID for the trial (1, 2, 3, ...)")
+	logFlag := fs.String("log", "", "Log file (or 'term' to log to terminal; default: trial.log)")
+	lawsuitsFile := fs.String("lawsuits", "lawsuits.json", "JSON file  with the states for the trial's lawsuits")
+	storageFlag := fs.String("storage", "json", "Storage of the lawsuits: json (-lawsuits file and its journal) or kv (lawsuits.db, one record per lawsuit)")
+	fs.Parse(args)
+
+	if *helpFlag {
+		fmt.Println("Program used to simulate the functioning of a civel trial,")
+		fmt.Println("with lists of actives and dismissed lawsuits (with and without merit judgment)")
+		fmt.Println("and responding the distribution requests (res judicata, lis pendens, etc.).")
+		fmt.Println("\n Release: ",Release)
+		fmt.Println()
+		fmt.Println("Usage: judiciary trial [-h] [-info] -district <district's address> [-id <id_trial>]")
+		fmt.Println("            [-transport <udp|tcp|unix|mem>] [-log <file_name|term>] [-lawsuits <json_file>]")
+		fmt.Println("            [-storage <json|kv>]")
+		fmt.Println()
+		fmt.Println("The trial's address is get from the district (and mirrored on disc).")
+		fmt.Println("The transport must be the same used by the district.")
+		return
+	}
+
+	// Uses -info as the default behavior for -h
+	if *infoFlag {
+		fs.Usage()
+		os.Exit(0)
+	}
+
+	// LOG configuration
+	if *logFlag == "" {
+		logFile, err := os.OpenFile("trial.log",
+			os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			fmt.Println("Error while opening default log file (trial.log):", err)
+		} else {
+			log.SetOutput(logFile)
+		}
+	} else if *logFlag == "term" {
+		// default output (stderr)
+	} else {
+		logFile, err := os.OpenFile(*logFlag,
+			os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			fmt.Println("Error while opening log file:", err)
+		} else {
+			log.SetOutput(logFile)
+		}
+	}
+
+	t, err := transport.ByName(*transportFlag)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	tport = t
+
+	// Load the state (local mirror); with kv, lawsuits.json -> lawsuits.db
+	kvPath := strings.TrimSuffix(*lawsuitsFile, filepath.Ext(*lawsuitsFile)) + ".db"
+	store, err := storage.Open(*storageFlag, kvPath, map[string]string{keyLawsuits: *lawsuitsFile})
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	defer store.Close()
+	ts := NewTrialStore(*lawsuitsFile, store)
+	if err := ts.Load(); err != nil {
+		fmt.Println("Error while loading lawsuits from disc:", err)
+	}
+
+	// Resolve district's address: flag -> file
+	districtAddr := strings.TrimSpace(*districtAddrFlag)
+	if districtAddr == "" {
+		districtAddr = loadDistrictAddress(districtAddrFile)
+	} else {
+		actual := loadDistrictAddress(districtAddrFile)
+		if districtAddr != actual {
+			saveDistrictAddress(districtAddrFile, districtAddr)
+		}
+	}
+
+	if districtAddr == "" {
+		fmt.Println("Error: it was not possible to determine the district's UDP address (use -district or configure", districtAddrFile, ").")
+		return
+	}
+
+	// Determine TrialID: flag -> mirror
+	trialID := *trialIDFlag
+	if trialID <= 0 {
+		_, storedTrialID := ts.GetIDs()
+		trialID = storedTrialID
+	}
+
+	if trialID <= 0 {
+		fmt.Println("Error: it is necessary to inform the trial's ID (-id or already have an ID saved on disc).")
+		return
+	}
+
+	localSender = fmt.Sprintf("trial:%d@%s", trialID, districtAddr)
+
+	// Update the mirror with given TrialID (without modifying other things)
+	_ = ts.UpdateInfo(0, "", trialID, "")
+
+	// Handshake wht the district to get DistrictID, DistrictName, TrialAddr
+	if err := getInfoFromDistrict(districtAddr, trialID, ts); err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	// Trial's final address: the one that is in the mirror (from the district or from previous execution)
+	udpAddr := ts.GetTrialAddr()
+	if udpAddr == "" {
+		fmt.Println("Error: it was not possible to determine the trial's UDP address from the district or from the local mirror.")
+		return
+	}
+
+	districtID, finalTrialID := ts.GetIDs()
+	districtName := ts.GetDistrictName()
+	log.Printf("Initialization for TRIAL: DistrictID=%d, DistrictName=%q, TrialID=%d, TrialAddr=%s, DistrictAddr=%s",
+		districtID, districtName, finalTrialID, udpAddr, districtAddr)
+
+	console.ClearScreen()
+	time.Sleep(100 * time.Millisecond)
+	console.ClearScreen()
+	fmt.Printf("initialization for TRIAL: DistrictID=%d, DistrictName=%q, TrialID=%d, TrialAddr=%s, DistrictAddr=%s",
+		districtID, districtName, finalTrialID, udpAddr, districtAddr)
+	time.Sleep(2000 * time.Millisecond)
+	console.ClearScreen()
+
+	quit := make(chan bool)
+	go startMenu(ts, quit)
+
+	// Server for the district's requests
+	ln, err := Serve(ts, udpAddr)
+	if err != nil {
+		fmt.Println("Error while opening "+tport.Name()+":", err)
+		return
+	}
+	defer ln.Close()
+
+	if districtName != "" {
+		log.Printf("Trial server running on %s (Trial %d, District: %s, ID District=%d) - district on %s\n",
+			udpAddr, finalTrialID, districtName, districtID, districtAddr)
+	} else {
+		log.Printf("Trial server running on %s (DistrictID=%d, TrialID=%d) - district on %s\n",
+			udpAddr, districtID, finalTrialID, districtAddr)
+	}
+
+	<-quit
+}